@@ -0,0 +1,198 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte("\x1f\x8b")
+	tarMagic  = []byte("ustar")
+)
+
+// errTotalSize stops unpacking once the entries of the archives hold more bytes than allowed
+var errTotalSize = errors.New("the entries unpacked so far hold more bytes than the total size limit")
+
+// IsArchive answers if data is a zip (including jar, war and whl files), tar or gzip archive, judging by its magic bytes
+func IsArchive(data []byte) bool {
+	return bytes.HasPrefix(data, zipMagic) || bytes.HasPrefix(data, gzipMagic) || isTar(data)
+}
+
+const tarHeaderSize = 512
+
+func isTar(data []byte) bool {
+	return len(data) > 262 && bytes.HasPrefix(data[257:], tarMagic)
+}
+
+// Expand returns the additions along with the entries of the archives among them, which are unpacked in memory within the limits.
+// Entries are additions whose path is nested in the path of their archive, e.g. lib.jar!/config/app.properties.
+// Archives within archives are unpacked up to the depth limit. Archives for which shouldExpand answers false are not unpacked.
+// The total size limit is shared by all the archives among the additions, so that many archives cannot add up to an archive bomb.
+func Expand(additions []gitrepo.Addition, limits talismanrc.ArchiveConfig, shouldExpand func(gitrepo.Addition) bool, result *helpers.DetectionResults) []gitrepo.Addition {
+	if limits.MaxDepth <= 0 {
+		return additions
+	}
+	expanded := append([]gitrepo.Addition{}, additions...)
+	u := &unpacker{limits: limits, result: result}
+	for _, addition := range additions {
+		if !IsArchive(addition.Data) || !shouldExpand(addition) {
+			continue
+		}
+		u.container = addition.Path
+		expanded = append(expanded, u.entriesOf(addition, 1)...)
+	}
+	return expanded
+}
+
+// unpacker unpacks the archives of the repository, along with the archives within them, counting the bytes of all their entries
+type unpacker struct {
+	limits    talismanrc.ArchiveConfig
+	result    *helpers.DetectionResults
+	container gitrepo.FilePath
+	total     int64
+}
+
+func (u *unpacker) entriesOf(archive gitrepo.Addition, depth int) []gitrepo.Addition {
+	var entries []gitrepo.Addition
+	unpacked := 0
+	err := u.read(archive, func(name string, data []byte) {
+		entry := gitrepo.NewArchiveEntryAddition(archive, name, data)
+		entries = append(entries, entry)
+		unpacked++
+		if !IsArchive(data) {
+			return
+		}
+		if depth < u.limits.MaxDepth {
+			entries = append(entries, u.entriesOf(entry, depth+1)...)
+		} else {
+			u.result.Explain(u.container, "archive", "not unpacking %s, as it is nested in more than %d archive(s)", entry.Path, u.limits.MaxDepth)
+		}
+	})
+	if err != nil {
+		u.result.Explain(u.container, "archive", "stopped unpacking %s: %v", archive.Path, err)
+	}
+	u.result.Explain(u.container, "archive", "unpacked %d entries of %s", unpacked, archive.Path)
+	return entries
+}
+
+// read calls onEntry for each file of an archive
+func (u *unpacker) read(archive gitrepo.Addition, onEntry func(name string, data []byte)) error {
+	data := archive.Data
+	switch {
+	case bytes.HasPrefix(data, zipMagic):
+		return u.readZip(data, onEntry)
+	case isTar(data):
+		return u.readTar(bytes.NewReader(data), onEntry)
+	case bytes.HasPrefix(data, gzipMagic):
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		// tarballs are streamed, so that only their entries count towards the size limits
+		buffered := bufio.NewReader(reader)
+		if header, _ := buffered.Peek(tarHeaderSize); isTar(header) {
+			return u.readTar(buffered, onEntry)
+		}
+		uncompressed, err := u.readEntry(buffered, -1)
+		if err != nil {
+			return err
+		}
+		name := reader.Name
+		if name == "" {
+			name = strings.TrimSuffix(strings.TrimSuffix(string(archive.Name), ".gz"), ".tgz")
+		}
+		onEntry(name, uncompressed)
+	}
+	return nil
+}
+
+func (u *unpacker) readZip(data []byte, onEntry func(name string, data []byte)) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			u.result.Explain(u.container, "archive", "unable to read %s: %v", file.Name, err)
+			continue
+		}
+		data, err := u.readEntry(entry, int64(file.UncompressedSize64))
+		entry.Close()
+		if err == errTotalSize {
+			return err
+		}
+		if err != nil {
+			u.result.Explain(u.container, "archive", "not scanning %s: %v", file.Name, err)
+			continue
+		}
+		onEntry(cleanName(file.Name), data)
+	}
+	return nil
+}
+
+func (u *unpacker) readTar(data io.Reader, onEntry func(name string, data []byte)) error {
+	reader := tar.NewReader(data)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := u.readEntry(reader, header.Size)
+		if err == errTotalSize {
+			return err
+		}
+		if err != nil {
+			u.result.Explain(u.container, "archive", "not scanning %s: %v", header.Name, err)
+			continue
+		}
+		onEntry(cleanName(header.Name), data)
+	}
+}
+
+// readEntry reads an entry of an archive, unless it holds more bytes than allowed. The declared size is -1 when unknown.
+// As declared sizes may lie, the entry is never read beyond the limit.
+func (u *unpacker) readEntry(entry io.Reader, declaredSize int64) ([]byte, error) {
+	if declaredSize > u.limits.MaxEntrySize {
+		return nil, fmt.Errorf("its size of %d bytes exceeds the limit of %d bytes", declaredSize, u.limits.MaxEntrySize)
+	}
+	data, err := io.ReadAll(io.LimitReader(entry, u.limits.MaxEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > u.limits.MaxEntrySize {
+		return nil, fmt.Errorf("it holds more than the limit of %d bytes", u.limits.MaxEntrySize)
+	}
+	u.total += int64(len(data))
+	if u.total > u.limits.MaxTotalSize {
+		return nil, errTotalSize
+	}
+	return data, nil
+}
+
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
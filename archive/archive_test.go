@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var defaultLimits = (&talismanrc.TalismanRC{}).ArchiveLimits()
+
+var expandAll = func(gitrepo.Addition) bool { return true }
+
+func zipOf(t *testing.T, files map[string][]byte) []byte {
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	for name, data := range files {
+		entry, err := writer.Create(name)
+		assert.NoError(t, err)
+		_, err = entry.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func tgzOf(t *testing.T, files map[string][]byte) []byte {
+	buffer := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buffer)
+	writer := tar.NewWriter(gzipWriter)
+	for name, data := range files {
+		assert.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+		_, err := writer.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	assert.NoError(t, gzipWriter.Close())
+	return buffer.Bytes()
+}
+
+func pathsOf(additions []gitrepo.Addition) []gitrepo.FilePath {
+	var paths []gitrepo.FilePath
+	for _, addition := range additions {
+		paths = append(paths, addition.Path)
+	}
+	return paths
+}
+
+func TestShouldRecogniseArchivesByTheirMagicBytes(t *testing.T) {
+	assert.True(t, IsArchive(zipOf(t, map[string][]byte{"a.txt": []byte("a")})))
+	assert.True(t, IsArchive(tgzOf(t, map[string][]byte{"a.txt": []byte("a")})))
+	assert.False(t, IsArchive([]byte("PK is not enough")))
+	assert.False(t, IsArchive(nil))
+}
+
+func TestShouldAddTheEntriesOfArchivesUnderNestedPaths(t *testing.T) {
+	jar := gitrepo.NewAddition("lib/lib.jar", zipOf(t, map[string][]byte{"config/app.properties": []byte("db.password=hunter2!")}))
+	readme := gitrepo.NewAddition("README.md", []byte("# readme"))
+
+	expanded := Expand([]gitrepo.Addition{readme, jar}, defaultLimits, expandAll, helpers.NewDetectionResults())
+
+	assert.Equal(t, []gitrepo.FilePath{"README.md", "lib/lib.jar", "lib/lib.jar!/config/app.properties"}, pathsOf(expanded))
+	assert.Equal(t, []byte("db.password=hunter2!"), expanded[2].Data)
+	assert.Equal(t, gitrepo.FileName("app.properties"), expanded[2].Name)
+}
+
+func TestShouldUnpackArchivesWithinArchives(t *testing.T) {
+	jar := zipOf(t, map[string][]byte{"db.properties": []byte("password=hunter2!")})
+	war := gitrepo.NewAddition("app.war", zipOf(t, map[string][]byte{"WEB-INF/lib/db.jar": jar}))
+
+	expanded := Expand([]gitrepo.Addition{war}, defaultLimits, expandAll, helpers.NewDetectionResults())
+
+	assert.Equal(t, []gitrepo.FilePath{"app.war", "app.war!/WEB-INF/lib/db.jar", "app.war!/WEB-INF/lib/db.jar!/db.properties"}, pathsOf(expanded))
+}
+
+func TestShouldUnpackGzippedTarballs(t *testing.T) {
+	tgz := gitrepo.NewAddition("dist/release.tgz", tgzOf(t, map[string][]byte{"./release/.env": []byte("API_KEY=abc")}))
+
+	expanded := Expand([]gitrepo.Addition{tgz}, defaultLimits, expandAll, helpers.NewDetectionResults())
+
+	assert.Equal(t, []gitrepo.FilePath{"dist/release.tgz", "dist/release.tgz!/release/.env"}, pathsOf(expanded))
+}
+
+func TestShouldNotUnpackArchivesNestedBeyondTheDepthLimit(t *testing.T) {
+	jar := zipOf(t, map[string][]byte{"db.properties": []byte("password=hunter2!")})
+	war := gitrepo.NewAddition("app.war", zipOf(t, map[string][]byte{"WEB-INF/lib/db.jar": jar}))
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	expanded := Expand([]gitrepo.Addition{war}, talismanrc.ArchiveConfig{MaxDepth: 1, MaxEntrySize: 1024, MaxTotalSize: 1024}, expandAll, results)
+
+	assert.Equal(t, []gitrepo.FilePath{"app.war", "app.war!/WEB-INF/lib/db.jar"}, pathsOf(expanded))
+	assert.Contains(t, results.Explanations["app.war"], helpers.Explanation{Detector: "archive", Decision: "not unpacking app.war!/WEB-INF/lib/db.jar, as it is nested in more than 1 archive(s)"})
+}
+
+func TestShouldNotUnpackArchivesWhenTheDepthLimitIsNegative(t *testing.T) {
+	jar := gitrepo.NewAddition("lib.jar", zipOf(t, map[string][]byte{"app.properties": []byte("password=hunter2!")}))
+	limits := (&talismanrc.TalismanRC{Archives: talismanrc.ArchiveConfig{MaxDepth: -1}}).ArchiveLimits()
+
+	expanded := Expand([]gitrepo.Addition{jar}, limits, expandAll, helpers.NewDetectionResults())
+
+	assert.Equal(t, []gitrepo.FilePath{"lib.jar"}, pathsOf(expanded))
+}
+
+func TestShouldNotUnpackArchivesThatAreNotToBeExpanded(t *testing.T) {
+	jar := gitrepo.NewAddition("lib.jar", zipOf(t, map[string][]byte{"app.properties": []byte("password=hunter2!")}))
+
+	expanded := Expand([]gitrepo.Addition{jar}, defaultLimits, func(gitrepo.Addition) bool { return false }, helpers.NewDetectionResults())
+
+	assert.Equal(t, []gitrepo.FilePath{"lib.jar"}, pathsOf(expanded))
+}
+
+func TestShouldSkipEntriesLargerThanTheEntrySizeLimit(t *testing.T) {
+	jar := gitrepo.NewAddition("lib.jar", zipOf(t, map[string][]byte{"big.bin": bytes.Repeat([]byte("a"), 64), "small.txt": []byte("a")}))
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	expanded := Expand([]gitrepo.Addition{jar}, talismanrc.ArchiveConfig{MaxDepth: 1, MaxEntrySize: 32, MaxTotalSize: 1024}, expandAll, results)
+
+	assert.Equal(t, []gitrepo.FilePath{"lib.jar", "lib.jar!/small.txt"}, pathsOf(expanded))
+	assert.Contains(t, results.Explanations["lib.jar"], helpers.Explanation{Detector: "archive", Decision: "not scanning big.bin: its size of 64 bytes exceeds the limit of 32 bytes"})
+}
+
+func TestShouldStopUnpackingOnceTheTotalSizeLimitIsReached(t *testing.T) {
+	tgz := gitrepo.NewAddition("logs.tgz", tgzOf(t, map[string][]byte{"a.log": bytes.Repeat([]byte("a"), 32)}))
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	expanded := Expand([]gitrepo.Addition{tgz}, talismanrc.ArchiveConfig{MaxDepth: 1, MaxEntrySize: 1024, MaxTotalSize: 16}, expandAll, results)
+
+	assert.Equal(t, []gitrepo.FilePath{"logs.tgz"}, pathsOf(expanded))
+	assert.Contains(t, results.Explanations["logs.tgz"], helpers.Explanation{Detector: "archive", Decision: "stopped unpacking logs.tgz: the entries unpacked so far hold more bytes than the total size limit"})
+}
+
+func TestShouldShareTheTotalSizeLimitAcrossArchives(t *testing.T) {
+	first := gitrepo.NewAddition("first.jar", zipOf(t, map[string][]byte{"a.txt": bytes.Repeat([]byte("a"), 12)}))
+	second := gitrepo.NewAddition("second.jar", zipOf(t, map[string][]byte{"b.txt": bytes.Repeat([]byte("b"), 12)}))
+	third := gitrepo.NewAddition("third.jar", zipOf(t, map[string][]byte{"c.txt": bytes.Repeat([]byte("c"), 12)}))
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	expanded := Expand([]gitrepo.Addition{first, second, third}, talismanrc.ArchiveConfig{MaxDepth: 1, MaxEntrySize: 1024, MaxTotalSize: 30}, expandAll, results)
+
+	assert.Equal(t, []gitrepo.FilePath{"first.jar", "second.jar", "third.jar", "first.jar!/a.txt", "second.jar!/b.txt"}, pathsOf(expanded))
+	assert.Contains(t, results.Explanations["third.jar"], helpers.Explanation{Detector: "archive", Decision: "stopped unpacking third.jar: the entries unpacked so far hold more bytes than the total size limit"})
+}
@@ -10,6 +10,7 @@ import (
 type ChecksumCalculator interface {
 	SuggestTalismanRC(fileNamePatterns []string) string
 	CalculateCollectiveChecksumForPattern(fileNamePattern string) string
+	CalculateCollectiveChecksumForPatterns(fileNamePatterns []string) string
 }
 
 type checksumCalculator struct {
@@ -26,8 +27,13 @@ func NewChecksumCalculator(hasher utility.SHA256Hasher, gitAdditions []gitrepo.A
 func (cc *checksumCalculator) SuggestTalismanRC(fileNamePatterns []string) string {
 	var fileIgnoreConfigs []talismanrc.FileIgnoreConfig
 	result := strings.Builder{}
-	for _, pattern := range fileNamePatterns {
-		collectiveChecksum := cc.CalculateCollectiveChecksumForPattern(pattern)
+	for index, pattern := range fileNamePatterns {
+		if gitrepo.IsNegatedPattern(pattern) {
+			// Negated patterns exclude files from the patterns before them and carry no checksum of their own
+			fileIgnoreConfigs = append(fileIgnoreConfigs, talismanrc.IgnoreFileWithChecksum(pattern, ""))
+			continue
+		}
+		collectiveChecksum := cc.CalculateCollectiveChecksumForPatterns(withLaterNegations(fileNamePatterns, index))
 		if collectiveChecksum != "" {
 			fileIgnoreConfigs = append(fileIgnoreConfigs, talismanrc.IgnoreFileWithChecksum(pattern, collectiveChecksum))
 		}
@@ -39,12 +45,28 @@ func (cc *checksumCalculator) SuggestTalismanRC(fileNamePatterns []string) strin
 	return result.String()
 }
 
+func withLaterNegations(fileNamePatterns []string, index int) []string {
+	patterns := []string{fileNamePatterns[index]}
+	for _, later := range fileNamePatterns[index+1:] {
+		if gitrepo.IsNegatedPattern(later) {
+			patterns = append(patterns, later)
+		}
+	}
+	return patterns
+}
+
 // CalculateCollectiveChecksumForPattern calculates and returns the checksum for files matching the input pattern
 func (cc *checksumCalculator) CalculateCollectiveChecksumForPattern(fileNamePattern string) string {
+	return cc.CalculateCollectiveChecksumForPatterns([]string{fileNamePattern})
+}
+
+// CalculateCollectiveChecksumForPatterns calculates and returns the checksum for files matching the input patterns,
+// evaluated like the lines of a .gitignore file
+func (cc *checksumCalculator) CalculateCollectiveChecksumForPatterns(fileNamePatterns []string) string {
 	var patternPaths []string
 	currentCollectiveChecksum := ""
 	for _, file := range cc.allTrackedFiles {
-		if file.Matches(fileNamePattern) {
+		if file.MatchesAny(fileNamePatterns) {
 			patternPaths = append(patternPaths, string(file.Path))
 		}
 	}
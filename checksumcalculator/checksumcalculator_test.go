@@ -11,7 +11,7 @@ import (
 var defaultSHA256Hasher utility.SHA256Hasher
 
 func init() {
-	defaultSHA256Hasher = utility.MakeHasher("default", ".")
+	defaultSHA256Hasher, _ = utility.NewHashers().Make("default", gitrepo.RepoLocatedAt("."))
 }
 
 func TestNewChecksumCalculator(t *testing.T) {
@@ -78,6 +78,25 @@ func TestNewChecksumCalculator(t *testing.T) {
 	})
 }
 
+func TestCalculateCollectiveChecksumForPatterns(t *testing.T) {
+	gitAdditions := []gitrepo.Addition{
+		gitrepo.NewAddition("GitRepoPath1/GitRepoName1", nil),
+		gitrepo.NewAddition("GitRepoPath1/private.pem", nil),
+	}
+	cc := NewChecksumCalculator(defaultSHA256Hasher, gitAdditions)
+
+	t.Run("should exclude files matched by later negated patterns", func(t *testing.T) {
+		actualCC := cc.CalculateCollectiveChecksumForPatterns([]string{"GitRepoPath1/", "!*.pem"})
+
+		assert.Equal(t, cc.CalculateCollectiveChecksumForPattern("GitRepoPath1/GitRepoName1"), actualCC)
+		assert.NotEqual(t, cc.CalculateCollectiveChecksumForPattern("GitRepoPath1/"), actualCC)
+	})
+
+	t.Run("should return empty CollectiveChecksum when negated patterns exclude all files", func(t *testing.T) {
+		assert.Equal(t, "", cc.CalculateCollectiveChecksumForPatterns([]string{"GitRepoPath1/**", "!GitRepoPath1/"}))
+	})
+}
+
 func TestDefaultChecksumCalculator_SuggestTalismanRC(t *testing.T) {
 	t.Run("should return no suggestion for .talismanrc format when no matching file name patterns is sent", func(t *testing.T) {
 		gitAdditions := []gitrepo.Addition{
@@ -116,4 +135,19 @@ func TestDefaultChecksumCalculator_SuggestTalismanRC(t *testing.T) {
 
 		assert.Equal(t, expectedCC, actualCC)
 	})
+
+	t.Run("should exclude negated patterns from the checksums of earlier patterns", func(t *testing.T) {
+		gitAdditions := []gitrepo.Addition{
+			gitrepo.NewAddition("GitRepoPath1/GitRepoName1", nil),
+			gitrepo.NewAddition("GitRepoPath1/private.pem", nil),
+		}
+		cc := NewChecksumCalculator(defaultSHA256Hasher, gitAdditions)
+		expectedCC := "\n\x1b[33m.talismanrc format for given file names / patterns\x1b[0m\nfileignoreconfig:\n- filename: GitRepoPath1/\n  checksum: " +
+			cc.CalculateCollectiveChecksumForPattern("GitRepoPath1/GitRepoName1") +
+			"\n- filename: '!*.pem'\nversion: \"1.0\"\n"
+
+		actualCC := cc.SuggestTalismanRC([]string{"GitRepoPath1/", "!*.pem"})
+
+		assert.Equal(t, expectedCC, actualCC)
+	})
 }
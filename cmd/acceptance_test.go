@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"talisman/prompt"
 	"testing"
@@ -140,6 +141,39 @@ func TestShouldExitOneWhenSecretIsCommitted(t *testing.T) {
 	})
 }
 
+func TestShouldHonourTalismanrcWhenRunFromSubdirectory(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		options.Debug = false
+		options.GitHook = PreCommit
+		options.Scan = false
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("nested/dir/private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", `
+fileignoreconfig:
+- filename: nested/dir/private.pem
+  checksum: 413f79eea25d71377168bfc3957ef59d776df21b48ddff9ae2e0ccec6dda98b5
+`)
+		git.Add("*")
+		wd, _ := os.Getwd()
+		os.Chdir(filepath.Join(git.Root(), "nested", "dir"))
+		defer func() { os.Chdir(wd) }()
+		assert.Equal(t, 0, run(prompt.NewPromptContext(false, prompt.NewPrompt())), "Expected run() to discover the .talismanrc at the root of the repository")
+	})
+}
+
+func TestShouldExitWithErrorWhenGitCommandsFail(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		options.Debug = false
+		options.GitHook = PrePush
+		git.SetupBaselineFiles("simple-file")
+		wd, _ := os.Getwd()
+		os.Chdir(git.Root())
+		defer func() { os.Chdir(wd) }()
+		talismanInput = mockStdIn(git.EarliestCommit(), "1111111111111111111111111111111111111111")
+		assert.Equal(t, EXIT_ERROR, run(prompt.NewPromptContext(false, prompt.NewPrompt())), "Expected run() to return 2 as the pushed commit does not exist")
+	})
+}
+
 func TestShouldExitZeroWhenNonSecretIsCommittedButFileContainsSecretPreviously(t *testing.T) {
 	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
 		options.Debug = false
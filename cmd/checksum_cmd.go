@@ -2,37 +2,40 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"talisman/checksumcalculator"
 	"talisman/gitrepo"
 	"talisman/utility"
-
-	"github.com/sirupsen/logrus"
 )
 
 type ChecksumCmd struct {
 	fileNamePatterns []string
-	hasher           utility.SHA256Hasher
-	repoRoot         string
+	repo             gitrepo.GitRepo
 }
 
-func NewChecksumCmd(fileNamePatterns []string) *ChecksumCmd {
-	wd, _ := os.Getwd()
-	hasher := utility.MakeHasher("checksum", wd)
-	return &ChecksumCmd{fileNamePatterns: fileNamePatterns, hasher: hasher, repoRoot: wd}
+func NewChecksumCmd(repo gitrepo.GitRepo, fileNamePatterns []string) *ChecksumCmd {
+	return &ChecksumCmd{fileNamePatterns: fileNamePatterns, repo: repo}
 }
 
 func (s *ChecksumCmd) Run() int {
-	repo := gitrepo.RepoLocatedAt(s.repoRoot)
-	if s.hasher == nil {
-		logrus.Errorf("unable to start hasher")
-		return EXIT_FAILURE
+	repo := s.repo
+	hashers := utility.NewHashers()
+	defer hashers.Shutdown()
+	hasher, err := hashers.Make("checksum", repo)
+	if err != nil {
+		return exitWithError("Unable to calculate checksums", err)
 	}
 
-	gitTrackedFilesAsAdditions := repo.TrackedFilesAsAdditions()
-	gitTrackedFilesAsAdditions = append(gitTrackedFilesAsAdditions, repo.StagedAdditions()...)
+	gitTrackedFilesAsAdditions, err := repo.TrackedFilesAsAdditions()
+	if err != nil {
+		return exitWithError("Unable to read files tracked by git", err)
+	}
+	stagedAdditions, err := repo.StagedAdditions()
+	if err != nil {
+		return exitWithError("Unable to read staged changes", err)
+	}
+	gitTrackedFilesAsAdditions = append(gitTrackedFilesAsAdditions, stagedAdditions...)
 
-	cc := checksumcalculator.NewChecksumCalculator(s.hasher, gitTrackedFilesAsAdditions)
+	cc := checksumcalculator.NewChecksumCalculator(hasher, gitTrackedFilesAsAdditions)
 	rcSuggestion := cc.SuggestTalismanRC(s.fileNamePatterns)
 
 	if rcSuggestion != "" {
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"talisman/git_testing"
+	"talisman/gitrepo"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,7 +17,7 @@ func TestChecksumCalculatorShouldExitSuccess(t *testing.T) {
 		git.CreateFileWithContents("sample.txt", "password")
 		os.Chdir(git.Root())
 
-		checksumCmd := NewChecksumCmd([]string{"*.txt"})
+		checksumCmd := NewChecksumCmd(gitrepo.RepoLocatedAt(git.Root()), []string{"*.txt"})
 		assert.Equal(t, 0, checksumCmd.Run(), "Expected run() to return 0 as given patterns are found and .talsimanrc is suggested")
 		options.Checksum = ""
 	})
@@ -30,15 +31,13 @@ func TestChecksumCalculatorShouldExitFailure(t *testing.T) {
 		git.CreateFileWithContents("sample.txt", "password")
 		os.Chdir(git.Root())
 
-		checksumCmd := NewChecksumCmd([]string{"*.java"})
+		checksumCmd := NewChecksumCmd(gitrepo.RepoLocatedAt(git.Root()), []string{"*.java"})
 		assert.Equal(t, 1, checksumCmd.Run(), "Expected run() to return 1 as given patterns are found and .talsimanrc is suggested")
 		options.Checksum = ""
 	})
 }
 
-func TestChecksumCalculatorShouldExitFailureWhenHasherIsEmpty(t *testing.T) {
-	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
-		checksumCmd := ChecksumCmd{[]string{"*.java"}, nil, git.Root()}
-		assert.Equal(t, 1, checksumCmd.Run(), "Expected run() to return 1 because hasher failed to start")
-	})
+func TestChecksumCalculatorShouldExitFailureWhenHasherFailsToStart(t *testing.T) {
+	checksumCmd := NewChecksumCmd(gitrepo.RepoLocatedAt(t.TempDir()), []string{"*.java"})
+	assert.Equal(t, EXIT_ERROR, checksumCmd.Run(), "Expected run() to return 2 because hasher failed to start")
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/prompt"
+	"talisman/talismanrc"
+	"talisman/utility"
+)
+
+// CommitMsgHook checks the message of a commit that is about to be made, which git passes to the hook in a file
+type CommitMsgHook struct {
+	runner
+}
+
+// NewCommitMsgHook reads the message from the file given to a commit-msg hook, without the comments git strips before committing it
+func NewCommitMsgHook(repo gitrepo.GitRepo, messageFile string) (*CommitMsgHook, error) {
+	message, err := os.ReadFile(messageFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read commit message: %v", err)
+	}
+	message = gitrepo.CleanupCommitMessage(message, repo.CommentChar())
+	hook := &CommitMsgHook{*NewRunner(repo, nil, CommitMsg)}
+	hook.messages = []gitrepo.Addition{
+		gitrepo.NewMessageAddition(gitrepo.CommitMessagePrefix+filepath.Base(messageFile), []string{}, message),
+	}
+	return hook, nil
+}
+
+// Run validates the commit message. As there are no changed files to checksum, no file ignores need to be evaluated
+func (h *CommitMsgHook) Run(tRC *talismanrc.TalismanRC, promptContext prompt.PromptContext) int {
+	hashers := utility.NewHashers()
+	defer hashers.Shutdown()
+	return h.runWith(hashers, helpers.HistoricBlobEvaluator(tRC), tRC, promptContext)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"talisman/git_testing"
+	"talisman/gitrepo"
+	"talisman/prompt"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitMsgHookFailsForSecretsInMessage(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		messageFile := writeCommitMessage(t, "fix deployment\n\n"+awsAccessKeyIDExample+"\n")
+
+		commitMsgHook, err := NewCommitMsgHook(gitrepo.RepoLocatedAt(git.Root()), messageFile)
+
+		assert.NoError(t, err)
+		assert.Equal(t, EXIT_FAILURE, commitMsgHook.Run(&talismanrc.TalismanRC{}, prompt.NewPromptContext(false, prompt.NewPrompt())))
+		assert.True(t, commitMsgHook.results.HasFailures())
+		assert.NotEmpty(t, commitMsgHook.results.GetFailures(gitrepo.CommitMessagePrefix+"COMMIT_EDITMSG"))
+	})
+}
+
+func TestCommitMsgHookIgnoresCommentsAndAllowedPatterns(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		repo := gitrepo.RepoLocatedAt(git.Root())
+		promptContext := prompt.NewPromptContext(false, prompt.NewPrompt())
+
+		commented, err := NewCommitMsgHook(repo, writeCommitMessage(t, "fix deployment\n# "+awsAccessKeyIDExample+"\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, EXIT_SUCCESS, commented.Run(&talismanrc.TalismanRC{}, promptContext), "Comments are stripped by git before committing")
+
+		allowed, err := NewCommitMsgHook(repo, writeCommitMessage(t, "fix deployment\n\n"+awsAccessKeyIDExample+"\n"))
+		assert.NoError(t, err)
+		tRC := &talismanrc.TalismanRC{AllowedPatterns: []*talismanrc.Pattern{{Regexp: regexp.MustCompile("accessKey=.*")}}}
+		assert.Equal(t, EXIT_SUCCESS, allowed.Run(tRC, promptContext))
+	})
+}
+
+func TestCommitMsgHookReturnsErrorForMissingMessageFile(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		_, err := NewCommitMsgHook(gitrepo.RepoLocatedAt(git.Root()), filepath.Join(t.TempDir(), "missing"))
+		assert.Error(t, err)
+	})
+}
+
+func writeCommitMessage(t *testing.T, message string) string {
+	messageFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	assert.NoError(t, os.WriteFile(messageFile, []byte(message), 0644))
+	return messageFile
+}
@@ -13,7 +13,7 @@ type PatternCmd struct {
 	*runner
 }
 
-func NewPatternCmd(pattern string) *PatternCmd {
+func NewPatternCmd(repo gitrepo.GitRepo, pattern string) *PatternCmd {
 	var additions []gitrepo.Addition
 
 	files, _ := doublestar.Glob(pattern)
@@ -30,5 +30,5 @@ func NewPatternCmd(pattern string) *PatternCmd {
 		additions = append(additions, newAddition)
 	}
 
-	return &PatternCmd{NewRunner(additions, "pattern")}
+	return &PatternCmd{NewRunner(repo, additions, "pattern")}
 }
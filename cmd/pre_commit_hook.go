@@ -1,8 +1,6 @@
 package main
 
 import (
-	"os"
-
 	"talisman/gitrepo"
 )
 
@@ -10,9 +8,10 @@ type PreCommitHook struct {
 	runner
 }
 
-func NewPreCommitHook() *PreCommitHook {
-	wd, _ := os.Getwd()
-	repo := gitrepo.RepoLocatedAt(wd)
-
-	return &PreCommitHook{*NewRunner(repo.GetDiffForStagedFiles(), PreCommit)}
+func NewPreCommitHook(repo gitrepo.GitRepo) (*PreCommitHook, error) {
+	additions, err := repo.GetDiffForStagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return &PreCommitHook{*NewRunner(repo, additions, PreCommit)}, nil
 }
@@ -2,12 +2,16 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"talisman/detector/helpers"
 	"talisman/gitrepo"
+	"talisman/prompt"
+	"talisman/talismanrc"
+	"talisman/utility"
 )
 
 const (
@@ -17,77 +21,206 @@ const (
 	EmptyTreeSha string = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
 )
 
-type PrePushHook struct {
+// refUpdate represents a single line of the input of a pre-push hook, i.e. a ref that is about to be pushed
+type refUpdate struct {
 	localRef, localCommit, remoteRef, remoteCommit string
+}
+
+// refPush holds the run checking the additions of a refUpdate
+type refPush struct {
+	refUpdate
 	*runner
+	// alreadyChecked counts the additions of the ref that are skipped, as they were checked for an earlier ref
+	alreadyChecked int
+	// scope describes which changes of a new ref are checked
+	scope string
+}
+
+type PrePushHook struct {
+	repo   gitrepo.GitRepo
+	pushes []*refPush
+}
+
+// NewPrePushHook reads all ref updates that are about to be pushed and computes the additions of each of them.
+// Additions shared between refs, e.g. when pushing a branch together with a tag on it, are only checked for the first ref.
+func NewPrePushHook(repo gitrepo.GitRepo, stdin io.Reader) (*PrePushHook, error) {
+	updates, err := readRefUpdates(stdin)
+	if err != nil {
+		return nil, err
+	}
+	prePushHook := &PrePushHook{repo: repo}
+	checked := make(map[string]bool)
+	for _, update := range updates {
+		additions, scope, err := update.getRepoAdditions(repo)
+		if err != nil {
+			return nil, err
+		}
+		var messages []gitrepo.Addition
+		if options.ScanMessages {
+			messages, err = update.getMessageAdditions(repo)
+			if err != nil {
+				return nil, err
+			}
+		}
+		push := &refPush{refUpdate: update, scope: scope}
+		push.runner = NewRunner(repo, push.notYetChecked(additions, checked), PrePush)
+		push.messages = push.notYetChecked(messages, checked)
+		prePushHook.pushes = append(prePushHook.pushes, push)
+	}
+	return prePushHook, nil
+}
+
+// notYetChecked returns the additions that were not checked for an earlier ref, counting the others as already checked
+func (push *refPush) notYetChecked(additions []gitrepo.Addition, checked map[string]bool) []gitrepo.Addition {
+	var additionsToCheck []gitrepo.Addition
+	for _, addition := range additions {
+		key := string(addition.Path) + "\x00" + string(addition.Data)
+		if checked[key] {
+			push.alreadyChecked++
+			continue
+		}
+		checked[key] = true
+		additionsToCheck = append(additionsToCheck, addition)
+	}
+	return additionsToCheck
+}
+
+// Run checks the additions of every pushed ref, reporting the results of each ref separately when several refs are pushed
+func (p *PrePushHook) Run(tRC *talismanrc.TalismanRC, promptContext prompt.PromptContext) int {
+	hashers := utility.NewHashers()
+	defer hashers.Shutdown()
+	ie, err := helpers.BuildIgnoreEvaluator(hashers, PrePush, tRC, p.repo)
+	if err != nil {
+		return exitWithError("Unable to read files tracked by git", err)
+	}
+	exitStatus := EXIT_SUCCESS
+	for _, push := range p.pushes {
+		if len(p.pushes) > 1 {
+			fmt.Println(push.heading())
+		}
+		if push.scope != "" {
+			fmt.Println(push.scope)
+		}
+		if push.runWith(hashers, ie, tRC, promptContext) != EXIT_SUCCESS {
+			exitStatus = EXIT_FAILURE
+		}
+	}
+	return exitStatus
 }
 
-func NewPrePushHook(stdin io.Reader) *PrePushHook {
-	localRef, localCommit, remoteRef, remoteCommit := readRefAndSha(stdin)
-	prePushHook := &PrePushHook{
-		localRef,
-		localCommit,
-		remoteRef,
-		remoteCommit,
-		NewRunner(nil, PrePush)}
-	prePushHook.additions = prePushHook.getRepoAdditions()
-	return prePushHook
+func (push *refPush) heading() string {
+	heading := fmt.Sprintf("Talisman results for %s", push.localRef)
+	if push.alreadyChecked > 0 {
+		heading += fmt.Sprintf(" (skipping %d change(s) already checked for another ref)", push.alreadyChecked)
+	}
+	return heading
 }
 
-// If the outgoing ref does not exist on the remote, all commits on the local ref will be checked
-// If the outgoing ref already exists, all additions in the range between "localSha" and "remoteSha" will be validated
-func (p *PrePushHook) getRepoAdditions() []gitrepo.Addition {
-	if p.runningOnDeletedRef() {
-		log.WithFields(log.Fields{
-			"localRef":     p.localRef,
-			"localCommit":  p.localCommit,
-			"remoteRef":    p.remoteRef,
-			"remoteCommit": p.remoteCommit,
-		}).Info("Running on a deleted ref. Nothing to verify as outgoing changes are all deletions.")
+// If the outgoing ref does not exist on the remote, the changes of all commits on the local ref that are not on any remote-tracking ref
+// will be checked. Without any remote history, e.g. on the first push to a new remote, all files of the local ref will be checked.
+// If the outgoing ref already exists, all additions in the range between "localSha" and "remoteSha" will be validated.
+// For new refs, a description of the changes that are checked is returned along with them.
+func (u refUpdate) getRepoAdditions(repo gitrepo.GitRepo) ([]gitrepo.Addition, string, error) {
+	if u.runningOnDeletedRef() {
+		log.WithFields(u.logFields()).Info("Running on a deleted ref. Nothing to verify as outgoing changes are all deletions.")
+
+		return []gitrepo.Addition{}, "", nil
+	}
 
-		return []gitrepo.Addition{}
+	if u.runningOnNewRef() {
+		return u.getNewRefAdditions(repo)
 	}
 
-	if p.runningOnNewRef() {
-		log.WithFields(log.Fields{
-			"localRef":     p.localRef,
-			"localCommit":  p.localCommit,
-			"remoteRef":    p.remoteRef,
-			"remoteCommit": p.remoteCommit,
-		}).Info("Running on a new ref. All changes in the ref will be verified.")
+	log.WithFields(u.logFields()).Info("Running on an existing ref. All changes in the commit range will be verified.")
+
+	additions, err := repo.AdditionsWithinRange(u.remoteCommit, u.localCommit)
+	return additions, "", err
+}
 
-		return p.getRepoAdditionsFrom(EmptyTreeSha, p.localCommit)
+func (u refUpdate) getNewRefAdditions(repo gitrepo.GitRepo) ([]gitrepo.Addition, string, error) {
+	hasRemoteHistory, err := repo.HasRemoteHistory()
+	if err != nil {
+		return nil, "", err
 	}
+	if !hasRemoteHistory {
+		log.WithFields(u.logFields()).Info("Running on a new ref without any remote history. All files in the ref will be verified.")
 
-	log.WithFields(log.Fields{
-		"localRef":     p.localRef,
-		"localCommit":  p.localCommit,
-		"remoteRef":    p.remoteRef,
-		"remoteCommit": p.remoteCommit,
-	}).Info("Running on an existing ref. All changes in the commit range will be verified.")
+		additions, err := repo.AdditionsWithinRange(EmptyTreeSha, u.localCommit)
+		return additions, fmt.Sprintf("%s is a new ref and there is no remote history: checking all files", u.localRef), err
+	}
+
+	commits, err := repo.CommitsNotOnRemotes(u.localCommit)
+	if err != nil {
+		return nil, "", err
+	}
+	log.WithFields(u.logFields()).WithField("commits", len(commits)).Info("Running on a new ref. Changes in commits not on any remote-tracking ref will be verified.")
 
-	return p.getRepoAdditionsFrom(p.remoteCommit, p.localCommit)
+	additions, err := repo.AdditionsNotOnRemotes(u.localCommit)
+	return additions, fmt.Sprintf("%s is a new ref: checking the changes of %d commit(s) not on any remote-tracking ref", u.localRef, len(commits)), err
 }
 
-func (p *PrePushHook) runningOnDeletedRef() bool {
-	return p.localCommit == EmptySha
+// getMessageAdditions returns the messages of the outgoing commits, along with the annotation of an outgoing annotated tag.
+// As for the changes, if the ref does not exist on the remote, the messages of the commits on the local ref that are not on any
+// remote-tracking ref are returned.
+func (u refUpdate) getMessageAdditions(repo gitrepo.GitRepo) ([]gitrepo.Addition, error) {
+	if u.runningOnDeletedRef() {
+		return []gitrepo.Addition{}, nil
+	}
+	var messages []gitrepo.Addition
+	var err error
+	if u.runningOnNewRef() {
+		messages, err = repo.CommitMessagesNotOnRemotes(u.localCommit)
+	} else {
+		messages, err = repo.CommitMessagesWithinRange(u.remoteCommit, u.localCommit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(u.localRef, "refs/tags/") {
+		return messages, nil
+	}
+	annotations, err := repo.TagAnnotations(u.localRef)
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, annotations...), nil
 }
 
-func (p *PrePushHook) runningOnNewRef() bool {
-	return p.remoteCommit == EmptySha
+func (u refUpdate) logFields() log.Fields {
+	return log.Fields{
+		"localRef":     u.localRef,
+		"localCommit":  u.localCommit,
+		"remoteRef":    u.remoteRef,
+		"remoteCommit": u.remoteCommit,
+	}
+}
+
+func (u refUpdate) runningOnDeletedRef() bool {
+	return u.localCommit == EmptySha
 }
 
-func (p *PrePushHook) getRepoAdditionsFrom(oldCommit, newCommit string) []gitrepo.Addition {
-	wd, _ := os.Getwd()
-	repo := gitrepo.RepoLocatedAt(wd)
-	return repo.AdditionsWithinRange(oldCommit, newCommit)
+func (u refUpdate) runningOnNewRef() bool {
+	return u.remoteCommit == EmptySha
 }
 
-func readRefAndSha(file io.Reader) (string, string, string, string) {
-	text, _ := bufio.NewReader(file).ReadString('\n')
-	refsAndShas := strings.Split(strings.Trim(string(text), "\n"), " ")
-	if len(refsAndShas) < 4 {
-		return EmptySha, EmptySha, "", ""
+// readRefUpdates reads all lines given to a pre-push hook, each of the form "<local ref> <local sha> <remote ref> <remote sha>"
+// ref: https://git-scm.com/docs/githooks#_pre_push
+func readRefUpdates(file io.Reader) ([]refUpdate, error) {
+	var updates []refUpdate
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		refsAndShas := strings.Fields(scanner.Text())
+		if len(refsAndShas) == 0 {
+			continue
+		}
+		if len(refsAndShas) < 4 {
+			log.Warnf("Ignoring malformed pre-push input line %q", scanner.Text())
+			continue
+		}
+		updates = append(updates, refUpdate{refsAndShas[0], refsAndShas[1], refsAndShas[2], refsAndShas[3]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read refs to push: %v", err)
 	}
-	return refsAndShas[0], refsAndShas[1], refsAndShas[2], refsAndShas[3]
+	return updates, nil
 }
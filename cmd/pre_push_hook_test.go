@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"talisman/git_testing"
+	"talisman/gitrepo"
+	"talisman/prompt"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrePushHookChecksEveryPushedRef(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		input := fmt.Sprintf("refs/heads/main %s refs/heads/main %s\nrefs/heads/feature %s refs/heads/feature %s\n",
+			baseline, baseline, git.LatestCommit(), baseline)
+
+		prePushHook, err := NewPrePushHook(gitrepo.RepoLocatedAt(git.Root()), strings.NewReader(input))
+
+		assert.NoError(t, err)
+		if assert.Len(t, prePushHook.pushes, 2) {
+			assert.Empty(t, prePushHook.pushes[0].additions)
+			assert.Len(t, prePushHook.pushes[1].additions, 1)
+		}
+		exitStatus := prePushHook.Run(&talismanrc.TalismanRC{}, prompt.NewPromptContext(false, prompt.NewPrompt()))
+		assert.Equal(t, EXIT_FAILURE, exitStatus, "Expected the secret on the second ref to be found")
+		assert.True(t, prePushHook.pushes[1].results.HasFailures())
+		assert.False(t, prePushHook.pushes[0].results.HasFailures())
+	})
+}
+
+func TestPrePushHookChecksAdditionsSharedBetweenRefsOnce(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		input := fmt.Sprintf("refs/heads/main %s refs/heads/main %s\nrefs/tags/v1 %s refs/tags/v1 %s\n",
+			git.LatestCommit(), baseline, git.LatestCommit(), EmptySha)
+
+		prePushHook, err := NewPrePushHook(gitrepo.RepoLocatedAt(git.Root()), strings.NewReader(input))
+
+		assert.NoError(t, err)
+		if assert.Len(t, prePushHook.pushes, 2) {
+			assert.Len(t, prePushHook.pushes[0].additions, 1)
+			assert.Len(t, prePushHook.pushes[1].additions, 1, "Only the baseline file should be left to check for the tag")
+			assert.Equal(t, 1, prePushHook.pushes[1].alreadyChecked)
+			assert.Equal(t, "Talisman results for refs/tags/v1 (skipping 1 change(s) already checked for another ref)", prePushHook.pushes[1].heading())
+		}
+	})
+}
+
+func TestPrePushHookScansMessagesOfOutgoingCommitsAndTags(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("safe-file", "safeContents")
+		git.AddAndcommit("*", "deploy with "+awsAccessKeyIDExample)
+		git.Tag("v1", "release notes "+awsAccessKeyIDExample)
+		input := fmt.Sprintf("refs/heads/main %s refs/heads/main %s\nrefs/tags/v1 %s refs/tags/v1 %s\n",
+			git.LatestCommit(), baseline, git.LatestCommit(), EmptySha)
+		options.ScanMessages = true
+		defer func() { options.ScanMessages = false }()
+
+		prePushHook, err := NewPrePushHook(gitrepo.RepoLocatedAt(git.Root()), strings.NewReader(input))
+
+		assert.NoError(t, err)
+		if assert.Len(t, prePushHook.pushes, 2) {
+			assert.Len(t, prePushHook.pushes[0].messages, 1)
+			assert.Len(t, prePushHook.pushes[1].messages, 2, "Expected the message of the baseline commit and the annotation of the tag")
+		}
+		exitStatus := prePushHook.Run(&talismanrc.TalismanRC{}, prompt.NewPromptContext(false, prompt.NewPrompt()))
+		assert.Equal(t, EXIT_FAILURE, exitStatus)
+		assert.NotEmpty(t, prePushHook.pushes[0].results.GetFailures(gitrepo.FilePath(gitrepo.CommitMessagePrefix+git.LatestCommit())))
+		assert.NotEmpty(t, prePushHook.pushes[1].results.GetFailures(gitrepo.TagAnnotationPrefix+"v1"))
+	})
+}
+
+func TestPrePushHookChecksOnlyCommitsNotOnRemotesForNewRefs(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret already on the remote")
+		git.AddAndcommit("*", "add private key")
+		input := fmt.Sprintf("refs/heads/feature %s refs/heads/feature %s\n", git.LatestCommit(), EmptySha)
+		repo := gitrepo.RepoLocatedAt(git.Root())
+
+		withoutRemoteHistory, err := NewPrePushHook(repo, strings.NewReader(input))
+		assert.NoError(t, err)
+		assert.Len(t, withoutRemoteHistory.pushes[0].additions, 2)
+		assert.Equal(t, "refs/heads/feature is a new ref and there is no remote history: checking all files", withoutRemoteHistory.pushes[0].scope)
+
+		gitCommand(t, git.Root(), nil, "", "update-ref", "refs/remotes/origin/main", "HEAD")
+		git.CreateFileWithContents("feature.txt", "feature")
+		git.AddAndcommit("*", "add feature")
+		input = fmt.Sprintf("refs/heads/feature %s refs/heads/feature %s\n", git.LatestCommit(), EmptySha)
+		options.ScanMessages = true
+		defer func() { options.ScanMessages = false }()
+
+		withRemoteHistory, err := NewPrePushHook(repo, strings.NewReader(input))
+		assert.NoError(t, err)
+		if assert.Len(t, withRemoteHistory.pushes[0].additions, 1) {
+			assert.Equal(t, gitrepo.FilePath("feature.txt"), withRemoteHistory.pushes[0].additions[0].Path)
+		}
+		if assert.Len(t, withRemoteHistory.pushes[0].messages, 1, "Expected the messages of commits on the remote not to be checked again") {
+			assert.Equal(t, gitrepo.FilePath(gitrepo.CommitMessagePrefix+git.LatestCommit()), withRemoteHistory.pushes[0].messages[0].Path)
+		}
+		assert.Equal(t, "refs/heads/feature is a new ref: checking the changes of 1 commit(s) not on any remote-tracking ref", withRemoteHistory.pushes[0].scope)
+		exitStatus := withRemoteHistory.Run(&talismanrc.TalismanRC{}, prompt.NewPromptContext(false, prompt.NewPrompt()))
+		assert.Equal(t, EXIT_SUCCESS, exitStatus, "Expected the private key already on the remote not to be checked again")
+	})
+}
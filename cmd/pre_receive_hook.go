@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"talisman/detector"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+)
+
+// sidebandPrefix starts every line the pre-receive hook prints, which git relays to the pushing client after "remote: "
+const sidebandPrefix = "talisman: "
+
+// PreReceiveHook checks the refs pushed to a git server, which is typically a bare repository.
+// As there is no working tree, each ref is checked against the .talismanrc committed in its new tip.
+// Pushed objects are read through git, so that they are found in the quarantine directory git keeps them in until the push is accepted.
+type PreReceiveHook struct {
+	repo    gitrepo.GitRepo
+	updates []refUpdate
+	output  io.Writer
+}
+
+func NewPreReceiveHook(repo gitrepo.GitRepo, stdin io.Reader) (*PreReceiveHook, error) {
+	updates, err := readReceivedRefUpdates(stdin)
+	if err != nil {
+		return nil, err
+	}
+	return &PreReceiveHook{repo: repo, updates: updates, output: os.Stdout}, nil
+}
+
+// Run checks every pushed ref and returns EXIT_FAILURE, which makes git reject the whole push, if any of them fails
+func (p *PreReceiveHook) Run() int {
+	exitStatus := EXIT_SUCCESS
+	for _, update := range p.updates {
+		if update.runningOnDeletedRef() {
+			continue
+		}
+		if status := p.check(update); status > exitStatus {
+			exitStatus = status
+		}
+	}
+	if exitStatus == EXIT_FAILURE {
+		p.sideband("push rejected. Remove the secrets, or add the files to .talismanrc in the pushed commits if they are safe")
+	}
+	return exitStatus
+}
+
+func (p *PreReceiveHook) check(update refUpdate) int {
+	tRC, err := talismanrc.LoadAtRef(p.repo.Root(), update.localCommit)
+	if err != nil {
+		p.sideband("%s: invalid configuration: %v", update.localRef, err)
+		return EXIT_FAILURE
+	}
+	additions, scope, err := p.getRepoAdditions(update)
+	if err != nil {
+		log.Errorf("unable to read pushed changes of %s: %v", update.localRef, err)
+		p.sideband("%s: unable to read pushed changes: %v", update.localRef, err)
+		return EXIT_ERROR
+	}
+	if scope != "" {
+		p.sideband("%s", scope)
+	}
+
+	results := helpers.NewDetectionResults()
+	if options.Explain {
+		results.EnableExplain()
+	}
+	// The attributes of the pushed commits are part of the change under check, so only those the server already has apply
+	attributes := gitrepo.Attributes{}
+	if !update.runningOnNewRef() {
+		attributes = p.repo.AttributesAtRef(update.remoteCommit)
+	}
+	additionsToScan := helpers.RemoveScopedFiles(tRC, additions, results)
+	detector.DefaultChain(tRC, helpers.HistoricBlobEvaluator(tRC)).
+		WithProgressOutput(nil).
+		WithAttributes(attributes).
+		WithLFSObjects(p.repo.LFSObjects()).
+		Test(additionsToScan, tRC, results)
+
+	for _, line := range results.ReportPlainLines() {
+		p.sideband("%s: %s", update.localRef, line)
+	}
+	if results.HasFailures() {
+		return EXIT_FAILURE
+	}
+	return EXIT_SUCCESS
+}
+
+// getRepoAdditions returns the changes of a pushed ref. As a server has no remote-tracking refs to tell which commits of a new ref
+// it already has, the changes of the commits of a new ref that are not reachable from any of its existing refs are returned.
+func (p *PreReceiveHook) getRepoAdditions(update refUpdate) ([]gitrepo.Addition, string, error) {
+	if !update.runningOnNewRef() {
+		return update.getRepoAdditions(p.repo)
+	}
+	commits, err := p.repo.CommitsNotOnAnyRef(update.localCommit)
+	if err != nil {
+		return nil, "", err
+	}
+	log.WithFields(update.logFields()).WithField("commits", len(commits)).Info("Running on a new ref. Changes in commits not on any existing ref will be verified.")
+
+	additions, err := p.repo.AdditionsNotOnAnyRef(update.localCommit)
+	return additions, fmt.Sprintf("%s is a new ref: checking the changes of %d commit(s) not on any existing ref", update.localRef, len(commits)), err
+}
+
+func (p *PreReceiveHook) sideband(format string, args ...interface{}) {
+	fmt.Fprintln(p.output, sidebandPrefix+fmt.Sprintf(format, args...))
+}
+
+// readReceivedRefUpdates reads all lines given to a pre-receive hook, each of the form "<old sha> <new sha> <ref>".
+// As the old sha is what the server knows of the ref, it plays the part of the remote commit of a pre-push.
+// ref: https://git-scm.com/docs/githooks#pre-receive
+func readReceivedRefUpdates(file io.Reader) ([]refUpdate, error) {
+	var updates []refUpdate
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		shasAndRef := strings.Fields(scanner.Text())
+		if len(shasAndRef) == 0 {
+			continue
+		}
+		if len(shasAndRef) < 3 {
+			log.Warnf("Ignoring malformed pre-receive input line %q", scanner.Text())
+			continue
+		}
+		oldCommit, newCommit, ref := shasAndRef[0], shasAndRef[1], shasAndRef[2]
+		updates = append(updates, refUpdate{localRef: ref, localCommit: newCommit, remoteRef: ref, remoteCommit: oldCommit})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read pushed refs: %v", err)
+	}
+	return updates, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"talisman/git_testing"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+	"talisman/utility"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreReceiveHookRejectsSecretsPushedToBareRepository(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		bare := bareCloneOf(t, git.Root())
+		output := &bytes.Buffer{}
+
+		exitStatus := runPreReceiveHook(t, bare, fmt.Sprintf("%s %s refs/heads/main\n", baseline, git.LatestCommit()), output)
+
+		assert.Equal(t, EXIT_FAILURE, exitStatus)
+		assert.Contains(t, output.String(), "talisman: refs/heads/main: failure: private.pem: [filename, ")
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			assert.True(t, strings.HasPrefix(line, sidebandPrefix), "Expected every line to be prefixed, got %q", line)
+			assert.NotContains(t, line, "\x1b", "Expected no colours in sideband output")
+		}
+	})
+}
+
+func TestPreReceiveHookAppliesTalismanrcOfPushedCommits(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(talismanrc.RCFileName, fmt.Sprintf(`fileignoreconfig:
+- filename: private.pem
+  checksum: %s
+`, utility.SHA256HashOfContents("private.pem", []byte("secret"))))
+		git.AddAndcommit("*", "add private key along with its ignore")
+		bare := bareCloneOf(t, git.Root())
+		output := &bytes.Buffer{}
+
+		exitStatus := runPreReceiveHook(t, bare, fmt.Sprintf("%s %s refs/heads/main\n", baseline, git.LatestCommit()), output)
+
+		assert.Equal(t, EXIT_SUCCESS, exitStatus, output.String())
+		assert.NotContains(t, output.String(), "failure")
+	})
+}
+
+func TestPreReceiveHookScansTextFilesThatPushedAttributesMarkBinary(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents(".gitattributes", "*.cfg -diff\n")
+		git.CreateFileWithContents("app.cfg", "password=hunter2secret\n")
+		git.AddAndcommit("*", "add config along with attributes hiding it")
+		bare := bareCloneOf(t, git.Root())
+		output := &bytes.Buffer{}
+
+		exitStatus := runPreReceiveHook(t, bare, fmt.Sprintf("%s %s refs/heads/main\n", baseline, git.LatestCommit()), output)
+
+		assert.Equal(t, EXIT_FAILURE, exitStatus, output.String())
+		assert.Contains(t, output.String(), "talisman: refs/heads/main: failure: app.cfg")
+	})
+}
+
+func TestPreReceiveHookReadsObjectsFromQuarantine(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		bare := bareCloneOf(t, git.Root())
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		pushed := git.LatestCommit()
+
+		// git receives pushed objects into a quarantine directory, which is only visible to the hook through its environment
+		quarantine := filepath.Join(bare, "objects", "incoming-test")
+		require.NoError(t, os.MkdirAll(quarantine, 0700))
+		pack := gitCommand(t, git.Root(), nil, fmt.Sprintf("%s\n^%s\n", pushed, baseline), "pack-objects", "--revs", "--stdout")
+		gitCommand(t, bare, []string{"GIT_OBJECT_DIRECTORY=" + quarantine}, pack, "unpack-objects", "-q")
+		t.Setenv("GIT_QUARANTINE_PATH", quarantine)
+		t.Setenv("GIT_OBJECT_DIRECTORY", quarantine)
+		t.Setenv("GIT_ALTERNATE_OBJECT_DIRECTORIES", filepath.Join(bare, "objects"))
+		output := &bytes.Buffer{}
+
+		exitStatus := runPreReceiveHook(t, bare, fmt.Sprintf("%s %s refs/heads/main\n", baseline, pushed), output)
+
+		assert.Equal(t, EXIT_FAILURE, exitStatus, output.String())
+		assert.Contains(t, output.String(), "talisman: refs/heads/main: failure: private.pem")
+	})
+}
+
+func TestPreReceiveHookChecksOnlyNewCommitsOfNewBranches(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key, which the server already has")
+		baseline := git.LatestCommit()
+		bare := bareCloneOf(t, git.Root())
+		git.CreateFileWithContents("notes.txt", "nothing to see here")
+		git.AddAndcommit("notes.txt", "add notes on a new branch")
+		pushed := git.LatestCommit()
+		pack := gitCommand(t, git.Root(), nil, fmt.Sprintf("%s\n^%s\n", pushed, baseline), "pack-objects", "--revs", "--stdout")
+		gitCommand(t, bare, nil, pack, "unpack-objects", "-q")
+		output := &bytes.Buffer{}
+
+		exitStatus := runPreReceiveHook(t, bare, fmt.Sprintf("%s %s refs/heads/feature\n", EmptySha, pushed), output)
+
+		assert.Equal(t, EXIT_SUCCESS, exitStatus, output.String())
+		assert.Contains(t, output.String(), "talisman: refs/heads/feature is a new ref: checking the changes of 1 commit(s) not on any existing ref")
+		assert.NotContains(t, output.String(), "private.pem")
+	})
+}
+
+func TestPreReceiveHookSkipsDeletedRefs(t *testing.T) {
+	hook := &PreReceiveHook{
+		updates: []refUpdate{{localRef: "refs/heads/old", localCommit: EmptySha, remoteRef: "refs/heads/old", remoteCommit: "abc"}},
+		output:  &bytes.Buffer{},
+	}
+	assert.Equal(t, EXIT_SUCCESS, hook.Run())
+}
+
+func TestReadingReceivedRefUpdates(t *testing.T) {
+	updates, err := readReceivedRefUpdates(strings.NewReader(fmt.Sprintf("%s def refs/heads/new\nmalformed\n\nabc def refs/heads/main\n", EmptySha)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []refUpdate{
+		{localRef: "refs/heads/new", localCommit: "def", remoteRef: "refs/heads/new", remoteCommit: EmptySha},
+		{localRef: "refs/heads/main", localCommit: "def", remoteRef: "refs/heads/main", remoteCommit: "abc"},
+	}, updates)
+	assert.True(t, updates[0].runningOnNewRef())
+}
+
+func runPreReceiveHook(t *testing.T, bare string, input string, output *bytes.Buffer) int {
+	repo, err := gitrepo.Discover(bare)
+	require.NoError(t, err)
+	hook, err := NewPreReceiveHook(repo, strings.NewReader(input))
+	require.NoError(t, err)
+	hook.output = output
+	return hook.Run()
+}
+
+func bareCloneOf(t *testing.T, root string) string {
+	bare := filepath.Join(t.TempDir(), "server.git")
+	gitCommand(t, root, nil, "", "clone", "--quiet", "--bare", root, bare)
+	return bare
+}
+
+func gitCommand(t *testing.T, dir string, env []string, stdin string, args ...string) string {
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	command.Env = append(os.Environ(), env...)
+	command.Stdin = strings.NewReader(stdin)
+	output, err := command.Output()
+	require.NoError(t, err, "git %v failed", args)
+	return string(output)
+}
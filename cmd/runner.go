@@ -2,53 +2,75 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"talisman/detector"
 	"talisman/detector/helpers"
-	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/prompt"
 	"talisman/talismanrc"
+	"talisman/utility"
 )
 
 // runner represents a single run of the validations for a given commit range
 type runner struct {
+	repo      gitrepo.GitRepo
 	additions []gitrepo.Addition
 	results   *helpers.DetectionResults
 	mode      string
+	// messages holds the commit messages and tag annotations to validate along with the additions
+	messages []gitrepo.Addition
 }
 
 // NewRunner returns a new runner.
-func NewRunner(additions []gitrepo.Addition, mode string) *runner {
+func NewRunner(repo gitrepo.GitRepo, additions []gitrepo.Addition, mode string) *runner {
+	results := helpers.NewDetectionResults()
+	results.SetRepository(repo)
+	if options.Explain {
+		results.EnableExplain()
+	}
+	if options.RevealSecrets {
+		results.RevealSecrets()
+	}
 	return &runner{
+		repo:      repo,
 		additions: additions,
-		results:   helpers.NewDetectionResults(),
+		results:   results,
 		mode:      mode,
 	}
 }
 
 // Run will validate the commit range for errors and return either COMPLETED_SUCCESSFULLY or COMPLETED_WITH_ERRORS
 func (r *runner) Run(tRC *talismanrc.TalismanRC, promptContext prompt.PromptContext) int {
-	wd, _ := os.Getwd()
-	repo := gitrepo.RepoLocatedAt(wd)
-	ie := helpers.BuildIgnoreEvaluator(r.mode, tRC, repo)
+	hashers := utility.NewHashers()
+	defer hashers.Shutdown()
+	ie, err := helpers.BuildIgnoreEvaluator(hashers, r.mode, tRC, r.repo)
+	if err != nil {
+		return exitWithError("Unable to read files tracked by git", err)
+	}
+	return r.runWith(hashers, ie, tRC, promptContext)
+}
 
-	setCustomSeverities(tRC)
-	additionsToScan := tRC.RemoveScopedFiles(r.additions)
+// runWith validates the additions using hashers and an IgnoreEvaluator that may be shared with other runners
+func (r *runner) runWith(hashers *utility.Hashers, ie helpers.IgnoreEvaluator, tRC *talismanrc.TalismanRC, promptContext prompt.PromptContext) int {
+	r.results.UseHashers(hashers)
+	additionsToScan := helpers.RemoveScopedFiles(tRC, r.additions, r.results)
 
-	detector.DefaultChain(tRC, ie).Test(additionsToScan, tRC, r.results)
+	detector.DefaultChain(tRC, ie).
+		WithAttributes(r.repo.Attributes()).
+		WithLFSObjects(r.repo.LFSObjects()).
+		Test(additionsToScan, tRC, r.results)
+	if len(r.messages) > 0 {
+		// Messages are not part of the working tree, so their checksums are compared against their contents on their own
+		detector.MessageChain(tRC, helpers.HistoricBlobEvaluator(tRC)).Test(r.messages, tRC, r.results)
+	}
 	r.printReport(promptContext)
 	exitStatus := r.exitStatus()
 	return exitStatus
 }
 
-func setCustomSeverities(tRC *talismanrc.TalismanRC) {
-	for _, cs := range tRC.CustomSeverities {
-		severity.SeverityConfiguration[cs.Detector] = cs.Severity
-	}
-}
-
 func (r *runner) printReport(promptContext prompt.PromptContext) {
+	if r.results.Explaining() {
+		r.results.ReportExplanations()
+	}
 	if r.results.HasWarnings() {
 		fmt.Println(r.results.ReportWarnings())
 	}
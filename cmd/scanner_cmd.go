@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"talisman/detector"
 	"talisman/detector/helpers"
 	"talisman/gitrepo"
@@ -20,20 +19,34 @@ const (
 
 type ScannerCmd struct {
 	additions       []gitrepo.Addition
+	messages        []gitrepo.Addition
 	results         *helpers.DetectionResults
 	reportDirectory string
 	ignoreEvaluator helpers.IgnoreEvaluator
 	tRC             *talismanrc.TalismanRC
+	attributes      gitrepo.Attributes
+	lfsObjects      gitrepo.LFSObjects
+	hashers         *utility.Hashers
 }
 
 // Run scans git commit history for potential secrets and returns 0 or 1 as exit code
 func (s *ScannerCmd) Run() int {
+	defer s.hashers.Shutdown()
 	fmt.Printf("\n\n")
 	utility.CreateArt("Running Scan..")
 
-	additionsToScan := s.tRC.RemoveScopedFiles(s.additions)
+	additionsToScan := helpers.RemoveScopedFiles(s.tRC, s.additions, s.results)
 
-	detector.DefaultChain(s.tRC, s.ignoreEvaluator).Test(additionsToScan, s.tRC, s.results)
+	detector.DefaultChain(s.tRC, s.ignoreEvaluator).
+		WithAttributes(s.attributes).
+		WithLFSObjects(s.lfsObjects).
+		Test(additionsToScan, s.tRC, s.results)
+	if len(s.messages) > 0 {
+		detector.MessageChain(s.tRC, s.ignoreEvaluator).Test(s.messages, s.tRC, s.results)
+	}
+	if s.results.Explaining() {
+		s.results.ReportExplanations()
+	}
 	reportsPath, err := report.GenerateReport(s.results, s.reportDirectory)
 	if err != nil {
 		logr.Errorf("error while generating report: %v", err)
@@ -52,19 +65,69 @@ func (s *ScannerCmd) exitStatus() int {
 }
 
 // NewScannerCmd Returns a new scanner command
-func NewScannerCmd(ignoreHistory bool, tRC *talismanrc.TalismanRC, reportDirectory string) *ScannerCmd {
-	repoRoot, _ := os.Getwd()
-	reader := gitrepo.NewBatchGitObjectHashReader(repoRoot)
-	additions := scanner.GetAdditions(ignoreHistory, reader)
+func NewScannerCmd(repo gitrepo.GitRepo, ignoreHistory bool, tRC *talismanrc.TalismanRC, reportDirectory string) (*ScannerCmd, error) {
+	reader, err := gitrepo.NewBatchGitObjectHashReader(repo)
+	if err != nil {
+		return nil, err
+	}
+	audit := options.Audit && !ignoreHistory
+	var additions []gitrepo.Addition
+	if audit {
+		additions, err = scanner.GetAuditAdditions(repo, reader)
+	} else {
+		additions, err = scanner.GetAdditions(repo, ignoreHistory, reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var messages []gitrepo.Addition
+	if options.ScanMessages && !ignoreHistory {
+		messages, err = historyMessages(repo, audit)
+		if err != nil {
+			return nil, err
+		}
+	}
 	ignoreEvaluator := helpers.ScanHistoryEvaluator()
+	if options.HonorTalismanrc {
+		ignoreEvaluator = helpers.HistoricBlobEvaluator(tRC)
+	}
+	hashers := utility.NewHashers()
 	if ignoreHistory {
-		ignoreEvaluator = helpers.BuildIgnoreEvaluator("default", tRC, gitrepo.RepoLocatedAt(repoRoot))
+		ignoreEvaluator, err = helpers.BuildIgnoreEvaluator(hashers, "default", tRC, repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	results := helpers.NewDetectionResults()
+	if options.Explain {
+		results.EnableExplain()
+	}
+	if options.RevealSecrets {
+		results.RevealSecrets()
 	}
 	return &ScannerCmd{
 		additions:       additions,
-		results:         helpers.NewDetectionResults(),
+		messages:        messages,
+		results:         results,
 		reportDirectory: reportDirectory,
 		ignoreEvaluator: ignoreEvaluator,
 		tRC:             tRC,
+		attributes:      repo.Attributes(),
+		lfsObjects:      repo.LFSObjects(),
+		hashers:         hashers,
+	}, nil
+}
+
+// historyMessages returns the messages of all commits in the history of a repo, along with the annotations of all its tags.
+// When auditing, the messages of commits only referenced by reflogs, e.g. older stash entries, are included as well.
+func historyMessages(repo gitrepo.GitRepo, audit bool) ([]gitrepo.Addition, error) {
+	messages, err := repo.AllCommitMessages(audit)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := repo.TagAnnotations()
+	if err != nil {
+		return nil, err
 	}
+	return append(messages, annotations...), nil
 }
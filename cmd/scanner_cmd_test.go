@@ -2,7 +2,10 @@ package main
 
 import (
 	"os"
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
 	"talisman/git_testing"
+	"talisman/gitrepo"
 	"talisman/talismanrc"
 	"testing"
 
@@ -16,7 +19,7 @@ func TestScannerCmdRunsSuccessfully(t *testing.T) {
 		git.AddAndcommit("*", "Start of Scan")
 		os.Chdir(git.Root())
 
-		scannerCmd := NewScannerCmd(true, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), true, &talismanrc.TalismanRC{}, git.Root())
 		scannerCmd.Run()
 		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 0 since no secret is found")
 	})
@@ -33,7 +36,7 @@ func TestScannerCmdDetectsSecretAndFails(t *testing.T) {
 		git.AddAndcommit("*", "Start of Scan")
 		os.Chdir(git.Root())
 
-		scannerCmd := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, &talismanrc.TalismanRC{}, git.Root())
 		scannerCmd.Run()
 		assert.Equal(t, 1, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 1 since secret present in history")
 	})
@@ -48,7 +51,7 @@ func TestScannerCmdAddingSecretKeyShouldExitZeroIfFileIsWithinConfiguredScope(t
 		os.Chdir(git.Root())
 
 		tRC := &talismanrc.TalismanRC{ScopeConfig: []talismanrc.ScopeConfig{{ScopeName: "go"}}}
-		scannerCmd := NewScannerCmd(false, tRC, git.Root())
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, tRC, git.Root())
 		scannerCmd.Run()
 		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 0 since no secret is found")
 	})
@@ -67,7 +70,7 @@ func TestScannerCmdDetectsSecretAndIgnoresWhileRunningInIgnoreHistoryModeWithVal
 				{FileName: "go.sum", Checksum: "582093519ae682d5170aecc9b935af7e90ed528c577ecd2c9dd1fad8f4924ab9"},
 				{FileName: "go.mod", Checksum: "8a03b9b61c505ace06d590d2b9b4f4b6fa70136e14c26875ced149180e00d1af"},
 			}}
-		scannerCmd := NewScannerCmd(true, tRC, git.Root())
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), true, tRC, git.Root())
 		scannerCmd.Run()
 		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 0 since secrets file ignore is enabled")
 	})
@@ -86,8 +89,86 @@ func TestScannerCmdDetectsSecretWhileRunningNormalScanMode(t *testing.T) {
 				{FileName: "go.sum", Checksum: "582093519ae682d5170aecc9b935af7e90ed528c577ecd2c9dd1fad8f4924ab9"},
 				{FileName: "go.mod", Checksum: "8a03b9b61c505ace06d590d2b9b4f4b6fa70136e14c26875ced149180e00d1af"},
 			}}
-		scannerCmd := NewScannerCmd(false, tRC, git.Root())
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, tRC, git.Root())
 		scannerCmd.Run()
 		assert.Equal(t, 1, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 1 because file ignore is disabled when scanning history")
 	})
 }
+
+func TestScannerCmdHonorsTalismanrcForHistoricBlobs(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("go.sum", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "go sum file")
+		git.CreateFileWithContents("secrets.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "reviewed secrets")
+		git.RemoveFile("secrets.txt")
+		git.AddAndcommit("*", "removed secrets")
+		os.Chdir(git.Root())
+		options.HonorTalismanrc = true
+		defer func() { options.HonorTalismanrc = false }()
+
+		tRC := &talismanrc.TalismanRC{
+			FileIgnoreConfig: []talismanrc.FileIgnoreConfig{
+				{FileName: "go.sum", Checksum: "582093519ae682d5170aecc9b935af7e90ed528c577ecd2c9dd1fad8f4924ab9"},
+				{FileName: "secrets.txt", IgnoreDetectors: []string{"filecontent"}},
+			}}
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, tRC, git.Root())
+		scannerCmd.Run()
+		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 0 since all secrets in history are ignored by .talismanrc")
+		assert.Equal(t, []helpers.Details{{Category: "filecontent", Message: `ignored by fileignoreconfig entry "secrets.txt", which ignores the filecontent detector`, Commits: []string{}, Severity: severity.Low}},
+			ignoredDetailsFor(scannerCmd.results, "secrets.txt"))
+	})
+}
+
+func ignoredDetailsFor(results *helpers.DetectionResults, filePath gitrepo.FilePath) []helpers.Details {
+	for _, resultDetails := range results.Results {
+		if resultDetails.Filename == filePath {
+			return resultDetails.IgnoreList
+		}
+	}
+	return nil
+}
+
+func TestScannerCmdScansCommitMessagesAndTagAnnotations(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("safe-file", "safeContents")
+		git.AddAndcommit("*", "deploy with "+awsAccessKeyIDExample)
+		git.Tag("v1", "release notes "+awsAccessKeyIDExample)
+		os.Chdir(git.Root())
+
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd.Run()
+		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected messages to be skipped unless asked for")
+
+		options.ScanMessages = true
+		defer func() { options.ScanMessages = false }()
+		scannerCmd, _ = NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd.Run()
+		assert.Equal(t, 1, scannerCmd.exitStatus())
+		assert.NotEmpty(t, scannerCmd.results.GetFailures(gitrepo.FilePath(gitrepo.CommitMessagePrefix+git.LatestCommit())))
+		assert.NotEmpty(t, scannerCmd.results.GetFailures(gitrepo.TagAnnotationPrefix+"v1"))
+	})
+}
+
+func TestScannerCmdAuditFindsSecretsLeftBehindByRewrittenHistory(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("secrets.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "add secrets")
+		gitCommand(t, git.Root(), nil, "", "reset", "--hard", "HEAD~1")
+		os.Chdir(git.Root())
+
+		scannerCmd, _ := NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd.Run()
+		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected the purged secret to be invisible to a regular scan")
+
+		options.Audit = true
+		defer func() { options.Audit = false }()
+		scannerCmd, _ = NewScannerCmd(gitrepo.RepoLocatedAt(git.Root()), false, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd.Run()
+		assert.Equal(t, 1, scannerCmd.exitStatus(), "Expected the audit to find the secret through the reflog")
+		assert.NotEmpty(t, scannerCmd.results.GetFailures("secrets.txt"))
+	})
+}
@@ -7,7 +7,7 @@ import (
 	"os"
 	"runtime/pprof"
 	"strings"
-	"talisman/utility"
+	"talisman/gitrepo"
 	"time"
 
 	"talisman/prompt"
@@ -26,6 +26,8 @@ var (
 	Version       = "Development Build"
 	interactive   bool
 	talismanInput io.Reader
+	// hookArguments holds the arguments git passes to a hook, e.g. the file holding the message for a commit-msg hook
+	hookArguments []string
 )
 
 const (
@@ -33,10 +35,16 @@ const (
 	PrePush = "pre-push"
 	//PreCommit : Const for name of of pre-commit hook
 	PreCommit = "pre-commit"
+	//PreReceive : Const for name of the server-side pre-receive hook
+	PreReceive = "pre-receive"
+	//CommitMsg : Const for name of commit-msg hook
+	CommitMsg = "commit-msg"
 	//EXIT_SUCCESS : Const to indicate successful talisman invocation
 	EXIT_SUCCESS = 0
 	//EXIT_FAILURE : Const to indicate failed successful invocation
 	EXIT_FAILURE = 1
+	//EXIT_ERROR : Const to indicate that talisman could not complete its checks, e.g. because a git command failed
+	EXIT_ERROR = 2
 )
 
 var options struct {
@@ -50,6 +58,12 @@ var options struct {
 	ReportDirectory string
 	ScanWithHtml    bool
 	ShouldProfile   bool
+	PrintConfig     bool
+	Explain         bool
+	HonorTalismanrc bool
+	ScanMessages    bool
+	Audit           bool
+	RevealSecrets   bool
 }
 
 //var options Options
@@ -71,7 +85,7 @@ func init() {
 		"pattern (glob-like) of files to scan (ignores githooks)")
 	flag.StringVarP(&options.GitHook,
 		"githook", "g", PrePush,
-		"either pre-push or pre-commit")
+		"either pre-push, pre-commit, commit-msg (given the message file) or pre-receive (server-side)")
 	flag.BoolVarP(&options.Scan,
 		"scan", "s", false,
 		"scanner scans the git commit history for potential secrets")
@@ -93,6 +107,24 @@ func init() {
 	flag.BoolVarP(&options.ShouldProfile,
 		"profile", "f", false,
 		"profile cpu and memory usage of talisman")
+	flag.BoolVar(&options.PrintConfig,
+		"printConfig", false,
+		"print the effective .talismanrc configuration, with all includes resolved")
+	flag.BoolVar(&options.Explain,
+		"explain", false,
+		"explain why each file was scanned, ignored or failed, per detector")
+	flag.BoolVar(&options.HonorTalismanrc,
+		"honorTalismanrc", false,
+		"apply the fileignoreconfig entries of .talismanrc to blobs from the git history when scanning (only makes sense with -s/--scan)")
+	flag.BoolVar(&options.ScanMessages,
+		"scanMessages", false,
+		"also scan commit messages and tag annotations (only makes sense with -g/--githook pre-push or -s/--scan)")
+	flag.BoolVar(&options.Audit,
+		"audit", false,
+		"also scan all reflog entries, all stash entries and unreachable objects (only makes sense with -s/--scan, without --ignoreHistory)")
+	flag.BoolVar(&options.RevealSecrets,
+		"revealSecrets", false,
+		"report secrets in full rather than masked (local runs only: ignored with -g/--githook pre-receive and in CI pipelines)")
 }
 
 func main() {
@@ -109,11 +141,16 @@ func main() {
 	}
 
 	if options.GitHook != "" {
-		if !(options.GitHook == PreCommit || options.GitHook == PrePush) {
-			fmt.Println(fmt.Errorf("githook should be %s or %s, but got %s", PreCommit, PrePush, options.GitHook))
+		if !(options.GitHook == PreCommit || options.GitHook == PrePush || options.GitHook == PreReceive || options.GitHook == CommitMsg) {
+			fmt.Println(fmt.Errorf("githook should be %s, %s, %s or %s, but got %s", PreCommit, PrePush, CommitMsg, PreReceive, options.GitHook))
 			os.Exit(EXIT_FAILURE)
 		}
 	}
+	hookArguments = flag.Args()
+	if options.GitHook == CommitMsg && len(hookArguments) != 1 {
+		fmt.Println(fmt.Errorf("githook %s expects the file holding the commit message as its only argument, but got %v", CommitMsg, hookArguments))
+		os.Exit(EXIT_FAILURE)
+	}
 
 	if options.ShouldProfile {
 		stopProfFunc := setupProfiling()
@@ -135,6 +172,11 @@ func run(promptContext prompt.PromptContext) (returnCode int) {
 
 	setLogLevel()
 
+	if options.RevealSecrets && !isLocalRun(os.Getenv) {
+		fmt.Println("Ignoring --revealSecrets, as secrets are only revealed by local runs")
+		options.RevealSecrets = false
+	}
+
 	if options.GitHook == "" {
 		options.GitHook = PrePush
 	}
@@ -143,46 +185,130 @@ func run(promptContext prompt.PromptContext) (returnCode int) {
 	fields := make(map[string]interface{})
 	_ = json.Unmarshal(optionsBytes, &fields)
 	log.WithFields(fields).Debug("Talisman execution environment")
-	defer utility.DestroyHashers()
-	if options.Checksum != "" {
+	if options.PrintConfig {
+		_, talismanrc, status := loadRepository()
+		if status != EXIT_SUCCESS {
+			return status
+		}
+		fmt.Print(talismanrc.EffectiveConfig())
+		return EXIT_SUCCESS
+	} else if options.Checksum != "" {
 		log.Infof("Running %s patterns against checksum calculator", options.Checksum)
-		return NewChecksumCmd(strings.Fields(options.Checksum)).Run()
+		repo, err := discoverRepository()
+		if err != nil {
+			return EXIT_ERROR
+		}
+		return NewChecksumCmd(repo, strings.Fields(options.Checksum)).Run()
 	} else if options.Scan {
 		log.Infof("Running scanner")
-		talismanrc, err := talismanrc.Load()
+		repo, talismanrc, status := loadRepository()
+		if status != EXIT_SUCCESS {
+			return status
+		}
+		scannerCmd, err := NewScannerCmd(repo, options.IgnoreHistory, talismanrc, options.ReportDirectory)
 		if err != nil {
-			return EXIT_FAILURE
+			return exitWithError("Unable to read git history", err)
 		}
-		return NewScannerCmd(options.IgnoreHistory, talismanrc, options.ReportDirectory).Run()
+		return scannerCmd.Run()
 	} else if options.ScanWithHtml {
 		log.Infof("Running scanner with html report")
-		talismanrc, err := talismanrc.Load()
+		repo, talismanrc, status := loadRepository()
+		if status != EXIT_SUCCESS {
+			return status
+		}
+		scannerCmd, err := NewScannerCmd(repo, options.IgnoreHistory, talismanrc, "talisman_html_report")
 		if err != nil {
-			return EXIT_FAILURE
+			return exitWithError("Unable to read git history", err)
 		}
-		return NewScannerCmd(options.IgnoreHistory, talismanrc, "talisman_html_report").Run()
+		return scannerCmd.Run()
 	} else if options.Pattern != "" {
 		log.Infof("Running scan for %s", options.Pattern)
-		talismanrc, err := talismanrc.Load()
+		repo, talismanrc, status := loadRepository()
+		if status != EXIT_SUCCESS {
+			return status
+		}
+		return NewPatternCmd(repo, options.Pattern).Run(talismanrc, promptContext)
+	} else if options.GitHook == PreReceive {
+		log.Infof("Running %s hook", options.GitHook)
+		repo, err := discoverRepository()
+		if err != nil {
+			return EXIT_ERROR
+		}
+		preReceiveHook, err := NewPreReceiveHook(repo, talismanInput)
+		if err != nil {
+			return exitWithError("Unable to read pushed refs", err)
+		}
+		return preReceiveHook.Run()
+	} else if options.GitHook == CommitMsg {
+		log.Infof("Running %s hook", options.GitHook)
+		repo, talismanrc, status := loadRepository()
+		if status != EXIT_SUCCESS {
+			return status
+		}
+		commitMsgHook, err := NewCommitMsgHook(repo, hookArguments[0])
 		if err != nil {
-			return EXIT_FAILURE
+			return exitWithError("Unable to read commit message", err)
 		}
-		return NewPatternCmd(options.Pattern).Run(talismanrc, promptContext)
+		return commitMsgHook.Run(talismanrc, promptContext)
 	} else if options.GitHook == PreCommit {
 		log.Infof("Running %s hook", options.GitHook)
-		talismanrc, err := talismanrc.Load()
+		repo, talismanrc, status := loadRepository()
+		if status != EXIT_SUCCESS {
+			return status
+		}
+		preCommitHook, err := NewPreCommitHook(repo)
 		if err != nil {
-			return EXIT_FAILURE
+			return exitWithError("Unable to read staged changes", err)
 		}
-		return NewPreCommitHook().Run(talismanrc, promptContext)
+		return preCommitHook.Run(talismanrc, promptContext)
 	} else {
 		log.Infof("Running %s hook", options.GitHook)
-		talismanrc, err := talismanrc.Load()
+		repo, talismanrc, status := loadRepository()
+		if status != EXIT_SUCCESS {
+			return status
+		}
+		prePushHook, err := NewPrePushHook(repo, talismanInput)
 		if err != nil {
-			return EXIT_FAILURE
+			return exitWithError("Unable to read outgoing changes", err)
 		}
-		return NewPrePushHook(talismanInput).Run(talismanrc, promptContext)
+		return prePushHook.Run(talismanrc, promptContext)
+	}
+}
+
+// exitWithError reports an error that kept Talisman from completing its checks and returns EXIT_ERROR
+func exitWithError(description string, err error) int {
+	log.Errorf("%s: %v", description, err)
+	fmt.Println(fmt.Errorf("\n\x1b[1m\x1b[31m%s: %s\x1b[0m\x1b[0m", description, err))
+	return EXIT_ERROR
+}
+
+// discoverRepository locates the git repository Talisman was started in, from any of its directories or worktrees
+func discoverRepository() (gitrepo.GitRepo, error) {
+	repo, err := gitrepo.Discover(".")
+	if err != nil {
+		log.Errorf("unable to locate git repository: %v", err)
+		fmt.Println(fmt.Errorf("\n\x1b[1m\x1b[31mUnable to locate git repository: %s\x1b[0m\x1b[0m", err))
+		return repo, err
+	}
+	log.WithFields(log.Fields{
+		"root":   repo.Root(),
+		"gitDir": repo.GitDir(),
+	}).Debug("Located git repository")
+	return repo, nil
+}
+
+// loadRepository locates the git repository Talisman was started in, along with the .talismanrc in its root.
+// Unless both could be loaded, it returns the status Talisman should exit with.
+func loadRepository() (gitrepo.GitRepo, *talismanrc.TalismanRC, int) {
+	repo, err := discoverRepository()
+	if err != nil {
+		return repo, nil, EXIT_ERROR
+	}
+	talismanRC, err := talismanrc.LoadFrom(repo.Root())
+	if err != nil {
+		return repo, talismanRC, EXIT_FAILURE
 	}
+	return repo, talismanRC, EXIT_SUCCESS
 }
 
 func validateGitExecutable(fs afero.Fs, operatingSystem string) error {
@@ -203,6 +329,16 @@ func validateGitExecutable(fs afero.Fs, operatingSystem string) error {
 	return nil
 }
 
+// isLocalRun answers if Talisman runs on a developer's machine, rather than on a git server or in a CI pipeline,
+// which set the CI environment variable
+func isLocalRun(getenv func(string) string) bool {
+	if options.GitHook == PreReceive {
+		return false
+	}
+	ci := strings.ToLower(getenv("CI"))
+	return ci == "" || ci == "false" || ci == "0"
+}
+
 func setLogLevel() {
 	switch options.LogLevel {
 	case "info":
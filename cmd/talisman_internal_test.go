@@ -4,6 +4,7 @@ import (
 	"github.com/spf13/afero"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,9 +20,24 @@ func TestParsingShasFromStdIn(t *testing.T) {
 	file.WriteString("localRef localSha remoteRef remoteSha")
 	file.Seek(0, 0)
 
-	_, oldSha, _, newSha := readRefAndSha(file)
-	assert.Equal(t, "localSha", oldSha, "oldSha did not equal 'localSha', got: %s", oldSha)
-	assert.Equal(t, "remoteSha", newSha, "newSha did not equal 'remoteSha', got: %s", newSha)
+	updates, err := readRefUpdates(file)
+	assert.NoError(t, err)
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, "localSha", updates[0].localCommit, "localCommit did not equal 'localSha', got: %s", updates[0].localCommit)
+		assert.Equal(t, "remoteSha", updates[0].remoteCommit, "remoteCommit did not equal 'remoteSha', got: %s", updates[0].remoteCommit)
+	}
+}
+
+func TestParsingAllRefUpdatesFromStdIn(t *testing.T) {
+	input := "refs/heads/main aaa refs/heads/main bbb\n\nmalformed line\nrefs/tags/v1 ccc refs/tags/v1 0000000000000000000000000000000000000000\n"
+
+	updates, err := readRefUpdates(strings.NewReader(input))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []refUpdate{
+		{"refs/heads/main", "aaa", "refs/heads/main", "bbb"},
+		{"refs/tags/v1", "ccc", "refs/tags/v1", EmptySha},
+	}, updates)
 }
 
 func Test_validateGitExecutable(t *testing.T) {
@@ -65,3 +81,23 @@ func Test_validateGitExecutable(t *testing.T) {
 	})
 
 }
+
+func Test_isLocalRun(t *testing.T) {
+	defer func(gitHook string) { options.GitHook = gitHook }(options.GitHook)
+	env := func(ci string) func(string) string {
+		return func(name string) string {
+			if name == "CI" {
+				return ci
+			}
+			return ""
+		}
+	}
+
+	options.GitHook = PrePush
+	assert.True(t, isLocalRun(env("")))
+	assert.True(t, isLocalRun(env("false")))
+	assert.False(t, isLocalRun(env("true")), "CI pipelines are not local runs")
+
+	options.GitHook = PreReceive
+	assert.False(t, isLocalRun(env("")), "git servers are not local runs")
+}
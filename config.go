@@ -0,0 +1,30 @@
+package talisman
+
+import (
+	"talisman/talismanrc"
+)
+
+// Config holds the rules of a .talismanrc that a Scanner applies
+type Config struct {
+	rc *talismanrc.TalismanRC
+}
+
+// ParseConfig reads the rules of a .talismanrc from its contents. Files listed under `include` are resolved relative to dir.
+func ParseConfig(contents []byte, dir string) (*Config, error) {
+	rc, err := talismanrc.Parse(contents, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{rc: rc}, nil
+}
+
+// Severity is the severity of a finding: "info", "low", "medium", "high" or "critical"
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
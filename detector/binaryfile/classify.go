@@ -0,0 +1,53 @@
+package binaryfile
+
+import (
+	"bytes"
+	"talisman/archive"
+	"talisman/gitrepo"
+)
+
+// sniffLength is how many leading bytes are looked at for a NUL byte, which is how git itself tells binary files apart
+const sniffLength = 8000
+
+// Classify answers if an addition is a binary file, along with the reason why it is, or is not.
+// Files are binary when they are archives, or when their first bytes hold a NUL byte.
+// Git attributes marking a file binary ("binary", "-diff" or stored in Git LFS) are only mentioned in the reason, as whoever adds a file
+// may mark it so: they never keep a file whose content is text from being scanned.
+func Classify(addition gitrepo.Addition, attributes gitrepo.Attributes) (bool, string) {
+	isBinary, reason := sniff(addition.Data)
+	marking, marked := markedBinary(attributes.Of(addition.Path))
+	switch {
+	case !marked:
+		return isBinary, reason
+	case isBinary:
+		return true, marking + " and " + reason
+	default:
+		return false, reason + ", although " + marking
+	}
+}
+
+func sniff(data []byte) (bool, string) {
+	if archive.IsArchive(data) {
+		return true, "it is an archive"
+	}
+	head := data
+	if len(head) > sniffLength {
+		head = head[:sniffLength]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true, "its content holds a NUL byte"
+	}
+	return false, "its content holds no NUL byte"
+}
+
+func markedBinary(attributes map[string]string) (string, bool) {
+	switch {
+	case attributes["binary"] == gitrepo.AttributeSet:
+		return `it is marked "binary" in .gitattributes`, true
+	case attributes["diff"] == gitrepo.AttributeUnset:
+		return `it is marked "-diff" in .gitattributes`, true
+	case attributes["filter"] == "lfs":
+		return "it is stored in Git LFS according to .gitattributes", true
+	}
+	return "", false
+}
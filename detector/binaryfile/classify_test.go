@@ -0,0 +1,50 @@
+package binaryfile
+
+import (
+	"bytes"
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifiesFilesByTheirContent(t *testing.T) {
+	for name, expected := range map[string]struct {
+		data   []byte
+		binary bool
+		reason string
+	}{
+		"notes.txt":  {[]byte("password=hunter2\n"), false, "its content holds no NUL byte"},
+		"font.woff":  {[]byte("wOFF\x00\x01\x00\x00"), true, "its content holds a NUL byte"},
+		"lib.jar":    {[]byte("PK\x03\x04"), true, "it is an archive"},
+		"late.bin":   {append(bytes.Repeat([]byte("a"), sniffLength), 0), false, "its content holds no NUL byte"},
+		"empty.conf": {nil, false, "its content holds no NUL byte"},
+	} {
+		isBinary, reason := Classify(gitrepo.NewAddition(name, expected.data), gitrepo.Attributes{})
+		assert.Equal(t, expected.binary, isBinary, name)
+		assert.Equal(t, expected.reason, reason, name)
+	}
+}
+
+func TestClassifiesFilesMarkedBinaryInGitAttributesByTheirContent(t *testing.T) {
+	attributes := gitrepo.Attributes{".gitattributes": gitrepo.ParseAttributes([]byte("*.png binary\n*.pdf -diff\n*.psd filter=lfs diff=lfs\n*.svg diff\n"))}
+	for name, expectedReason := range map[string]string{
+		"logo.png":   `it is marked "binary" in .gitattributes and its content holds a NUL byte`,
+		"manual.pdf": `it is marked "-diff" in .gitattributes and its content holds a NUL byte`,
+		"cover.psd":  "it is stored in Git LFS according to .gitattributes and its content holds a NUL byte",
+	} {
+		isBinary, reason := Classify(gitrepo.NewAddition(name, []byte("\x89PNG\x00")), attributes)
+		assert.True(t, isBinary, name)
+		assert.Equal(t, expectedReason, reason, name)
+	}
+
+	for name, expectedReason := range map[string]string{
+		"logo.png":   `its content holds no NUL byte, although it is marked "binary" in .gitattributes`,
+		"manual.pdf": `its content holds no NUL byte, although it is marked "-diff" in .gitattributes`,
+		"logo.svg":   "its content holds no NUL byte",
+	} {
+		isBinary, reason := Classify(gitrepo.NewAddition(name, []byte("password=hunter2\n")), attributes)
+		assert.False(t, isBinary, name)
+		assert.Equal(t, expectedReason, reason, name)
+	}
+}
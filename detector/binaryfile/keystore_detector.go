@@ -0,0 +1,77 @@
+package binaryfile
+
+import (
+	"bytes"
+	"fmt"
+	"talisman/detector/detector"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// keyStoreFormat is a key store format told apart by its first bytes
+type keyStoreFormat struct {
+	name  string
+	magic []byte
+	rule  string
+}
+
+var keyStoreFormats = []keyStoreFormat{
+	{name: "Java key store", magic: []byte{0xfe, 0xed, 0xfe, 0xed}, rule: "KeyStoreFile"},
+	{name: "Java cryptography extension key store", magic: []byte{0xce, 0xce, 0xce, 0xce}, rule: "KeyStoreFile"},
+	{name: "KeePass database", magic: []byte{0x03, 0xd9, 0xa2, 0x9a}, rule: "KDBFile"},
+}
+
+// KeyStoreDetector tests the content of the Additions for key stores, whatever their name is.
+// It is a dedicated check of binary files, which the text detectors are not given.
+type KeyStoreDetector struct{}
+
+// NewKeyStoreDetector returns a KeyStoreDetector
+func NewKeyStoreDetector() detector.Detector {
+	return KeyStoreDetector{}
+}
+
+// TestsBinaryFiles answers that key stores are binary files
+func (KeyStoreDetector) TestsBinaryFiles() bool {
+	return true
+}
+
+// Test tests the first bytes of the Additions against the magic bytes of known key store formats
+func (kd KeyStoreDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	severities := ignoreConfig.SeverityConfiguration()
+	for _, addition := range currentAdditions {
+		if ignored, rule := helpers.EvaluateIgnore(comparator, addition, "filecontent", "keystore", result); ignored {
+			log.WithFields(log.Fields{
+				"filePath": addition.Path,
+			}).Info("Ignoring addition as it was specified to be ignored.")
+			result.IgnoreWithRule(addition.Path, "filecontent", rule)
+			additionCompletionCallback()
+			continue
+		}
+		if format, found := keyStoreFormatOf(addition.Data); found {
+			log.WithFields(log.Fields{
+				"filePath": addition.Path,
+				"format":   format.name,
+			}).Info("Failing file as it is a key store.")
+			message := fmt.Sprintf("Expected file to not be a %s", format.name)
+			findingSeverity, fails := result.AssessFinding(ignoreConfig, addition, "keystore", message, severities.SeverityOf(format.rule), ignoreConfig.Threshold)
+			if fails {
+				result.Fail(addition.Path, "filecontent", message, addition.Commits, findingSeverity)
+			} else {
+				result.Warn(addition.Path, "filecontent", message, addition.Commits, findingSeverity)
+			}
+		}
+		additionCompletionCallback()
+	}
+}
+
+func keyStoreFormatOf(data []byte) (keyStoreFormat, bool) {
+	for _, format := range keyStoreFormats {
+		if bytes.HasPrefix(data, format.magic) {
+			return format, true
+		}
+	}
+	return keyStoreFormat{}, false
+}
@@ -0,0 +1,41 @@
+package binaryfile
+
+import (
+	"io"
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+	"testing"
+
+	logr "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logr.SetOutput(io.Discard)
+}
+
+func TestShouldFlagKeyStoresWhateverTheirName(t *testing.T) {
+	talismanRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("src/main/resources/app.bin", []byte("\xfe\xed\xfe\xed\x00\x00\x00\x02")),
+		gitrepo.NewAddition("vault.dat", []byte("\x03\xd9\xa2\x9a\x67\xfb\x4b\xb5")),
+		gitrepo.NewAddition("logo.png", []byte("\x89PNG\r\n\x1a\n")),
+	}
+
+	NewKeyStoreDetector().Test(helpers.HistoricBlobEvaluator(talismanRC), additions, talismanRC, results, func() {})
+
+	failures := results.GetFailures("src/main/resources/app.bin")
+	if assert.Len(t, failures, 1) {
+		assert.Equal(t, "Expected file to not be a Java key store", failures[0].Message)
+		assert.Equal(t, severity.High, failures[0].Severity)
+	}
+	if failures := results.GetFailures("vault.dat"); assert.Len(t, failures, 1) {
+		assert.Equal(t, "Expected file to not be a KeePass database", failures[0].Message)
+		assert.Equal(t, severity.Low, failures[0].Severity)
+	}
+	assert.Empty(t, results.GetFailures("logo.png"))
+	assert.Empty(t, results.GetWarnings("logo.png"))
+}
@@ -2,10 +2,17 @@ package detector
 
 import (
 	"os"
+	"talisman/archive"
+	"talisman/detector/binaryfile"
+	"talisman/detector/connection"
+	"talisman/detector/decoding"
 	"talisman/detector/detector"
+	"talisman/detector/encrypted"
 	"talisman/detector/filecontent"
 	"talisman/detector/filename"
+	"talisman/detector/filesize"
 	"talisman/detector/helpers"
+	"talisman/detector/keyvalue"
 	"talisman/detector/pattern"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
@@ -19,12 +26,15 @@ import (
 type Chain struct {
 	detectors       []detector.Detector
 	ignoreEvaluator helpers.IgnoreEvaluator
+	progressOutput  *os.File
+	attributes      gitrepo.Attributes
+	lfsObjects      gitrepo.LFSObjects
 }
 
 // NewChain returns an empty DetectorChain
 // It is itself a detector, but it tests nothing.
 func NewChain(ignoreEvaluator helpers.IgnoreEvaluator) *Chain {
-	result := Chain{[]detector.Detector{}, ignoreEvaluator}
+	result := Chain{[]detector.Detector{}, ignoreEvaluator, os.Stdout, gitrepo.Attributes{}, gitrepo.LFSObjects{}}
 	return &result
 }
 
@@ -32,6 +42,19 @@ func NewChain(ignoreEvaluator helpers.IgnoreEvaluator) *Chain {
 func DefaultChain(tRC *talismanrc.TalismanRC, ignoreEvaluator helpers.IgnoreEvaluator) *Chain {
 	chain := NewChain(ignoreEvaluator)
 	chain.AddDetector(filename.DefaultFileNameDetector(tRC.Threshold))
+	chain.AddDetector(binaryfile.NewKeyStoreDetector())
+	chain.AddDetector(filesize.NewFileSizeDetector(filesize.DefaultMaxFileSize))
+	chain.AddDetector(filecontent.NewFileContentDetector(tRC))
+	chain.AddDetector(pattern.NewPatternDetector(tRC.CustomPatterns))
+	chain.AddDetector(keyvalue.NewKeyValueDetector())
+	chain.AddDetector(connection.NewConnectionStringDetector())
+	chain.AddDetector(decoding.NewDecodingDetector(tRC))
+	return chain
+}
+
+// MessageChain returns a DetectorChain for commit messages and tag annotations, which only looks at contents, as they have no file name
+func MessageChain(tRC *talismanrc.TalismanRC, ignoreEvaluator helpers.IgnoreEvaluator) *Chain {
+	chain := NewChain(ignoreEvaluator)
 	chain.AddDetector(filecontent.NewFileContentDetector(tRC))
 	chain.AddDetector(pattern.NewPatternDetector(tRC.CustomPatterns))
 	return chain
@@ -43,18 +66,164 @@ func (dc *Chain) AddDetector(d detector.Detector) *Chain {
 	return dc
 }
 
-// Test validates the additions against each detector in the chain.
+// WithProgressOutput sets where the progress of a run is rendered. A nil output disables the progress bar.
+func (dc *Chain) WithProgressOutput(out *os.File) *Chain {
+	dc.progressOutput = out
+	return dc
+}
+
+// WithAttributes sets the git attributes of the repository, which tell binary files apart along with their content.
+// The .gitattributes files among the additions take precedence over those of the repository.
+func (dc *Chain) WithAttributes(attributes gitrepo.Attributes) *Chain {
+	dc.attributes = attributes
+	return dc
+}
+
+// WithLFSObjects sets the local store of Git LFS objects, whose content is scanned in place of their pointers if the .talismanrc asks
+func (dc *Chain) WithLFSObjects(objects gitrepo.LFSObjects) *Chain {
+	dc.lfsObjects = objects
+	return dc
+}
+
+// Test validates the additions against each detector in the chain, along with the entries of the archives among them.
+// Binary files are only given to the detectors that test binary files, unless the .talismanrc asks to scan them as text.
+// The text of Git LFS pointers is never scanned, but the objects they point to may be scanned in their place.
+// The ciphertext of encrypted secret files is not scanned either, while their plaintext is.
 // The results are passed in from detector to detector and thus collect all errors from all detectors
 func (dc *Chain) Test(additions []gitrepo.Addition, talismanRC *talismanrc.TalismanRC, result *helpers.DetectionResults) {
+	originals := originalAdditions{}
+	ignoreEvaluator := originals.evaluator(dc.ignoreEvaluator)
+	additions = dc.resolveLFSPointers(additions, originals, talismanRC, result)
+	additions = archive.Expand(additions, talismanRC.ArchiveLimits(), func(addition gitrepo.Addition) bool {
+		return ignoreEvaluator == nil || !ignoreEvaluator.ShouldIgnore(addition, "filecontent")
+	}, result)
+	textAdditions := dc.textAdditions(additions, originals, talismanRC, result)
 	log.Printf("Number of files to scan: %d\n", len(additions))
 	log.Printf("Number of detectors: %d\n", len(dc.detectors))
-	total := len(additions) * len(dc.detectors)
-	progressBar := utility.GetProgressBar(os.Stdout, "Talisman Scan")
+	total := 0
+	for _, v := range dc.detectors {
+		total += len(additionsFor(v, additions, textAdditions))
+	}
+	progressBar := utility.GetProgressBar(dc.progressOutput, "Talisman Scan")
 	progressBar.Start(total)
 	for _, v := range dc.detectors {
-		v.Test(dc.ignoreEvaluator, additions, talismanRC, result, func() {
+		v.Test(ignoreEvaluator, additionsFor(v, additions, textAdditions), talismanRC, result, func() {
 			progressBar.Increment()
 		})
 	}
 	progressBar.Finish()
 }
+
+// resolveLFSPointers replaces the content of Git LFS pointers with the content of their objects, if the .talismanrc asks and they are
+// in the local store. The pointers whose content was replaced are kept in originals.
+func (dc *Chain) resolveLFSPointers(additions []gitrepo.Addition, originals originalAdditions, talismanRC *talismanrc.TalismanRC, result *helpers.DetectionResults) []gitrepo.Addition {
+	if !talismanRC.ScansLFSObjects() {
+		return additions
+	}
+	resolvedAdditions := make([]gitrepo.Addition, len(additions))
+	for i, addition := range additions {
+		resolvedAdditions[i] = addition
+		pointer, isPointer := gitrepo.ParseLFSPointer(addition.Data)
+		if !isPointer {
+			continue
+		}
+		content, err := dc.lfsObjects.Read(pointer, talismanRC.LFSObjectSizeLimit())
+		if err != nil {
+			result.Explain(addition.Path, "lfs", "not scanning the Git LFS object %s, as %v", pointer.OID, err)
+			continue
+		}
+		result.Explain(addition.Path, "lfs", "scanning the Git LFS object %s in place of its pointer", pointer.OID)
+		resolvedAdditions[i].Data = content
+		originals[addition.Path] = addition
+	}
+	return resolvedAdditions
+}
+
+// textAdditions returns the additions that are given to the detectors reading them as text, explaining how each addition was classified.
+// The content of Git LFS objects, which are the only originals so far, is classified on its own, as the attributes of their files tell
+// they are stored in Git LFS. The ciphertext of encrypted files is blanked out, and the files it was blanked out of are kept in originals.
+func (dc *Chain) textAdditions(additions []gitrepo.Addition, originals originalAdditions, talismanRC *talismanrc.TalismanRC, result *helpers.DetectionResults) []gitrepo.Addition {
+	attributes := gitrepo.Attributes{}
+	for file, rules := range dc.attributes {
+		attributes[file] = rules
+	}
+	for _, addition := range additions {
+		if addition.Name == gitrepo.AttributesFileName && !addition.IsArchiveEntry() {
+			attributes[addition.Path] = gitrepo.ParseAttributes(addition.Data)
+		}
+	}
+	var textAdditions []gitrepo.Addition
+	for _, addition := range additions {
+		if pointer, isPointer := gitrepo.ParseLFSPointer(addition.Data); isPointer {
+			result.Explain(addition.Path, "lfs", "not scanning the text of the Git LFS pointer to object %s", pointer.OID)
+			continue
+		}
+		encryption, isEncrypted := encrypted.Recognise(addition)
+		if isEncrypted && encryption.Whole() {
+			result.Explain(addition.Path, "encrypted", "encrypted with %s, so its ciphertext is not scanned", encryption.Format)
+			continue
+		}
+		classifiedBy := attributes
+		if _, isLFSObject := originals[addition.Path]; isLFSObject {
+			classifiedBy = gitrepo.Attributes{}
+		}
+		isBinary, reason := binaryfile.Classify(addition, classifiedBy)
+		switch {
+		case !isBinary && isEncrypted:
+			result.Explain(addition.Path, "encrypted", "encrypted with %s, so only the values left in plaintext are scanned", encryption.Format)
+			if _, replaced := originals[addition.Path]; !replaced {
+				originals[addition.Path] = addition
+			}
+			addition.Data = encryption.Blank(addition.Data)
+			textAdditions = append(textAdditions, addition)
+		case !isBinary:
+			result.Explain(addition.Path, "binaryfile", "classified as text, as %s", reason)
+			textAdditions = append(textAdditions, addition)
+		case talismanRC.ScansBinaryFilesAsText():
+			result.Explain(addition.Path, "binaryfile", "classified as binary, as %s, but scanned as text as the .talismanrc asks", reason)
+			textAdditions = append(textAdditions, addition)
+		default:
+			result.Explain(addition.Path, "binaryfile", "classified as binary, as %s, so only its name, size and format are checked", reason)
+		}
+	}
+	return textAdditions
+}
+
+func additionsFor(d detector.Detector, additions, textAdditions []gitrepo.Addition) []gitrepo.Addition {
+	if binaryDetector, ok := d.(detector.BinaryDetector); ok && binaryDetector.TestsBinaryFiles() {
+		return additions
+	}
+	return textAdditions
+}
+
+// originalAdditions holds the additions whose content the detectors are given something else in place of, keyed by their path.
+// Ignores are evaluated against the originals, as the checksums of fileignoreconfig are of what was committed.
+type originalAdditions map[gitrepo.FilePath]gitrepo.Addition
+
+func (originals originalAdditions) evaluator(ignoreEvaluator helpers.IgnoreEvaluator) helpers.IgnoreEvaluator {
+	if ignoreEvaluator == nil {
+		return nil
+	}
+	return originalsEvaluator{ignoreEvaluator, originals}
+}
+
+// originalsEvaluator evaluates ignores against the original of each addition whose content was replaced
+type originalsEvaluator struct {
+	ignoreEvaluator helpers.IgnoreEvaluator
+	originals       originalAdditions
+}
+
+func (oe originalsEvaluator) ShouldIgnore(addition gitrepo.Addition, detectorType string) bool {
+	return oe.ignoreEvaluator.ShouldIgnore(oe.originalOf(addition), detectorType)
+}
+
+func (oe originalsEvaluator) ExplainIgnore(addition gitrepo.Addition, detectorType string) (bool, []string) {
+	return oe.ignoreEvaluator.ExplainIgnore(oe.originalOf(addition), detectorType)
+}
+
+func (oe originalsEvaluator) originalOf(addition gitrepo.Addition) gitrepo.Addition {
+	if original, replaced := oe.originals[addition.Path]; replaced {
+		return original
+	}
+	return addition
+}
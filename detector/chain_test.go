@@ -1,14 +1,27 @@
 package detector
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"talisman/detector/binaryfile"
+	"talisman/detector/connection"
+	"talisman/detector/decoding"
 	"talisman/detector/filecontent"
 	"talisman/detector/filename"
+	"talisman/detector/filesize"
 	"talisman/detector/helpers"
+	"talisman/detector/keyvalue"
 	"talisman/detector/pattern"
 	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
+	"talisman/utility"
 	"testing"
 
 	logr "github.com/sirupsen/logrus"
@@ -30,8 +43,16 @@ type PassingDetection struct{}
 func (p PassingDetection) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
 }
 
+// on returns a set boolean option of the .talismanrc
+func on() *bool {
+	value := true
+	return &value
+}
+
 func TestEmptyValidationChainPassesAllValidations(t *testing.T) {
-	ie := helpers.BuildIgnoreEvaluator("pre-push", nil, gitrepo.RepoLocatedAt("."))
+	hashers := utility.NewHashers()
+	defer hashers.Shutdown()
+	ie, _ := helpers.BuildIgnoreEvaluator(hashers, "pre-push", nil, gitrepo.RepoLocatedAt("."))
 	v := NewChain(ie)
 	results := helpers.NewDetectionResults()
 	v.Test(nil, &talismanrc.TalismanRC{}, results)
@@ -39,7 +60,9 @@ func TestEmptyValidationChainPassesAllValidations(t *testing.T) {
 }
 
 func TestValidationChainWithFailingValidationAlwaysFails(t *testing.T) {
-	ie := helpers.BuildIgnoreEvaluator("pre-push", nil, gitrepo.RepoLocatedAt("."))
+	hashers := utility.NewHashers()
+	defer hashers.Shutdown()
+	ie, _ := helpers.BuildIgnoreEvaluator(hashers, "pre-push", nil, gitrepo.RepoLocatedAt("."))
 	v := NewChain(ie)
 	v.AddDetector(PassingDetection{})
 	v.AddDetector(FailingDetection{})
@@ -54,16 +77,208 @@ func TestDefaultChainShouldCreateChainSpecifiedModeAndPresetDetectors(t *testing
 		Threshold:      severity.Medium,
 		CustomPatterns: []talismanrc.PatternString{"AKIA*"},
 	}
-	ie := helpers.BuildIgnoreEvaluator("pre-push", talismanRC, gitrepo.RepoLocatedAt("."))
+	hashers := utility.NewHashers()
+	defer hashers.Shutdown()
+	ie, _ := helpers.BuildIgnoreEvaluator(hashers, "pre-push", talismanRC, gitrepo.RepoLocatedAt("."))
 	v := DefaultChain(talismanRC, ie)
-	assert.Equal(t, 3, len(v.detectors))
+	assert.Equal(t, 8, len(v.detectors))
 
 	defaultFileNameDetector := filename.DefaultFileNameDetector(talismanRC.Threshold)
 	assert.Equal(t, defaultFileNameDetector, v.detectors[0])
 
+	assert.Equal(t, binaryfile.NewKeyStoreDetector(), v.detectors[1])
+
+	assert.Equal(t, filesize.NewFileSizeDetector(filesize.DefaultMaxFileSize), v.detectors[2])
+
 	expectedFileContentDetector := filecontent.NewFileContentDetector(talismanRC)
-	assert.Equal(t, expectedFileContentDetector, v.detectors[1])
+	assert.Equal(t, expectedFileContentDetector, v.detectors[3])
 
 	expectedPatternDetector := pattern.NewPatternDetector(talismanRC.CustomPatterns)
-	assert.Equal(t, expectedPatternDetector, v.detectors[2])
+	assert.Equal(t, expectedPatternDetector, v.detectors[4])
+
+	assert.Equal(t, keyvalue.NewKeyValueDetector(), v.detectors[5])
+
+	assert.Equal(t, connection.NewConnectionStringDetector(), v.detectors[6])
+
+	assert.Equal(t, decoding.NewDecodingDetector(talismanRC), v.detectors[7])
+}
+
+func TestMessageChainShouldOnlyLookAtContents(t *testing.T) {
+	talismanRC := &talismanrc.TalismanRC{
+		Threshold:      severity.Medium,
+		CustomPatterns: []talismanrc.PatternString{"AKIA*"},
+	}
+	v := MessageChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC))
+	assert.Equal(t, 2, len(v.detectors))
+	assert.Equal(t, filecontent.NewFileContentDetector(talismanRC), v.detectors[0])
+	assert.Equal(t, pattern.NewPatternDetector(talismanRC.CustomPatterns), v.detectors[1])
+}
+
+func TestChainShouldReportSecretsInsideArchivesUnderNestedPaths(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	entry, _ := writer.Create("config/app.properties")
+	entry.Write([]byte("db.password=hunter2!\n"))
+	writer.Close()
+	talismanRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{gitrepo.NewAddition("lib.jar", buffer.Bytes())}, talismanRC, results)
+
+	assert.NotEmpty(t, results.GetFailures("lib.jar!/config/app.properties"), "Expected the secret inside the jar to be reported under its nested path")
+}
+
+func TestChainShouldOnlyCheckTheNamesOfBinaryFiles(t *testing.T) {
+	image := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), []byte("password=hunter2secret aGVsbG8gdGhlcmUgaG93IGFyZSB5b3UgZG9pbmc=")...)
+	talismanRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{gitrepo.NewAddition("logo.png", image), gitrepo.NewAddition("id_rsa", image)}, talismanRC, results)
+
+	assert.Empty(t, results.GetFailures("logo.png"), "Expected the content of the image to not be scanned")
+	assert.Len(t, results.GetFailures("id_rsa"), 1, "Expected the name of the binary file to be checked")
+	assert.Contains(t, results.Explanations["logo.png"], helpers.Explanation{Detector: "binaryfile", Decision: "classified as binary, as its content holds a NUL byte, so only its name, size and format are checked"})
+}
+
+func TestChainShouldCheckTheSizesOfBinaryFiles(t *testing.T) {
+	image := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), bytes.Repeat([]byte{0}, filesize.DefaultMaxFileSize)...)
+	talismanRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{gitrepo.NewAddition("logo.png", image)}, talismanRC, results)
+
+	failures := results.GetFailures("logo.png")
+	assert.Len(t, failures, 1)
+	assert.Equal(t, "filesize", failures[0].Category)
+}
+
+func TestChainShouldScanBinaryFilesAsTextWhenTheTalismanRCAsks(t *testing.T) {
+	talismanRC := &talismanrc.TalismanRC{BinaryFiles: talismanrc.BinaryFilesConfig{ScanAsText: on()}}
+	results := helpers.NewDetectionResults()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{gitrepo.NewAddition("dump.bin", []byte("\x00\x01password=hunter2secret\n"))}, talismanRC, results)
+
+	assert.NotEmpty(t, results.GetFailures("dump.bin"))
+}
+
+func TestChainShouldNotSkipTextFilesMarkedBinaryInGitAttributes(t *testing.T) {
+	talismanRC := &talismanrc.TalismanRC{}
+	attributes := gitrepo.Attributes{".gitattributes": gitrepo.ParseAttributes([]byte("*.dat -diff\n"))}
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("fixtures/data.dat", []byte("password=hunter2secret\n")),
+		gitrepo.NewAddition("fixtures/image.dat", []byte("\x89PNG\x00password=hunter2secret\n")),
+		gitrepo.NewAddition("fixtures/.gitattributes", []byte("*.txt binary\n")),
+		gitrepo.NewAddition("fixtures/notes.txt", []byte("password=hunter2secret\n")),
+	}
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).WithAttributes(attributes).Test(additions, talismanRC, results)
+
+	assert.NotEmpty(t, results.GetFailures("fixtures/data.dat"))
+	assert.NotEmpty(t, results.GetFailures("fixtures/notes.txt"))
+	assert.Empty(t, results.GetFailures("fixtures/image.dat"))
+	assert.Contains(t, results.Explanations["fixtures/notes.txt"], helpers.Explanation{Detector: "binaryfile", Decision: `classified as text, as its content holds no NUL byte, although it is marked "binary" in .gitattributes`})
+	assert.Contains(t, results.Explanations["fixtures/image.dat"], helpers.Explanation{Detector: "binaryfile", Decision: `classified as binary, as it is marked "-diff" in .gitattributes and its content holds a NUL byte, so only its name, size and format are checked`})
+}
+
+func TestChainShouldNotScanTheTextOfLFSPointers(t *testing.T) {
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+	talismanRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{gitrepo.NewAddition("assets/video.mp4", []byte(pointer))}, talismanRC, results)
+
+	assert.False(t, results.HasFailures(), "Expected the oid of the pointer to not be reported as a hex encoded text")
+	assert.Contains(t, results.Explanations["assets/video.mp4"], helpers.Explanation{Detector: "lfs", Decision: "not scanning the text of the Git LFS pointer to object 4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"})
+}
+
+func TestChainShouldScanLFSObjectsInPlaceOfTheirPointersWhenTheTalismanRCAsks(t *testing.T) {
+	root := t.TempDir()
+	content := []byte("db.password=hunter2secret\n")
+	sum := sha256.Sum256(content)
+	oid := hex.EncodeToString(sum[:])
+	objectDir := filepath.Join(root, ".git", "lfs", "objects", oid[0:2], oid[2:4])
+	assert.NoError(t, os.MkdirAll(objectDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(objectDir, oid), content, 0644))
+	pointer := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(content))
+	attributes := gitrepo.Attributes{".gitattributes": gitrepo.ParseAttributes([]byte("*.properties filter=lfs diff=lfs merge=lfs -text\n"))}
+	scan := func(talismanRC *talismanrc.TalismanRC) *helpers.DetectionResults {
+		results := helpers.NewDetectionResults()
+		DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).
+			WithAttributes(attributes).
+			WithLFSObjects(gitrepo.RepoLocatedAt(root).LFSObjects()).
+			Test([]gitrepo.Addition{gitrepo.NewAddition("app.properties", []byte(pointer))}, talismanRC, results)
+		return results
+	}
+
+	assert.False(t, scan(&talismanrc.TalismanRC{}).HasFailures(), "Expected LFS objects to not be scanned by default")
+	assert.NotEmpty(t, scan(&talismanrc.TalismanRC{LFS: talismanrc.LFSConfig{ScanObjects: on()}}).GetFailures("app.properties"))
+	assert.False(t, scan(&talismanrc.TalismanRC{LFS: talismanrc.LFSConfig{ScanObjects: on(), MaxObjectSize: 8}}).HasFailures(), "Expected LFS objects above the size limit to not be scanned")
+}
+
+func TestChainShouldOnlyFlagThePlaintextOfEncryptedFiles(t *testing.T) {
+	sopsFile := `database:
+    password: ENC[AES256_GCM,data:Tr7oRCUnRhNzYg==,iv:1T8QnxlOKKUhEbYIIF2nWHtNl+uXhObAjGpLQMG5N4M=,tag:dm6nMbR3ZNAlNmRdDDqDSQ==,type:str]
+    api_key: hunter2secret
+sops:
+    age:
+        - recipient: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+          enc: |
+            -----BEGIN AGE ENCRYPTED FILE-----
+            YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBxVnBqQ0hYZ2V3NXl5VVJr
+            -----END AGE ENCRYPTED FILE-----
+    mac: ENC[AES256_GCM,data:x3CcBE0PmAqRXTBmZ5I9Qik+3M7EAn5LqxLhVBr4VTFy6OnH5jONzk0kbh+9L2RQ,iv:xNmWFG1AxGNvLS5MGrrXHmkv1XXI2m0ml1Rs5m1rRAk=,tag:4cpCcdjRdJObVcCTyAXUUw==,type:str]
+    version: 3.8.1
+`
+	vaultFile := "$ANSIBLE_VAULT;1.1;AES256\n62313365396662343061393464336163383764373764613633653634306231386433626436623361\n"
+	talismanRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{
+		gitrepo.NewAddition("secrets.enc.yaml", []byte(sopsFile)),
+		gitrepo.NewAddition("group_vars/all/vault.yml", []byte(vaultFile)),
+	}, talismanRC, results)
+
+	var messages []string
+	for _, failure := range results.GetFailures("secrets.enc.yaml") {
+		messages = append(messages, failure.Message)
+	}
+	assert.Equal(t, []string{
+		`Expected file to not contain a literal value for secret key "database.api_key" such as: ` + helpers.Preview("hunter2secret"),
+	}, messages, "Expected only the value left in plaintext to be flagged, once")
+	assert.Contains(t, results.Explanations["secrets.enc.yaml"], helpers.Explanation{Detector: "encrypted", Decision: "encrypted with SOPS, so only the values left in plaintext are scanned"})
+	assert.Empty(t, results.GetFailures("group_vars/all/vault.yml"))
+	assert.Contains(t, results.Explanations["group_vars/all/vault.yml"], helpers.Explanation{Detector: "encrypted", Decision: "encrypted with ansible-vault, so its ciphertext is not scanned"})
+}
+
+func TestChainShouldEvaluateIgnoresAgainstTheContentOfEncryptedFiles(t *testing.T) {
+	sopsFile := []byte("password: ENC[AES256_GCM,data:Tr7oRCUn,iv:1T8Q,tag:dm6n,type:str]\ntoken: hunter2secret\nsops:\n    version: 3.8.1\n")
+	talismanRC := &talismanrc.TalismanRC{FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{
+		FileName: "secrets.enc.yaml",
+		Checksum: utility.SHA256HashOfContents("secrets.enc.yaml", sopsFile),
+	}}}
+	results := helpers.NewDetectionResults()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{gitrepo.NewAddition("secrets.enc.yaml", sopsFile)}, talismanRC, results)
+
+	assert.False(t, results.HasFailures(), "Expected the checksum of the committed content to ignore the file")
+}
+
+func TestChainShouldReportCredentialsInConnectionStringsWithoutTheirPasswords(t *testing.T) {
+	talismanRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+
+	DefaultChain(talismanRC, helpers.HistoricBlobEvaluator(talismanRC)).Test([]gitrepo.Addition{
+		gitrepo.NewAddition("main.go", []byte(`db, err := sql.Open("postgres", "postgres://app:Xk9mPq2z@db:5432/app")`)),
+	}, talismanRC, results)
+
+	var messages []string
+	for _, failure := range results.GetFailures("main.go") {
+		messages = append(messages, failure.Message)
+	}
+	assert.Equal(t, []string{"Expected file to not contain credentials in URLs or connection strings such as: postgres://app:****@db:5432/app"}, messages)
 }
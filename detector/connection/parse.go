@@ -0,0 +1,66 @@
+package connection
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const mask = "****"
+
+var (
+	// userInfoPattern matches URIs with a password in their user info, e.g. postgres://app:secret@db:5432/app or redis://:secret@cache:6379
+	userInfoPattern = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s:/?#@'"<>]*:([^\s/?#@'"<>]+)@[^\s/?#'"<>,;]+[^\s'"<>,;]*`)
+	// parameterPattern matches URIs and JDBC URLs with a password parameter,
+	// e.g. jdbc:postgresql://db/app?user=app&password=secret or jdbc:sqlserver://db;user=sa;password=secret
+	parameterPattern = regexp.MustCompile(`(?i)\b(?:jdbc:[a-z0-9]+:|[a-z][a-z0-9+.-]*://)[^\s'"<>]*?[?&;](?:password|passwd|pwd)=([^&;\s'"<>]+)[^\s'"<>]*`)
+	// keywordPattern matches ADO.NET and ODBC style connection strings, e.g. Server=db;Database=app;User Id=sa;Password=secret;
+	keywordPattern = regexp.MustCompile(`(?i)\b(?:server|data source|host|address|addr)\s*=[^;'"\n]*;(?:[^;'"\n]*;)*?\s*(?:password|passwd|pwd)\s*=[ \t]*([^;'"\s][^;'"\n]*);?`)
+)
+
+// Credential is a password found in a URI or a connection string of a content
+type Credential struct {
+	// Start and End locate the URI or connection string in the content
+	Start int
+	End   int
+	// PasswordStart and PasswordEnd locate the password in the content
+	PasswordStart int
+	PasswordEnd   int
+}
+
+// Password returns the password of the credential, decoded if it is URL encoded
+func (c Credential) Password(content string) string {
+	password := content[c.PasswordStart:c.PasswordEnd]
+	if decoded, err := url.PathUnescape(password); err == nil {
+		return decoded
+	}
+	return password
+}
+
+// Masked returns the URI or connection string of the credential, with its password masked
+func (c Credential) Masked(content string) string {
+	return content[c.Start:c.PasswordStart] + mask + content[c.PasswordEnd:c.End]
+}
+
+// Credentials returns the passwords found in the URIs and connection strings of a content, in order of appearance.
+// A password found by more than one pattern is returned once, and empty or blank passwords are not returned at all.
+func Credentials(content string) []Credential {
+	var credentials []Credential
+	seen := map[int]bool{}
+	for _, pattern := range []*regexp.Regexp{userInfoPattern, parameterPattern, keywordPattern} {
+		for _, match := range pattern.FindAllStringSubmatchIndex(content, -1) {
+			credential := Credential{Start: match[0], End: match[1], PasswordStart: match[2], PasswordEnd: match[3]}
+			if pattern == keywordPattern {
+				credential.PasswordEnd = credential.PasswordStart + len(strings.TrimRight(content[credential.PasswordStart:credential.PasswordEnd], " \t"))
+			}
+			if strings.TrimSpace(credential.Password(content)) == "" || seen[credential.PasswordStart] {
+				continue
+			}
+			seen[credential.PasswordStart] = true
+			credentials = append(credentials, credential)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].PasswordStart < credentials[j].PasswordStart })
+	return credentials
+}
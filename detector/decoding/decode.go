@@ -0,0 +1,100 @@
+package decoding
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Encodings of the texts that are decoded
+const (
+	Base64 = "base64"
+	Hex    = "hex"
+	URL    = "URL"
+)
+
+const minEncodedLength = 16
+
+var (
+	// base64 and hex texts share most of their characters, so both are found by the same pattern
+	base64OrHexPattern = regexp.MustCompile(`[A-Za-z0-9+/_-]{16,}={0,2}`)
+	hexPattern         = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	urlEncodedPattern  = regexp.MustCompile(`[A-Za-z0-9._~+-]*(%[0-9A-Fa-f]{2}[A-Za-z0-9._~+-]*)+`)
+)
+
+// encodedText is a text found at content[start:end] that decodes to printable text
+type encodedText struct {
+	encoding string
+	start    int
+	end      int
+	decoded  string
+}
+
+// encodedTexts returns the base64, hex and URL encoded texts in content, which decode to printable text
+func encodedTexts(content string) []encodedText {
+	var texts []encodedText
+	for _, match := range base64OrHexPattern.FindAllStringIndex(content, -1) {
+		candidate := content[match[0]:match[1]]
+		if decoded, ok := decodeHex(candidate); ok {
+			texts = append(texts, encodedText{Hex, match[0], match[1], decoded})
+		} else if decoded, ok := decodeBase64(candidate); ok {
+			texts = append(texts, encodedText{Base64, match[0], match[1], decoded})
+		}
+	}
+	for _, match := range urlEncodedPattern.FindAllStringIndex(content, -1) {
+		if decoded, err := url.QueryUnescape(content[match[0]:match[1]]); err == nil && isText(decoded) {
+			texts = append(texts, encodedText{URL, match[0], match[1], decoded})
+		}
+	}
+	return texts
+}
+
+func decodeHex(candidate string) (string, bool) {
+	if len(candidate)%2 != 0 || !hexPattern.MatchString(candidate) {
+		return "", false
+	}
+	decoded, err := hex.DecodeString(candidate)
+	return string(decoded), err == nil && isText(string(decoded))
+}
+
+func decodeBase64(candidate string) (string, bool) {
+	trimmed := strings.TrimRight(candidate, "=")
+	for _, encoding := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(trimmed); err == nil && isText(string(decoded)) {
+			return string(decoded), true
+		}
+	}
+	return "", false
+}
+
+// isText answers if decoded bytes are long enough to hold a secret, and only made of printable characters and whitespace
+func isText(decoded string) bool {
+	if len(decoded) < minEncodedLength/2 || !utf8.ValidString(decoded) {
+		return false
+	}
+	for _, r := range decoded {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// nameOf returns a file name telling the format of decoded content, so that the keys of JSON and YAML documents are checked
+func nameOf(decoded string) string {
+	if json.Valid([]byte(decoded)) {
+		return "decoded.json"
+	}
+	var document yaml.MapSlice
+	if strings.Contains(decoded, ":") && yaml.Unmarshal([]byte(decoded), &document) == nil && len(document) > 0 {
+		return "decoded.yaml"
+	}
+	return "decoded"
+}
@@ -0,0 +1,36 @@
+package decoding
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodedTextsFindsBase64HexAndURLEncodedTexts(t *testing.T) {
+	encodedBase64 := base64.StdEncoding.EncodeToString([]byte("password=hunter2secret"))
+	encodedHex := hex.EncodeToString([]byte("token: abc123def"))
+	content := "a: " + encodedBase64 + "\nb: " + encodedHex + "\nc: password%3Dhunter2%21\n"
+
+	texts := encodedTexts(content)
+
+	assert.Len(t, texts, 3)
+	assert.Equal(t, encodedText{Base64, 3, 3 + len(encodedBase64), "password=hunter2secret"}, texts[0])
+	assert.Equal(t, Hex, texts[1].encoding)
+	assert.Equal(t, "token: abc123def", texts[1].decoded)
+	assert.Equal(t, URL, texts[2].encoding)
+	assert.Equal(t, "password=hunter2!", texts[2].decoded)
+}
+
+func TestEncodedTextsSkipsTextsThatDoNotDecodeToPrintableText(t *testing.T) {
+	texts := encodedTexts("configurationManagementService " + base64.StdEncoding.EncodeToString([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}) + " a%20b")
+
+	assert.Empty(t, texts)
+}
+
+func TestNameOfTellsTheFormatOfDecodedContent(t *testing.T) {
+	assert.Equal(t, "decoded.json", nameOf(`{"password": "hunter2"}`))
+	assert.Equal(t, "decoded.yaml", nameOf("password: hunter2\nuser: admin"))
+	assert.Equal(t, "decoded", nameOf("user:hunter2"))
+}
@@ -0,0 +1,113 @@
+package decoding
+
+import (
+	"fmt"
+	"strings"
+	"talisman/detector/detector"
+	"talisman/detector/helpers"
+	"talisman/detector/keyvalue"
+	"talisman/detector/pattern"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DecodingDetector decodes base64, hex and URL encoded texts, and looks for secrets inside them with the pattern and key detectors.
+// Decoded texts are decoded again, up to the decoding depth of the .talismanrc.
+type DecodingDetector struct {
+	customPatterns []talismanrc.PatternString
+	depth          int
+}
+
+// NewDecodingDetector returns a DecodingDetector that decodes as many layers of encoding as the .talismanrc allows
+func NewDecodingDetector(tRC *talismanrc.TalismanRC) *DecodingDetector {
+	return &DecodingDetector{customPatterns: tRC.CustomPatterns, depth: tRC.DecodingDepth()}
+}
+
+// decodedFinding is a finding of another detector inside an encoded text
+type decodedFinding struct {
+	text     encodedText
+	line     int
+	message  string
+	severity severity.Severity
+}
+
+type decodedFindings struct {
+	addition gitrepo.Addition
+	findings []decodedFinding
+	allowed  []helpers.AllowedFinding
+}
+
+// Test tests the texts encoded in the Additions, once decoded
+func (dd *DecodingDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, tRC *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	helpers.CheckContents(comparator, currentAdditions, "decoding", result, additionCompletionCallback, func(addition gitrepo.Addition) func() {
+		decoded := dd.decode(comparator, addition, tRC, result)
+		return func() { processDecodedFindings(decoded, tRC, result) }
+	})
+}
+
+// decode runs the detectors on each encoded text of an Addition, once decoded
+func (dd *DecodingDetector) decode(comparator helpers.IgnoreEvaluator, addition gitrepo.Addition, tRC *talismanrc.TalismanRC, result *helpers.DetectionResults) decodedFindings {
+	decoded := decodedFindings{addition: addition}
+	if dd.depth <= 0 {
+		return decoded
+	}
+	content := string(addition.Data)
+	for _, text := range encodedTexts(content) {
+		line := strings.Count(content[:text.start], "\n") + 1
+		findings := dd.findingsIn(comparator, addition, text, tRC, result)
+		if len(findings) == 0 {
+			result.Explain(addition.Path, "decoding", "decoded %s text at line %d, nothing found inside", text.encoding, line)
+			continue
+		}
+		finding := helpers.NewFinding(content, text.start, text.end)
+		if pattern, allowed := tRC.AllowedPatternFor(addition, finding.Line, finding.Start, finding.End); allowed {
+			decoded.allowed = append(decoded.allowed, helpers.AllowedFinding{Secret: finding.Secret, Pattern: pattern})
+			continue
+		}
+		for _, details := range findings {
+			decoded.findings = append(decoded.findings, decodedFinding{text: text, line: line, message: details.Message, severity: details.Severity})
+		}
+	}
+	return decoded
+}
+
+// findingsIn returns what the pattern and key detectors find in a decoded text, as well as in the texts encoded inside it
+func (dd *DecodingDetector) findingsIn(comparator helpers.IgnoreEvaluator, addition gitrepo.Addition, text encodedText, tRC *talismanrc.TalismanRC, result *helpers.DetectionResults) []helpers.Details {
+	inner := gitrepo.Addition{Path: addition.Path, Name: gitrepo.FileName(nameOf(text.decoded)), Commits: addition.Commits, Data: []byte(text.decoded)}
+	innerResult := helpers.NewDetectionResults()
+	if result.RevealingSecrets() {
+		innerResult.RevealSecrets()
+	}
+	for _, d := range []detector.Detector{
+		pattern.NewPatternDetector(dd.customPatterns),
+		keyvalue.NewKeyValueDetector(),
+		&DecodingDetector{customPatterns: dd.customPatterns, depth: dd.depth - 1},
+	} {
+		d.Test(comparator, []gitrepo.Addition{inner}, tRC, innerResult, func() {})
+	}
+	return append(innerResult.GetFailures(addition.Path), innerResult.GetWarnings(addition.Path)...)
+}
+
+func processDecodedFindings(decoded decodedFindings, tRC *talismanrc.TalismanRC, result *helpers.DetectionResults) {
+	path := decoded.addition.Path
+	for _, allowed := range decoded.allowed {
+		result.IgnoreAllowedFinding(path, "filecontent", "decoding", allowed.Secret, allowed.Pattern)
+	}
+	for _, finding := range decoded.findings {
+		log.WithFields(log.Fields{
+			"filePath": path,
+			"encoding": finding.text.encoding,
+		}).Info("DecodingDetector: Failing file as it contains an encoded secret.")
+		encoded := string(decoded.addition.Data[finding.text.start:finding.text.end])
+		message := fmt.Sprintf("Expected file to not contain %s encoded secrets such as %s at line %d, which decodes to: %s",
+			finding.text.encoding, helpers.FormatForReporting(result.Masked(encoded)), finding.line, finding.message)
+		if findingSeverity, fails := result.AssessFinding(tRC, decoded.addition, "decoding", message, finding.severity, tRC.Threshold); fails {
+			result.Fail(path, "filecontent", message, decoded.addition.Commits, findingSeverity)
+		} else {
+			result.Warn(path, "filecontent", message, decoded.addition.Commits, findingSeverity)
+		}
+	}
+}
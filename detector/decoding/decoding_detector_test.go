@@ -0,0 +1,103 @@
+package decoding
+
+import (
+	"encoding/base64"
+	"regexp"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var dummyCallback = func() {}
+
+func encode(text string) string {
+	return base64.StdEncoding.EncodeToString([]byte(text))
+}
+
+func testDecoding(tRC *talismanrc.TalismanRC, addition gitrepo.Addition) *helpers.DetectionResults {
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+	NewDecodingDetector(tRC).Test(helpers.HistoricBlobEvaluator(tRC), []gitrepo.Addition{addition}, tRC, results, dummyCallback)
+	return results
+}
+
+func messagesOf(details []helpers.Details) []string {
+	var messages []string
+	for _, detail := range details {
+		messages = append(messages, detail.Message)
+	}
+	return messages
+}
+
+func TestShouldReportSecretPatternsInsideEncodedTexts(t *testing.T) {
+	encoded := encode("password=hunter2secret")
+
+	results := testDecoding(&talismanrc.TalismanRC{}, gitrepo.NewAddition("ci.yml", []byte("steps:\n  - run: echo "+encoded+" | base64 -d\n")))
+
+	assert.Equal(t, []string{"Expected file to not contain base64 encoded secrets such as " + helpers.Preview(encoded) + " at line 2, which decodes to: Potential secret pattern : password=" + helpers.Preview("hunter2secret")},
+		messagesOf(results.GetFailures("ci.yml")))
+}
+
+func TestShouldReportEncodedSecretsInFullOnlyWhenRevealed(t *testing.T) {
+	encoded := encode("password=hunter2secret")
+	tRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+	results.RevealSecrets()
+
+	NewDecodingDetector(tRC).Test(helpers.HistoricBlobEvaluator(tRC), []gitrepo.Addition{gitrepo.NewAddition("ci.yml", []byte(encoded))}, tRC, results, dummyCallback)
+
+	assert.Equal(t, []string{"Expected file to not contain base64 encoded secrets such as " + encoded + " at line 1, which decodes to: Potential secret pattern : password=hunter2secret"},
+		messagesOf(results.GetFailures("ci.yml")))
+}
+
+func TestShouldReportSecretKeysOfEncodedDockerConfigs(t *testing.T) {
+	dockerConfig := `{"auths": {"registry.example.com": {"username": "bot", "password": "hunter2secret", "auth": "` + encode("bot:hunter2secret") + `"}}}`
+	manifest := "apiVersion: v1\nkind: Secret\ntype: kubernetes.io/dockerconfigjson\ndata:\n  .dockerconfigjson: " + encode(dockerConfig) + "\n"
+
+	results := testDecoding(&talismanrc.TalismanRC{}, gitrepo.NewAddition("registry-secret.yml", []byte(manifest)))
+
+	failures := messagesOf(results.GetFailures("registry-secret.yml"))
+	assert.Len(t, failures, 2, "Expected both the password phrase and the password key inside the docker config to be reported")
+	assert.Regexp(t, `^Expected file to not contain base64 encoded secrets such as .* at line 5, which decodes to: Expected file to not contain a literal value for secret key "auths.registry.example.com.password"`, failures[1])
+}
+
+func TestShouldDecodeUpToTheConfiguredDepth(t *testing.T) {
+	twiceEncoded := encode("config: " + encode("password=hunter2secret"))
+	addition := gitrepo.NewAddition("values.txt", []byte(twiceEncoded))
+
+	assert.True(t, testDecoding(&talismanrc.TalismanRC{}, addition).HasFailures(), "Expected the default depth to decode both layers")
+	assert.False(t, testDecoding(&talismanrc.TalismanRC{Decoding: talismanrc.DecodingConfig{MaxDepth: 1}}, addition).HasFailures())
+	assert.False(t, testDecoding(&talismanrc.TalismanRC{Decoding: talismanrc.DecodingConfig{MaxDepth: -1}}, addition).HasFailures())
+
+	failures := messagesOf(testDecoding(&talismanrc.TalismanRC{}, addition).GetFailures("values.txt"))
+	assert.Regexp(t, `which decodes to: Expected file to not contain base64 encoded secrets such as .* at line 1, which decodes to: Potential secret pattern : password=`+regexp.QuoteMeta(helpers.Preview("hunter2secret"))+`$`, failures[0])
+}
+
+func TestShouldReportURLAndHexEncodedSecrets(t *testing.T) {
+	results := testDecoding(&talismanrc.TalismanRC{}, gitrepo.NewAddition("links.md", []byte("https://example.com/?q=password%3Dhunter2secret\n70617373776f72643d68756e7465723273656372657421\n")))
+
+	failures := messagesOf(results.GetFailures("links.md"))
+	assert.Len(t, failures, 2)
+	assert.Contains(t, failures[0], "hex encoded secrets such as "+helpers.Preview("70617373776f72643d68756e7465723273656372657421")+" at line 2")
+	assert.Contains(t, failures[1], "URL encoded secrets such as "+helpers.Preview("password%3Dhunter2secret")+" at line 1")
+}
+
+func TestShouldNotReportEncodedTextsAllowedByTalismanRC(t *testing.T) {
+	encoded := encode("password=hunter2secret")
+	tRC := &talismanrc.TalismanRC{AllowedPatterns: []*talismanrc.Pattern{{Regexp: regexp.MustCompile(regexp.QuoteMeta(encoded))}}}
+
+	results := testDecoding(tRC, gitrepo.NewAddition("ci.yml", []byte(encoded)))
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasIgnores())
+}
+
+func TestShouldExplainEncodedTextsWithoutFindings(t *testing.T) {
+	results := testDecoding(&talismanrc.TalismanRC{}, gitrepo.NewAddition("notes.txt", []byte("id: "+encode("just some harmless text"))))
+
+	assert.False(t, results.HasFailures())
+	assert.Contains(t, results.Explanations["notes.txt"], helpers.Explanation{Detector: "decoding", Decision: "decoded base64 text at line 1, nothing found inside"})
+}
@@ -12,3 +12,10 @@ import (
 type Detector interface {
 	Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func())
 }
+
+// BinaryDetector is a Detector that also tests binary files, e.g. by their name, size or format.
+// Other detectors read the Additions as text, so they are only given text files.
+type BinaryDetector interface {
+	Detector
+	TestsBinaryFiles() bool
+}
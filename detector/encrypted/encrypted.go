@@ -0,0 +1,220 @@
+package encrypted
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"talisman/gitrepo"
+)
+
+// Formats of encrypted secret files
+const (
+	SOPS         = "SOPS"
+	AnsibleVault = "ansible-vault"
+	GitCrypt     = "git-crypt"
+	SealedSecret = "SealedSecret"
+)
+
+var (
+	gitCryptMagic     = []byte("\x00GITCRYPT\x00")
+	ansibleVaultMagic = "$ANSIBLE_VAULT;"
+
+	sopsValuePattern      = regexp.MustCompile(`ENC\[AES256_GCM,data:[^\]]*\]`)
+	sopsYAMLPattern       = regexp.MustCompile(`(?m)^sops:[ \t]*$`)
+	sopsJSONPattern       = regexp.MustCompile(`"sops"\s*:\s*\{`)
+	sopsINIPattern        = regexp.MustCompile(`(?m)^\[sops\][ \t]*$`)
+	sopsEnvPattern        = regexp.MustCompile(`(?m)^sops_[A-Za-z0-9_]*=.*$`)
+	sealedSecretPattern   = regexp.MustCompile(`(?m)^kind:[ \t]*["']?SealedSecret["']?[ \t]*$|"kind"\s*:\s*"SealedSecret"`)
+	encryptedDataYAML     = regexp.MustCompile(`(?m)^[ \t]*encryptedData:[ \t]*$`)
+	encryptedDataJSON     = regexp.MustCompile(`"encryptedData"\s*:\s*\{`)
+	hexLinePattern        = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	vaultTagPattern       = regexp.MustCompile(`!vault[ \t]*[|>][-+]?[ \t]*\r?\n$`)
+	iniSectionLinePattern = regexp.MustCompile(`(?m)^\[`)
+)
+
+// Span is a part of a content, content[Start:End]
+type Span struct {
+	Start int
+	End   int
+}
+
+// Encryption tells how the secrets of a file are encrypted
+type Encryption struct {
+	Format string
+	// Ciphertext holds the parts of the content that are ciphertext or encryption metadata, or nil when all of it is
+	Ciphertext []Span
+}
+
+// Whole answers if the whole content of the file is ciphertext, so that it holds no plaintext at all
+func (e Encryption) Whole() bool {
+	return e.Ciphertext == nil
+}
+
+// Blank returns a copy of data in which the ciphertext is replaced with spaces.
+// Line breaks are kept, so that what remains is found at the same lines and offsets.
+func (e Encryption) Blank(data []byte) []byte {
+	blanked := append([]byte{}, data...)
+	for _, span := range e.Ciphertext {
+		for i := span.Start; i < span.End; i++ {
+			if blanked[i] != '\n' && blanked[i] != '\r' {
+				blanked[i] = ' '
+			}
+		}
+	}
+	return blanked
+}
+
+// Recognise answers if an Addition is a file encrypted with SOPS, ansible-vault or git-crypt, or a SealedSecret manifest,
+// and returns which parts of it are ciphertext
+func Recognise(addition gitrepo.Addition) (Encryption, bool) {
+	data := addition.Data
+	if bytes.HasPrefix(data, gitCryptMagic) {
+		return Encryption{Format: GitCrypt}, true
+	}
+	content := string(data)
+	if isAnsibleVaultFile(content) {
+		return Encryption{Format: AnsibleVault}, true
+	}
+	if spans := sopsCiphertext(content); len(spans) > 0 {
+		return Encryption{Format: SOPS, Ciphertext: spans}, true
+	}
+	if spans := ansibleVaultCiphertext(content); len(spans) > 0 {
+		return Encryption{Format: AnsibleVault, Ciphertext: spans}, true
+	}
+	if sealedSecretPattern.MatchString(content) {
+		if spans := sealedSecretCiphertext(content); len(spans) > 0 {
+			return Encryption{Format: SealedSecret, Ciphertext: spans}, true
+		}
+	}
+	return Encryption{}, false
+}
+
+// sopsCiphertext returns the encrypted values of a file encrypted with SOPS, along with its sops metadata,
+// which holds the encrypted data keys. Files without sops metadata are not SOPS files.
+func sopsCiphertext(content string) []Span {
+	var metadata []Span
+	for _, match := range sopsYAMLPattern.FindAllStringIndex(content, -1) {
+		metadata = append(metadata, yamlBlock(content, match[0]))
+	}
+	for _, match := range sopsJSONPattern.FindAllStringIndex(content, -1) {
+		metadata = append(metadata, jsonObjectInterior(content, match[1]-1))
+	}
+	for _, match := range sopsINIPattern.FindAllStringIndex(content, -1) {
+		end := len(content)
+		if next := iniSectionLinePattern.FindStringIndex(content[match[1]:]); next != nil {
+			end = match[1] + next[0]
+		}
+		metadata = append(metadata, Span{match[0], end})
+	}
+	for _, match := range sopsEnvPattern.FindAllStringIndex(content, -1) {
+		metadata = append(metadata, Span{match[0], match[1]})
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	spans := metadata
+	for _, match := range sopsValuePattern.FindAllStringIndex(content, -1) {
+		spans = append(spans, Span{match[0], match[1]})
+	}
+	return spans
+}
+
+// isAnsibleVaultFile answers if a whole file is encrypted with ansible-vault, i.e. if it starts with the $ANSIBLE_VAULT header
+// and every line after it is hex. Files holding anything else, e.g. plaintext appended to the ciphertext, are not encrypted as a whole.
+func isAnsibleVaultFile(content string) bool {
+	content = strings.TrimLeft(content, " \t\r\n")
+	if !strings.HasPrefix(content, ansibleVaultMagic) {
+		return false
+	}
+	lines := strings.Split(content, "\n")
+	for _, line := range lines[1:] {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !hexLinePattern.MatchString(trimmed) {
+			return false
+		}
+	}
+	return true
+}
+
+// ansibleVaultCiphertext returns the values encrypted inline with ansible-vault, e.g. password: !vault | followed by
+// an indented $ANSIBLE_VAULT header and lines of hex. The !vault tag is part of the ciphertext, so that the key is left without a value.
+func ansibleVaultCiphertext(content string) []Span {
+	var spans []Span
+	lines := strings.SplitAfter(content, "\n")
+	offset := 0
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, ansibleVaultMagic) {
+			offset += len(lines[i])
+			continue
+		}
+		indent := indentationOf(lines[i])
+		start := offset + strings.Index(lines[i], ansibleVaultMagic)
+		if i > 0 {
+			if tag := vaultTagPattern.FindStringIndex(lines[i-1]); tag != nil {
+				start = offset - len(lines[i-1]) + tag[0]
+			}
+		}
+		offset += len(lines[i])
+		for i+1 < len(lines) && indentationOf(lines[i+1]) >= indent && hexLinePattern.MatchString(strings.TrimSpace(lines[i+1])) {
+			i++
+			offset += len(lines[i])
+		}
+		spans = append(spans, Span{start, offset})
+	}
+	return spans
+}
+
+// sealedSecretCiphertext returns the encryptedData of a SealedSecret manifest
+func sealedSecretCiphertext(content string) []Span {
+	var spans []Span
+	for _, match := range encryptedDataYAML.FindAllStringIndex(content, -1) {
+		spans = append(spans, yamlBlock(content, match[0]))
+	}
+	for _, match := range encryptedDataJSON.FindAllStringIndex(content, -1) {
+		spans = append(spans, jsonObjectInterior(content, match[1]-1))
+	}
+	return spans
+}
+
+// yamlBlock returns the span of a YAML key starting at lineStart, along with all the lines that are indented below it
+func yamlBlock(content string, lineStart int) Span {
+	lines := strings.SplitAfter(content[lineStart:], "\n")
+	indent := indentationOf(lines[0])
+	end := lineStart + len(lines[0])
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && indentationOf(line) <= indent {
+			break
+		}
+		end += len(line)
+	}
+	return Span{lineStart, end}
+}
+
+// jsonObjectInterior returns the span between the braces of the JSON object opening at content[open]
+func jsonObjectInterior(content string, open int) Span {
+	depth := 0
+	inString := false
+	for i := open; i < len(content); i++ {
+		switch c := content[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return Span{open + 1, i}
+			}
+		}
+	}
+	return Span{open + 1, len(content)}
+}
+
+func indentationOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-	"sync"
 	"talisman/detector/helpers"
+	"talisman/detector/placeholder"
 	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
@@ -73,10 +73,12 @@ type content struct {
 	path        gitrepo.FilePath
 	contentType contentType
 	results     []string
+	allowed     []helpers.AllowedFinding
 	severity    severity.Severity
 }
 
 func (fc *FileContentDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, talismanRC *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	severities := talismanRC.SeverityConfiguration()
 	contentTypes := []struct {
 		contentType
 		fn
@@ -85,114 +87,102 @@ func (fc *FileContentDetector) Test(comparator helpers.IgnoreEvaluator, currentA
 		{
 			contentType: base64Content,
 			fn:          checkBase64,
-			severity:    severity.SeverityConfiguration["Base64Content"],
+			severity:    severities.SeverityOf("Base64Content"),
 		},
 		{
 			contentType: hexContent,
 			fn:          checkHex,
-			severity:    severity.SeverityConfiguration["HexContent"],
+			severity:    severities.SeverityOf("HexContent"),
 		},
 		{
 			contentType: creditCardContent,
 			fn:          checkCreditCardNumber,
-			severity:    severity.SeverityConfiguration["CreditCardContent"],
+			severity:    severities.SeverityOf("CreditCardContent"),
 		},
 	}
 	re := regexp.MustCompile(`(?i)checksum[ \t]*:[ \t]*[0-9a-fA-F]+`)
+	placeholders := placeholder.NewClassifier(talismanRC.Placeholders)
 
-	contents := make(chan content, 512)
-	ignoredFilePaths := make(chan gitrepo.FilePath, len(currentAdditions))
-
-	waitGroup := &sync.WaitGroup{}
-	waitGroup.Add(len(currentAdditions))
-	for _, addition := range currentAdditions {
-		go func(addition gitrepo.Addition) {
-			defer waitGroup.Done()
-			defer additionCompletionCallback()
-			if comparator.ShouldIgnore(addition, "filecontent") {
-				ignoredFilePaths <- addition.Path
-				return
-			}
-
-			if string(addition.Name) == talismanrc.RCFileName {
-				content := re.ReplaceAllString(string(addition.Data), "")
-				data := []byte(content)
-				addition.Data = data
+	helpers.CheckContents(comparator, currentAdditions, "filecontent", result, additionCompletionCallback, func(addition gitrepo.Addition) func() {
+		if string(addition.Name) == talismanrc.RCFileName {
+			content := re.ReplaceAllString(string(addition.Data), "")
+			data := []byte(content)
+			addition.Data = data
+		}
+		var contents []content
+		for _, ct := range contentTypes {
+			c := content{
+				name:        addition.Name,
+				path:        addition.Path,
+				contentType: ct.contentType,
+				severity:    ct.severity,
 			}
-			addition.Data = []byte(talismanRC.RemoveAllowedPatterns(addition))
-			for _, ct := range contentTypes {
-				contents <- content{
-					name:        addition.Name,
-					path:        addition.Path,
-					contentType: ct.contentType,
-					results:     fc.detectFile(addition.Data, ct.fn),
-					severity:    ct.severity,
+			for _, finding := range fc.detectFile(addition.Data, ct.fn) {
+				if kind, isPlaceholder := classifyWord(placeholders, finding.Secret); isPlaceholder {
+					result.Explain(addition.Path, "filecontent", "not flagging %q as it is a placeholder (%s)", helpers.FormatForReporting(finding.Secret), kind)
+					continue
+				}
+				if pattern, allowed := talismanRC.AllowedPatternFor(addition, finding.Line, finding.Start, finding.End); allowed {
+					c.allowed = append(c.allowed, helpers.AllowedFinding{Secret: finding.Secret, Pattern: pattern})
+				} else {
+					c.results = append(c.results, finding.Secret)
 				}
 			}
-		}(addition)
-	}
-	go func() {
-		waitGroup.Wait()
-		close(ignoredFilePaths)
-		close(contents)
-	}()
-	for ignoredChanHasMore, contentChanHasMore := true, true; ignoredChanHasMore || contentChanHasMore; {
-		select {
-		case ignoredFilePath, hasMore := <-ignoredFilePaths:
-			log.Debugf("Processing results for ignored file %v", ignoredFilePath)
-			if !hasMore {
-				ignoredChanHasMore = false
-				continue
-			}
-			processIgnoredFilepath(ignoredFilePath, result)
-		case c, hasMore := <-contents:
-			log.Debugf("Processing results for file %v", c.path)
-			if !hasMore {
-				contentChanHasMore = false
-				continue
+			contents = append(contents, c)
+		}
+		return func() {
+			for _, c := range contents {
+				log.Debugf("Processing results for file %v", c.path)
+				processContent(c, talismanRC, result)
 			}
-			processContent(c, talismanRC.Threshold, result)
 		}
-	}
-}
-
-func processIgnoredFilepath(path gitrepo.FilePath, result *helpers.DetectionResults) {
-	log.WithFields(log.Fields{
-		"filePath": path,
-	}).Info("Ignoring addition as it was specified to be ignored.")
-	result.Ignore(path, "filecontent")
+	})
 }
 
-func processContent(c content, threshold severity.Severity, result *helpers.DetectionResults) {
+func processContent(c content, talismanRC *talismanrc.TalismanRC, result *helpers.DetectionResults) {
+	for _, allowed := range c.allowed {
+		result.IgnoreAllowedFinding(c.path, "filecontent", "filecontent", allowed.Secret, allowed.Pattern)
+	}
 	for _, res := range c.results {
 		if res != "" {
 			log.WithFields(log.Fields{
 				"filePath": c.path,
 			}).Info(c.contentType.getInfo())
-			if string(c.name) == talismanrc.RCFileName || !c.severity.ExceedsThreshold(threshold) {
-				result.Warn(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), formatForReporting(res)), []string{}, c.severity)
+			message := fmt.Sprintf(c.contentType.getMessageFormat(), helpers.FormatForReporting(result.Masked(res)))
+			if string(c.name) == talismanrc.RCFileName {
+				result.Explain(c.path, "filecontent", "%s: findings in %s are only reported as warnings", message, talismanrc.RCFileName)
+				result.Warn(c.path, "filecontent", message, []string{}, c.severity)
+				continue
+			}
+			addition := gitrepo.Addition{Path: c.path, Name: c.name}
+			if findingSeverity, fails := result.AssessFinding(talismanRC, addition, "filecontent", message, c.severity, talismanRC.Threshold); fails {
+				result.Fail(c.path, "filecontent", message, []string{}, findingSeverity)
 			} else {
-				result.Fail(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), formatForReporting(res)), []string{}, c.severity)
+				result.Warn(c.path, "filecontent", message, []string{}, findingSeverity)
 			}
 		}
 	}
 }
 
-func formatForReporting(input string) string {
-	if len(input) > 50 {
-		return input[:47] + "..."
+// classifyWord answers if a word is a placeholder, or assigns one, as in key="${KEY}"
+func classifyWord(placeholders *placeholder.Classifier, word string) (string, bool) {
+	if kind, isPlaceholder := placeholders.Classify(word); isPlaceholder {
+		return kind, true
+	}
+	if index := strings.IndexAny(word, "=:"); index >= 0 {
+		return placeholders.Classify(word[index+1:])
 	}
-	return input
+	return "", false
 }
 
-func (fc *FileContentDetector) detectFile(data []byte, getResult fn) []string {
+func (fc *FileContentDetector) detectFile(data []byte, getResult fn) []helpers.Finding {
 	content := string(data)
 	return fc.checkEachLine(content, getResult)
 }
 
-func (fc *FileContentDetector) checkEachLine(content string, getResult fn) []string {
+func (fc *FileContentDetector) checkEachLine(content string, getResult fn) []helpers.Finding {
 	lines := strings.Split(content, "\n")
-	res := []string{}
+	res := []helpers.Finding{}
 	for _, line := range lines {
 		lineResult := fc.checkEachWord(line, getResult)
 		if len(lineResult) > 0 {
@@ -202,13 +192,16 @@ func (fc *FileContentDetector) checkEachLine(content string, getResult fn) []str
 	return res
 }
 
-func (fc *FileContentDetector) checkEachWord(line string, getResult fn) []string {
+func (fc *FileContentDetector) checkEachWord(line string, getResult fn) []helpers.Finding {
 	words := strings.Fields(line)
-	res := []string{}
+	res := []helpers.Finding{}
+	offset := 0
 	for _, word := range words {
+		start := offset + strings.Index(line[offset:], word)
+		offset = start + len(word)
 		wordResult := getResult(fc, word)
 		if wordResult != "" {
-			res = append(res, wordResult)
+			res = append(res, helpers.Finding{Secret: wordResult, Line: line, Start: start, End: offset})
 		}
 	}
 	return res
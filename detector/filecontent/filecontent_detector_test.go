@@ -8,16 +8,22 @@ import (
 	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
+	"talisman/utility"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 var emptyTalismanRC = &talismanrc.TalismanRC{FileIgnoreConfig: []talismanrc.FileIgnoreConfig{}}
-var defaultIgnoreEvaluator = helpers.BuildIgnoreEvaluator("default", emptyTalismanRC, gitrepo.RepoLocatedAt("."))
+var defaultIgnoreEvaluator = ignoreEvaluatorWithTalismanRC(emptyTalismanRC)
 var dummyCallback = func() {}
 var filename = "filename"
 
+func ignoreEvaluatorWithTalismanRC(tRC *talismanrc.TalismanRC) helpers.IgnoreEvaluator {
+	ie, _ := helpers.BuildIgnoreEvaluator(utility.NewHashers(), "default", tRC, gitrepo.RepoLocatedAt("."))
+	return ie
+}
+
 func TestShouldNotFlagSafeText(t *testing.T) {
 	results := helpers.NewDetectionResults()
 	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte("prettySafe"))}
@@ -76,7 +82,7 @@ func TestShouldFlagPotentialAWSSecretKeys(t *testing.T) {
 		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, results, dummyCallback)
 
 	expectedMessage := fmt.
-		Sprintf("Expected file to not contain base64 encoded texts such as: %s", awsSecretAccessKey)
+		Sprintf("Expected file to not contain base64 encoded texts such as: %s", helpers.Preview(awsSecretAccessKey))
 	assert.True(t, results.HasFailures(), "Expected file to not contain base64 encoded texts.")
 	assert.Equal(t, expectedMessage, getFailureMessages(results, filePath)[0])
 	assert.Len(t, results.Results, 1)
@@ -85,6 +91,7 @@ func TestShouldFlagPotentialAWSSecretKeys(t *testing.T) {
 func TestShouldFlagPotentialSecretWithoutTrimmingWhenLengthLessThan50Characters(t *testing.T) {
 	const secret string = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9asdfa"
 	results := helpers.NewDetectionResults()
+	results.RevealSecrets()
 	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte(secret))}
 	filePath := additions[0].Path
 
@@ -101,6 +108,7 @@ func TestShouldFlagPotentialJWT(t *testing.T) {
 	const jwt string = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJpc3MiOiJzY290Y2guaW8iLCJleHAiOjEzMDA4MTkzODAsIm5hbWUi" +
 		"OiJDaHJpcyBTZXZpbGxlamEiLCJhZG1pbiI6dHJ1ZX0.03f329983b86f7d9a9f5fef85305880101d5e302afafa20154d094b229f757"
 	results := helpers.NewDetectionResults()
+	results.RevealSecrets()
 	content := []byte(jwt)
 	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, content)}
 	filePath := additions[0].Path
@@ -124,6 +132,7 @@ func TestShouldFlagPotentialSecretsWithinJavaCode(t *testing.T) {
 		"		}\r\n\r\n" +
 		"}"
 	results := helpers.NewDetectionResults()
+	results.RevealSecrets()
 	content := []byte(dangerousJavaCode)
 	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, content)}
 	filePath := additions[0].Path
@@ -170,7 +179,7 @@ func TestShouldFlagPotentialSecretsEncodedInHex(t *testing.T) {
 
 	NewFileContentDetector(emptyTalismanRC).
 		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, results, dummyCallback)
-	expectedMessage := "Expected file to not contain hex encoded texts such as: " + hex
+	expectedMessage := "Expected file to not contain hex encoded texts such as: " + helpers.Preview(hex)
 	assert.Equal(t, expectedMessage, getFailureMessages(results, filePath)[0])
 	assert.Len(t, results.Results, 1)
 }
@@ -180,7 +189,7 @@ func TestShouldNotFlagPotentialCreditCardNumberIfAboveThreshold(t *testing.T) {
 	results := helpers.NewDetectionResults()
 	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte(creditCardNumber))}
 	talismanRCWithThreshold := &talismanrc.TalismanRC{Threshold: severity.High}
-	ignoreEvaluatorWithThreshold := helpers.BuildIgnoreEvaluator("default", talismanRCWithThreshold, gitrepo.RepoLocatedAt("."))
+	ignoreEvaluatorWithThreshold := ignoreEvaluatorWithTalismanRC(talismanRCWithThreshold)
 
 	NewFileContentDetector(emptyTalismanRC).
 		Test(ignoreEvaluatorWithThreshold, additions, talismanRCWithThreshold, results, dummyCallback)
@@ -201,6 +210,49 @@ func TestShouldNotFlagPotentialSecretsIfIgnored(t *testing.T) {
 	assert.False(t, results.HasFailures(), "Expected file ignore allowed pattern for hex text")
 }
 
+func TestShouldNotGlueNeighbouringTokensWhenApplyingAllowedPatterns(t *testing.T) {
+	talismanRCWithIgnores := &talismanrc.TalismanRC{
+		AllowedPatterns: []*talismanrc.Pattern{{Regexp: regexp.MustCompile(" SAFE ")}}}
+	results := helpers.NewDetectionResults()
+	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte("68656C6C6F20 SAFE 776F726C6421"))}
+
+	NewFileContentDetector(emptyTalismanRC).
+		Test(defaultIgnoreEvaluator, additions, talismanRCWithIgnores, results, dummyCallback)
+
+	assert.False(t, results.HasFailures(), "Expected the tokens around an allowed pattern to be checked separately")
+}
+
+func TestShouldRecordFindingsSuppressedByAllowedPatternsAsIgnored(t *testing.T) {
+	const hex string = "68656C6C6F20776F726C6421"
+	talismanRCWithIgnores := &talismanrc.TalismanRC{
+		AllowedPatterns: []*talismanrc.Pattern{{Regexp: regexp.MustCompile("id=" + hex)}}}
+	results := helpers.NewDetectionResults()
+	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte("id="+hex))}
+
+	NewFileContentDetector(emptyTalismanRC).
+		Test(defaultIgnoreEvaluator, additions, talismanRCWithIgnores, results, dummyCallback)
+
+	assert.False(t, results.HasFailures())
+	assert.Equal(t, helpers.Details{Category: "filecontent", Message: fmt.Sprintf("finding %q is allowed by pattern %q", helpers.Preview("id="+hex), "id="+hex), Commits: []string{}, Severity: severity.Low},
+		results.Results[0].IgnoreList[0])
+}
+
+func TestShouldNotFlagPlaceholdersOfTalismanRC(t *testing.T) {
+	const hex string = "68656C6C6F20776F726C6421"
+	talismanRCWithPlaceholders := &talismanrc.TalismanRC{
+		Placeholders: []*talismanrc.Pattern{{Regexp: regexp.MustCompile("^68656C6C6F")}}}
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte("id=\""+hex+"\""))}
+
+	NewFileContentDetector(emptyTalismanRC).
+		Test(defaultIgnoreEvaluator, additions, talismanRCWithPlaceholders, results, dummyCallback)
+
+	assert.False(t, results.HasFailures())
+	assert.Contains(t, results.Explanations[gitrepo.FilePath(filename)],
+		helpers.Explanation{Detector: "filecontent", Decision: fmt.Sprintf("not flagging %q as it is a placeholder (placeholder matching %q)", "id=\""+hex+"\"", "^68656C6C6F")})
+}
+
 func TestResultsShouldNotFlagCreditCardNumberIfSpecifiedInFileIgnores(t *testing.T) {
 	const creditCardNumber string = "340000000000009"
 	results := helpers.NewDetectionResults()
@@ -230,7 +282,7 @@ func TestResultsShouldContainHexTextsIfHexAndBase64ExistInFile(t *testing.T) {
 
 	NewFileContentDetector(emptyTalismanRC).
 		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, results, dummyCallback)
-	expectedMessage := "Expected file to not contain hex encoded texts such as: " + hex
+	expectedMessage := regexp.QuoteMeta("Expected file to not contain hex encoded texts such as: " + helpers.Preview(hex))
 	messageReceived := strings.Join(getFailureMessages(results, filePath), " ")
 	assert.Regexp(t, expectedMessage, messageReceived, "Should contain hex detection message")
 	assert.Len(t, results.Results, 1)
@@ -247,7 +299,7 @@ func TestResultsShouldContainBase64TextsIfHexAndBase64ExistInFile(t *testing.T)
 	NewFileContentDetector(emptyTalismanRC).
 		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, results, dummyCallback)
 
-	expectedMessage := "Expected file to not contain base64 encoded texts such as: " + base64
+	expectedMessage := regexp.QuoteMeta("Expected file to not contain base64 encoded texts such as: " + helpers.Preview(base64))
 	messageReceived := strings.Join(getFailureMessages(results, filePath), " ")
 	assert.Regexp(t, expectedMessage, messageReceived, "Should contain base64 detection message")
 	assert.Len(t, results.Results, 1)
@@ -262,11 +314,27 @@ func TestResultsShouldContainCreditCardNumberIfCreditCardNumberExistInFile(t *te
 	NewFileContentDetector(emptyTalismanRC).
 		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, results, dummyCallback)
 
-	expectedMessage := "Expected file to not contain credit card numbers such as: " + creditCardNumber
+	expectedMessage := "Expected file to not contain credit card numbers such as: " + helpers.Preview(creditCardNumber)
 	assert.Equal(t, expectedMessage, getFailureMessages(results, filePath)[0])
 	assert.Len(t, results.Results, 1)
 }
 
+func TestShouldOnlyReportSecretsInFullWhenRevealed(t *testing.T) {
+	const awsSecretAccessKey string = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte(awsSecretAccessKey))}
+	masked := helpers.NewDetectionResults()
+	revealed := helpers.NewDetectionResults()
+	revealed.RevealSecrets()
+
+	NewFileContentDetector(emptyTalismanRC).
+		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, masked, dummyCallback)
+	NewFileContentDetector(emptyTalismanRC).
+		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, revealed, dummyCallback)
+
+	assert.NotContains(t, getFailureMessages(masked, additions[0].Path)[0], awsSecretAccessKey)
+	assert.Equal(t, "Expected file to not contain base64 encoded texts such as: "+awsSecretAccessKey, getFailureMessages(revealed, additions[0].Path)[0])
+}
+
 func getFailureMessages(results *helpers.DetectionResults, filePath gitrepo.FilePath) []string {
 	failureMessages := []string{}
 	for _, failureDetails := range results.GetFailures(filePath) {
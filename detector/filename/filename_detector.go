@@ -15,53 +15,53 @@ import (
 
 var (
 	filenamePatterns = []*severity.PatternSeverity{
-		{Pattern: regexp.MustCompile(`^.+_rsa$`), Severity: severity.SeverityConfiguration["RSAFile"]},
-		{Pattern: regexp.MustCompile(`^.+_dsa.*$`), Severity: severity.SeverityConfiguration["DSAFile"]},
-		{Pattern: regexp.MustCompile(`^.+_ed25519$`), Severity: severity.SeverityConfiguration["DSAFile"]},
-		{Pattern: regexp.MustCompile(`^.+_ecdsa$`), Severity: severity.SeverityConfiguration["DSAFile"]},
-		{Pattern: regexp.MustCompile(`^\.\w+_history$`), Severity: severity.SeverityConfiguration["ShellHistory"]},
-		{Pattern: regexp.MustCompile(`^.+\.pem$`), Severity: severity.SeverityConfiguration["PemFile"]},
-		{Pattern: regexp.MustCompile(`^.+\.ppk$`), Severity: severity.SeverityConfiguration["PpkFile"]},
-		{Pattern: regexp.MustCompile(`^.+\.key(pair)?$`), Severity: severity.SeverityConfiguration["KeyPairFile"]},
-		{Pattern: regexp.MustCompile(`^.+\.pkcs12$`), Severity: severity.SeverityConfiguration["PKCSFile"]},
-		{Pattern: regexp.MustCompile(`^.+\.pfx$`), Severity: severity.SeverityConfiguration["PFXFile"]},
-		{Pattern: regexp.MustCompile(`^.+\.p12$`), Severity: severity.SeverityConfiguration["P12File"]},
-		{Pattern: regexp.MustCompile(`^.+\.asc$`), Severity: severity.SeverityConfiguration["ASCFile"]},
-		{Pattern: regexp.MustCompile(`^\.?htpasswd$`), Severity: severity.SeverityConfiguration["HTPASSWDFile"]},
-		{Pattern: regexp.MustCompile(`^\.?netrc$`), Severity: severity.SeverityConfiguration["NetrcFile"]},
-		{Pattern: regexp.MustCompile(`^.*\.tblk$`), Severity: severity.SeverityConfiguration["TunnelBlockFile"]},
-		{Pattern: regexp.MustCompile(`^.*\.ovpn$`), Severity: severity.SeverityConfiguration["OpenVPNFile"]},
-		{Pattern: regexp.MustCompile(`^.*\.kdb$`), Severity: severity.SeverityConfiguration["KDBFile"]},
-		{Pattern: regexp.MustCompile(`^.*\.agilekeychain$`), Severity: severity.SeverityConfiguration["AgileKeyChainFile"]},
-		{Pattern: regexp.MustCompile(`^.*\.keychain$`), Severity: severity.SeverityConfiguration["KeyChainFile"]},
-		{Pattern: regexp.MustCompile(`^.*\.key(store|ring)$`), Severity: severity.SeverityConfiguration["KeyStoreFile"]},
-		{Pattern: regexp.MustCompile(`^jenkins\.plugins\.publish_over_ssh\.BapSshPublisherPlugin.xml$`), Severity: severity.SeverityConfiguration["JenkinsPublishOverSSHFile"]},
-		{Pattern: regexp.MustCompile(`^credentials\.xml$`), Severity: severity.SeverityConfiguration["CredentialsXML"]},
-		{Pattern: regexp.MustCompile(`^.*\.pubxml(\.user)?$`), Severity: severity.SeverityConfiguration["PubXML"]},
-		{Pattern: regexp.MustCompile(`^\.?s3cfg$`), Severity: severity.SeverityConfiguration["s3Config"]},
-		{Pattern: regexp.MustCompile(`^\.gitrobrc$`), Severity: severity.SeverityConfiguration["GitRobRC"]},
-		{Pattern: regexp.MustCompile(`^\.?(bash|zsh)rc$`), Severity: severity.SeverityConfiguration["ShellRC"]},
-		{Pattern: regexp.MustCompile(`^\.?(bash_|zsh_)?profile$`), Severity: severity.SeverityConfiguration["ShellProfile"]},
-		{Pattern: regexp.MustCompile(`^\.?(bash_|zsh_)?aliases$`), Severity: severity.SeverityConfiguration["ShellAlias"]},
-		{Pattern: regexp.MustCompile(`^secret_token.rb$`), Severity: severity.SeverityConfiguration["SecretToken"]},
-		{Pattern: regexp.MustCompile(`^omniauth.rb$`), Severity: severity.SeverityConfiguration["OmniAuth"]},
-		{Pattern: regexp.MustCompile(`^carrierwave.rb$`), Severity: severity.SeverityConfiguration["CarrierWaveRB"]},
-		{Pattern: regexp.MustCompile(`^schema.rb$`), Severity: severity.SeverityConfiguration["SchemaRB"]},
-		{Pattern: regexp.MustCompile(`^database.yml$`), Severity: severity.SeverityConfiguration["DatabaseYml"]},
-		{Pattern: regexp.MustCompile(`^settings.py$`), Severity: severity.SeverityConfiguration["PythonSettings"]},
-		{Pattern: regexp.MustCompile(`^.*(config)(\.inc)?\.php$`), Severity: severity.SeverityConfiguration["PhpConfig"]},
-		{Pattern: regexp.MustCompile(`^LocalSettings.php$`), Severity: severity.SeverityConfiguration["PhpLocalSettings"]},
-		{Pattern: regexp.MustCompile(`\.?env`), Severity: severity.SeverityConfiguration["EnvFile"]},
-		{Pattern: regexp.MustCompile(`\bdump|dump\b`), Severity: severity.SeverityConfiguration["BDumpFile"]},
-		{Pattern: regexp.MustCompile(`\bsql|sql\b`), Severity: severity.SeverityConfiguration["BSQLFile"]},
-		{Pattern: regexp.MustCompile(`\bdump|dump\b`), Severity: severity.SeverityConfiguration["BDumpFile"]},
-		{Pattern: regexp.MustCompile(`password`), Severity: severity.SeverityConfiguration["PasswordFile"]},
-		{Pattern: regexp.MustCompile(`backup`), Severity: severity.SeverityConfiguration["BackupFile"]},
-		{Pattern: regexp.MustCompile(`private.*key`), Severity: severity.SeverityConfiguration["PrivateKeyFile"]},
-		{Pattern: regexp.MustCompile(`(oauth).*(token)`), Severity: severity.SeverityConfiguration["OauthTokenFile"]},
-		{Pattern: regexp.MustCompile(`^.*\.log$`), Severity: severity.SeverityConfiguration["LogFile"]},
-		{Pattern: regexp.MustCompile(`^\.?kwallet$`), Severity: severity.SeverityConfiguration["KWallet"]},
-		{Pattern: regexp.MustCompile(`^\.?gnucash$`), Severity: severity.SeverityConfiguration["GNUCash"]},
+		{Pattern: regexp.MustCompile(`^.+_rsa$`), Rule: "RSAFile"},
+		{Pattern: regexp.MustCompile(`^.+_dsa.*$`), Rule: "DSAFile"},
+		{Pattern: regexp.MustCompile(`^.+_ed25519$`), Rule: "DSAFile"},
+		{Pattern: regexp.MustCompile(`^.+_ecdsa$`), Rule: "DSAFile"},
+		{Pattern: regexp.MustCompile(`^\.\w+_history$`), Rule: "ShellHistory"},
+		{Pattern: regexp.MustCompile(`^.+\.pem$`), Rule: "PemFile"},
+		{Pattern: regexp.MustCompile(`^.+\.ppk$`), Rule: "PpkFile"},
+		{Pattern: regexp.MustCompile(`^.+\.key(pair)?$`), Rule: "KeyPairFile"},
+		{Pattern: regexp.MustCompile(`^.+\.pkcs12$`), Rule: "PKCSFile"},
+		{Pattern: regexp.MustCompile(`^.+\.pfx$`), Rule: "PFXFile"},
+		{Pattern: regexp.MustCompile(`^.+\.p12$`), Rule: "P12File"},
+		{Pattern: regexp.MustCompile(`^.+\.asc$`), Rule: "ASCFile"},
+		{Pattern: regexp.MustCompile(`^\.?htpasswd$`), Rule: "HTPASSWDFile"},
+		{Pattern: regexp.MustCompile(`^\.?netrc$`), Rule: "NetrcFile"},
+		{Pattern: regexp.MustCompile(`^.*\.tblk$`), Rule: "TunnelBlockFile"},
+		{Pattern: regexp.MustCompile(`^.*\.ovpn$`), Rule: "OpenVPNFile"},
+		{Pattern: regexp.MustCompile(`^.*\.kdb$`), Rule: "KDBFile"},
+		{Pattern: regexp.MustCompile(`^.*\.agilekeychain$`), Rule: "AgileKeyChainFile"},
+		{Pattern: regexp.MustCompile(`^.*\.keychain$`), Rule: "KeyChainFile"},
+		{Pattern: regexp.MustCompile(`^.*\.key(store|ring)$`), Rule: "KeyStoreFile"},
+		{Pattern: regexp.MustCompile(`^jenkins\.plugins\.publish_over_ssh\.BapSshPublisherPlugin.xml$`), Rule: "JenkinsPublishOverSSHFile"},
+		{Pattern: regexp.MustCompile(`^credentials\.xml$`), Rule: "CredentialsXML"},
+		{Pattern: regexp.MustCompile(`^.*\.pubxml(\.user)?$`), Rule: "PubXML"},
+		{Pattern: regexp.MustCompile(`^\.?s3cfg$`), Rule: "s3Config"},
+		{Pattern: regexp.MustCompile(`^\.gitrobrc$`), Rule: "GitRobRC"},
+		{Pattern: regexp.MustCompile(`^\.?(bash|zsh)rc$`), Rule: "ShellRC"},
+		{Pattern: regexp.MustCompile(`^\.?(bash_|zsh_)?profile$`), Rule: "ShellProfile"},
+		{Pattern: regexp.MustCompile(`^\.?(bash_|zsh_)?aliases$`), Rule: "ShellAlias"},
+		{Pattern: regexp.MustCompile(`^secret_token.rb$`), Rule: "SecretToken"},
+		{Pattern: regexp.MustCompile(`^omniauth.rb$`), Rule: "OmniAuth"},
+		{Pattern: regexp.MustCompile(`^carrierwave.rb$`), Rule: "CarrierWaveRB"},
+		{Pattern: regexp.MustCompile(`^schema.rb$`), Rule: "SchemaRB"},
+		{Pattern: regexp.MustCompile(`^database.yml$`), Rule: "DatabaseYml"},
+		{Pattern: regexp.MustCompile(`^settings.py$`), Rule: "PythonSettings"},
+		{Pattern: regexp.MustCompile(`^.*(config)(\.inc)?\.php$`), Rule: "PhpConfig"},
+		{Pattern: regexp.MustCompile(`^LocalSettings.php$`), Rule: "PhpLocalSettings"},
+		{Pattern: regexp.MustCompile(`\.?env`), Rule: "EnvFile"},
+		{Pattern: regexp.MustCompile(`\bdump|dump\b`), Rule: "BDumpFile"},
+		{Pattern: regexp.MustCompile(`\bsql|sql\b`), Rule: "BSQLFile"},
+		{Pattern: regexp.MustCompile(`\bdump|dump\b`), Rule: "BDumpFile"},
+		{Pattern: regexp.MustCompile(`password`), Rule: "PasswordFile"},
+		{Pattern: regexp.MustCompile(`backup`), Rule: "BackupFile"},
+		{Pattern: regexp.MustCompile(`private.*key`), Rule: "PrivateKeyFile"},
+		{Pattern: regexp.MustCompile(`(oauth).*(token)`), Rule: "OauthTokenFile"},
+		{Pattern: regexp.MustCompile(`^.*\.log$`), Rule: "LogFile"},
+		{Pattern: regexp.MustCompile(`^\.?kwallet$`), Rule: "KWallet"},
+		{Pattern: regexp.MustCompile(`^\.?gnucash$`), Rule: "GNUCash"},
 	}
 )
 
@@ -82,28 +82,36 @@ func NewFileNameDetector(patternsWithSeverity []*severity.PatternSeverity, thres
 	return FileNameDetector{patternsWithSeverity, threshold}
 }
 
+// TestsBinaryFiles answers that the names of binary files are tested as well
+func (fd FileNameDetector) TestsBinaryFiles() bool {
+	return true
+}
+
 // Test tests the fileNames of the Additions to ensure that they don't look suspicious
 func (fd FileNameDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	severities := ignoreConfig.SeverityConfiguration()
 	for _, addition := range currentAdditions {
-		if comparator.ShouldIgnore(addition, "filename") {
+		if ignored, rule := helpers.EvaluateIgnore(comparator, addition, "filename", "filename", result); ignored {
 			log.WithFields(log.Fields{
 				"filePath": addition.Path,
 			}).Info("Ignoring addition as it was specified to be ignored.")
-			result.Ignore(addition.Path, "filename")
+			result.IgnoreWithRule(addition.Path, "filename", rule)
 			additionCompletionCallback()
 			continue
 		}
 		for _, patternWithSeverity := range fd.flagPatterns {
 			if patternWithSeverity.Pattern.MatchString(string(addition.Name)) {
+				patternSeverity := patternWithSeverity.SeverityIn(severities)
 				log.WithFields(log.Fields{
 					"filePath": addition.Path,
 					"pattern":  patternWithSeverity.Pattern,
-					"severity": patternWithSeverity.Severity,
+					"severity": patternSeverity,
 				}).Info("Failing file as it matched pattern.")
-				if patternWithSeverity.Severity.ExceedsThreshold(fd.threshold) {
-					result.Fail(addition.Path, "filename", fmt.Sprintf("The file name %q failed checks against the pattern %s", addition.Path, patternWithSeverity.Pattern), addition.Commits, patternWithSeverity.Severity)
+				findingSeverity, fails := result.AssessFinding(ignoreConfig, addition, "filename", fmt.Sprintf("file name matches pattern %s", patternWithSeverity.Pattern), patternSeverity, fd.threshold)
+				if fails {
+					result.Fail(addition.Path, "filename", fmt.Sprintf("The file name %q failed checks against the pattern %s", addition.Path, patternWithSeverity.Pattern), addition.Commits, findingSeverity)
 				} else {
-					result.Warn(addition.Path, "filename", fmt.Sprintf("The file name %q failed checks against the pattern %s", addition.Path, patternWithSeverity.Pattern), addition.Commits, patternWithSeverity.Severity)
+					result.Warn(addition.Path, "filename", fmt.Sprintf("The file name %q failed checks against the pattern %s", addition.Path, patternWithSeverity.Pattern), addition.Commits, findingSeverity)
 				}
 			}
 		}
@@ -11,15 +11,17 @@ import (
 
 	"talisman/gitrepo"
 	"talisman/talismanrc"
+	"talisman/utility"
 
 	"github.com/stretchr/testify/assert"
 )
 
 var talismanRC = &talismanrc.TalismanRC{}
-var defaultIgnoreEvaluator = helpers.BuildIgnoreEvaluator("default", talismanRC, gitrepo.RepoLocatedAt("."))
+var defaultIgnoreEvaluator = ignoreEvaluatorWithTalismanRC(talismanRC)
 
 func ignoreEvaluatorWithTalismanRC(tRC *talismanrc.TalismanRC) helpers.IgnoreEvaluator {
-	return helpers.BuildIgnoreEvaluator("default", tRC, gitrepo.RepoLocatedAt("."))
+	ie, _ := helpers.BuildIgnoreEvaluator(utility.NewHashers(), "default", tRC, gitrepo.RepoLocatedAt("."))
+	return ie
 }
 
 func TestShouldFlagPotentialSSHPrivateKeys(t *testing.T) {
@@ -154,6 +156,17 @@ func TestShouldIgnoreIfErrorIsBelowThreshold(t *testing.T) {
 	assert.True(t, results.HasWarnings(), "Expected file %s to having warnings", fileName)
 }
 
+func TestShouldHonourCustomSeverities(t *testing.T) {
+	results := helpers.NewDetectionResults()
+	tRC := &talismanrc.TalismanRC{CustomSeverities: []talismanrc.CustomSeverityConfig{{Detector: "PemFile", Severity: severity.Low}}}
+
+	DefaultFileNameDetector(severity.Medium).
+		Test(defaultIgnoreEvaluator, additionsNamed("foo.pem"), tRC, results, func() {})
+
+	assert.False(t, results.HasFailures(), "Expected custom severity to keep foo.pem below the threshold")
+	assert.Equal(t, severity.Low, results.GetWarnings("foo.pem")[0].Severity)
+}
+
 func shouldFail(fileName, pattern string, threshold severity.Severity, t *testing.T) {
 	shouldFailWithSpecificPattern(fileName, pattern, threshold, t)
 	shouldFailWithDefaultDetector(fileName, pattern, threshold, t)
@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"talisman/detector/detector"
 	"talisman/detector/helpers"
-	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// DefaultMaxFileSize is the size in bytes above which the default chain reports files as large
+const DefaultMaxFileSize = 1 * 1024 * 1024
+
 type FileSizeDetector struct {
 	size int
 }
@@ -19,14 +21,19 @@ func NewFileSizeDetector(size int) detector.Detector {
 	return FileSizeDetector{size}
 }
 
+// TestsBinaryFiles answers that the sizes of binary files are tested as well
+func (fd FileSizeDetector) TestsBinaryFiles() bool {
+	return true
+}
+
 func (fd FileSizeDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
-	largeFileSizeSeverity := severity.SeverityConfiguration["LargeFileSize"]
+	largeFileSizeSeverity := ignoreConfig.SeverityConfiguration().SeverityOf("LargeFileSize")
 	for _, addition := range currentAdditions {
-		if comparator.ShouldIgnore(addition, "filesize") {
+		if ignored, rule := helpers.EvaluateIgnore(comparator, addition, "filesize", "filesize", result); ignored {
 			log.WithFields(log.Fields{
 				"filePath": addition.Path,
 			}).Info("Ignoring addition as it was specified to be ignored.")
-			result.Ignore(addition.Path, "filesize")
+			result.IgnoreWithRule(addition.Path, "filesize", rule)
 			additionCompletionCallback()
 			continue
 		}
@@ -37,10 +44,11 @@ func (fd FileSizeDetector) Test(comparator helpers.IgnoreEvaluator, currentAddit
 				"fileSize": size,
 				"maxSize":  fd.size,
 			}).Info("Failing file as it is larger than max allowed file size.")
-			if largeFileSizeSeverity.ExceedsThreshold(ignoreConfig.Threshold) {
-				result.Fail(addition.Path, "filesize", fmt.Sprintf("The file name %q with file size %d is larger than max allowed file size(%d)", addition.Path, size, fd.size), addition.Commits, largeFileSizeSeverity)
+			findingSeverity, fails := result.AssessFinding(ignoreConfig, addition, "filesize", fmt.Sprintf("file size %d exceeds %d", size, fd.size), largeFileSizeSeverity, ignoreConfig.Threshold)
+			if fails {
+				result.Fail(addition.Path, "filesize", fmt.Sprintf("The file name %q with file size %d is larger than max allowed file size(%d)", addition.Path, size, fd.size), addition.Commits, findingSeverity)
 			} else {
-				result.Warn(addition.Path, "filesize", fmt.Sprintf("The file name %q with file size %d is larger than max allowed file size(%d)", addition.Path, size, fd.size), addition.Commits, largeFileSizeSeverity)
+				result.Warn(addition.Path, "filesize", fmt.Sprintf("The file name %q with file size %d is larger than max allowed file size(%d)", addition.Path, size, fd.size), addition.Commits, findingSeverity)
 			}
 		}
 		additionCompletionCallback()
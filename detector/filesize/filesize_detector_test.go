@@ -7,15 +7,17 @@ import (
 
 	"talisman/gitrepo"
 	"talisman/talismanrc"
+	"talisman/utility"
 
 	"github.com/stretchr/testify/assert"
 )
 
 var talismanRC = &talismanrc.TalismanRC{}
-var defaultIgnoreEvaluator = helpers.BuildIgnoreEvaluator("default", talismanRC, gitrepo.RepoLocatedAt("."))
+var defaultIgnoreEvaluator = ignoreEvaluatorWithTalismanRC(talismanRC)
 
 func ignoreEvaluatorWithTalismanRC(tRC *talismanrc.TalismanRC) helpers.IgnoreEvaluator {
-	return helpers.BuildIgnoreEvaluator("default", tRC, gitrepo.RepoLocatedAt("."))
+	ie, _ := helpers.BuildIgnoreEvaluator(utility.NewHashers(), "default", tRC, gitrepo.RepoLocatedAt("."))
+	return ie
 }
 
 func TestShouldFlagLargeFiles(t *testing.T) {
@@ -36,6 +38,21 @@ func TestShouldNotFlagLargeFilesIfThresholdIsBelowSeverity(t *testing.T) {
 	assert.True(t, results.HasWarnings(), "Expected file to have warnings against file size detector.")
 }
 
+func TestShouldFlagLargeFilesAboveThresholdForTheirPath(t *testing.T) {
+	results := helpers.NewDetectionResults()
+	talismanRCWithPathThreshold := &talismanrc.TalismanRC{
+		Threshold:      severity.High,
+		PathThresholds: []talismanrc.PathThresholdConfig{{Path: "deploy/", Threshold: severity.Low}},
+	}
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("deploy/filename", []byte("more than one byte")),
+		gitrepo.NewAddition("src/filename", []byte("more than one byte")),
+	}
+	NewFileSizeDetector(2).Test(defaultIgnoreEvaluator, additions, talismanRCWithPathThreshold, results, func() {})
+	assert.Len(t, results.GetFailures("deploy/filename"), 1, "Expected file under deploy/ to fail against its stricter threshold.")
+	assert.Len(t, results.GetFailures("src/filename"), 0, "Expected other files to only be warned about.")
+}
+
 func TestShouldNotFlagSmallFiles(t *testing.T) {
 	results := helpers.NewDetectionResults()
 	content := []byte("m")
@@ -0,0 +1,24 @@
+package helpers
+
+import (
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+)
+
+// AssessFinding applies the severity_escalations and path_thresholds of the .talismanrc to a finding in an Addition.
+// It returns the resulting severity, and answers if the finding fails the run rather than only producing a warning.
+// The defaultThreshold applies to Additions that are not matched by any path_thresholds entry.
+func (r *DetectionResults) AssessFinding(tRC *talismanrc.TalismanRC, addition gitrepo.Addition, detector string, finding string, findingSeverity severity.Severity, defaultThreshold severity.Severity) (severity.Severity, bool) {
+	if escalation := tRC.EscalationFor(addition, findingSeverity); escalation != nil {
+		r.Explain(addition.Path, detector, "%s: severity escalated from %s to %s by severity_escalations entry %q", finding, findingSeverity, escalation.Severity, escalation.Path)
+		findingSeverity = escalation.Severity
+	}
+	threshold := defaultThreshold
+	if pathThreshold := tRC.PathThresholdFor(addition); pathThreshold != nil {
+		r.Explain(addition.Path, detector, "threshold %s applies from path_thresholds entry %q", pathThreshold.Threshold, pathThreshold.Path)
+		threshold = pathThreshold.Threshold
+	}
+	r.ExplainThreshold(addition.Path, detector, finding, findingSeverity, threshold)
+	return findingSeverity, findingSeverity.ExceedsThreshold(threshold)
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"testing"
+
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssessingFindings(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		Threshold: severity.Medium,
+		PathThresholds: []talismanrc.PathThresholdConfig{
+			{Path: "deploy/", Threshold: severity.Low},
+			{Path: "test/", Threshold: severity.Critical},
+		},
+		SeverityEscalations: []talismanrc.SeverityEscalationConfig{
+			{Path: "*prod*", Severity: severity.Critical},
+		},
+	}
+
+	t.Run("should apply the global threshold to other paths", func(t *testing.T) {
+		findingSeverity, fails := NewDetectionResults().AssessFinding(tRC, gitrepo.NewAddition("src/app.txt", nil), "pattern", "finding", severity.Low, tRC.Threshold)
+		assert.Equal(t, severity.Low, findingSeverity)
+		assert.False(t, fails)
+	})
+
+	t.Run("should apply stricter thresholds by path", func(t *testing.T) {
+		_, fails := NewDetectionResults().AssessFinding(tRC, gitrepo.NewAddition("deploy/app.txt", nil), "pattern", "finding", severity.Low, tRC.Threshold)
+		assert.True(t, fails)
+	})
+
+	t.Run("should apply looser thresholds by path", func(t *testing.T) {
+		_, fails := NewDetectionResults().AssessFinding(tRC, gitrepo.NewAddition("test/app.txt", nil), "pattern", "finding", severity.High, tRC.Threshold)
+		assert.False(t, fails)
+	})
+
+	t.Run("should escalate findings and explain why", func(t *testing.T) {
+		results := NewDetectionResults()
+		results.EnableExplain()
+		findingSeverity, fails := results.AssessFinding(tRC, gitrepo.NewAddition("test/prod.yml", nil), "pattern", "finding", severity.Low, tRC.Threshold)
+		assert.Equal(t, severity.Critical, findingSeverity)
+		assert.True(t, fails)
+		assert.Equal(t, []Explanation{
+			{"pattern", `finding: severity escalated from low to critical by severity_escalations entry "*prod*"`},
+			{"pattern", `threshold critical applies from path_thresholds entry "test/"`},
+			{"pattern", "finding: severity critical meets threshold critical, failing"},
+		}, results.Explanations["test/prod.yml"])
+	})
+}
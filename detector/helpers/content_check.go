@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"sync"
+	"talisman/gitrepo"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AllowedFinding is a finding that an allowed pattern of the .talismanrc suppressed, kept to be recorded as ignored
+type AllowedFinding struct {
+	Secret  string
+	Pattern string
+}
+
+type ignoredAddition struct {
+	path gitrepo.FilePath
+	rule string
+}
+
+// CheckContents checks the contents of each Addition that is not ignored for the named detector concurrently, using check.
+// Check returns a report of what it found, which records it in the results. Reports are run one at a time, on the calling goroutine,
+// so that they need not synchronise with each other. Additions that are ignored are recorded as ignored for their file content.
+func CheckContents(comparator IgnoreEvaluator, additions []gitrepo.Addition, detector string, result *DetectionResults, additionCompletionCallback func(), check func(addition gitrepo.Addition) (report func())) {
+	reports := make(chan func(), 512)
+	ignoredFilePaths := make(chan ignoredAddition, len(additions))
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(len(additions))
+	for _, addition := range additions {
+		go func(addition gitrepo.Addition) {
+			defer waitGroup.Done()
+			defer additionCompletionCallback()
+			if ignored, rule := EvaluateIgnore(comparator, addition, "filecontent", detector, result); ignored {
+				ignoredFilePaths <- ignoredAddition{addition.Path, rule}
+				return
+			}
+			reports <- check(addition)
+		}(addition)
+	}
+	go func() {
+		waitGroup.Wait()
+		close(reports)
+		close(ignoredFilePaths)
+	}()
+	for ignoredChanHasMore, reportChanHasMore := true, true; ignoredChanHasMore || reportChanHasMore; {
+		select {
+		case report, hasMore := <-reports:
+			if !hasMore {
+				reportChanHasMore = false
+				continue
+			}
+			report()
+		case ignored, hasMore := <-ignoredFilePaths:
+			if !hasMore {
+				ignoredChanHasMore = false
+				continue
+			}
+			log.WithFields(log.Fields{
+				"filePath": ignored.path,
+			}).Info("Ignoring addition as it was specified to be ignored.")
+			result.IgnoreWithRule(ignored.path, "filecontent", ignored.rule)
+		}
+	}
+}
+
+// FormatForReporting shortens a finding to the length reported in messages
+func FormatForReporting(input string) string {
+	if len(input) > 50 {
+		return input[:47] + "..."
+	}
+	return input
+}
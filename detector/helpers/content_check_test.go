@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"strings"
+	"sync/atomic"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckContentsReportsWhatIsFoundInAdditionsThatAreNotIgnored(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{FileName: "ignored.txt", IgnoreDetectors: []string{"filecontent"}}}}
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("checked.txt", []byte("secret")),
+		gitrepo.NewAddition("ignored.txt", []byte("secret")),
+		gitrepo.NewAddition("other.txt", []byte("secret")),
+	}
+	results := NewDetectionResults()
+	var completed atomic.Int32
+	var checked []gitrepo.FilePath
+
+	CheckContents(HistoricBlobEvaluator(tRC), additions, "test", results, func() { completed.Add(1) }, func(addition gitrepo.Addition) func() {
+		return func() {
+			checked = append(checked, addition.Path)
+			results.Fail(addition.Path, "filecontent", "found "+string(addition.Data), addition.Commits, severity.High)
+		}
+	})
+
+	assert.Equal(t, int32(3), completed.Load())
+	assert.ElementsMatch(t, []gitrepo.FilePath{"checked.txt", "other.txt"}, checked)
+	assert.Len(t, results.GetFailures("checked.txt"), 1)
+	assert.Empty(t, results.GetFailures("ignored.txt"))
+	assert.True(t, results.HasIgnores())
+}
+
+func TestFormatForReportingShortensLongFindings(t *testing.T) {
+	assert.Equal(t, "short", FormatForReporting("short"))
+	assert.Equal(t, strings.Repeat("a", 47)+"...", FormatForReporting(strings.Repeat("a", 60)))
+}
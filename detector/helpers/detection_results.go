@@ -3,9 +3,9 @@ package helpers
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/prompt"
@@ -50,8 +50,15 @@ type ResultsSummary struct {
 // Currently, it keeps track of failures and ignored files.
 // The results are grouped by FilePath for easy reporting of all detected problems with individual files.
 type DetectionResults struct {
-	Summary ResultsSummary   `json:"summary"`
-	Results []ResultsDetails `json:"results"`
+	Summary      ResultsSummary                     `json:"summary"`
+	Results      []ResultsDetails                   `json:"results"`
+	Explanations map[gitrepo.FilePath][]Explanation `json:"explanations,omitempty"`
+
+	explain          bool
+	revealSecrets    bool
+	explanationsLock sync.Mutex
+	repo             *gitrepo.GitRepo
+	hashers          *utility.Hashers
 }
 
 func (r *DetectionResults) getResultDetailsForFilePath(fileName gitrepo.FilePath) *ResultsDetails {
@@ -66,10 +73,10 @@ func (r *DetectionResults) getResultDetailsForFilePath(fileName gitrepo.FilePath
 // NewDetectionResults is a new DetectionResults struct. It represents the pre-run state of a Detection run.
 func NewDetectionResults() *DetectionResults {
 	return &DetectionResults{
-		ResultsSummary{
+		Summary: ResultsSummary{
 			FailureTypes{0, 0, 0, 0, 0},
 		},
-		make([]ResultsDetails, 0),
+		Results: make([]ResultsDetails, 0),
 	}
 }
 
@@ -131,26 +138,31 @@ func (r *DetectionResults) Warn(filePath gitrepo.FilePath, category string, mess
 // Ignore is used to mark the supplied FilePath as being ignored.
 // The most common reason for this is that the FilePath is Denied by the Ignores supplied to the Detector, however, Detectors may use more sophisticated reasons to ignore files.
 func (r *DetectionResults) Ignore(filePath gitrepo.FilePath, category string) {
+	r.IgnoreWithRule(filePath, category, "")
+}
+
+// IgnoreWithRule marks the supplied FilePath as being ignored, recording the rule that suppressed it as the message
+func (r *DetectionResults) IgnoreWithRule(filePath gitrepo.FilePath, category string, rule string) {
 
 	isFilePresentInResults := false
 	for resultIndex := 0; resultIndex < len(r.Results); resultIndex++ {
 		if r.Results[resultIndex].Filename == filePath {
 			isFilePresentInResults = true
-			isEntryPresentForGivenCategory := false
+			isEntryPresentForGivenCategoryAndRule := false
 			for detailIndex := 0; detailIndex < len(r.Results[resultIndex].IgnoreList); detailIndex++ {
-				if strings.Compare(r.Results[resultIndex].IgnoreList[detailIndex].Category, category) == 0 {
-					isEntryPresentForGivenCategory = true
+				if strings.Compare(r.Results[resultIndex].IgnoreList[detailIndex].Category, category) == 0 && strings.Compare(r.Results[resultIndex].IgnoreList[detailIndex].Message, rule) == 0 {
+					isEntryPresentForGivenCategoryAndRule = true
 
 				}
 			}
-			if !isEntryPresentForGivenCategory {
-				detail := Details{category, "", make([]string, 0), severity.Low}
+			if !isEntryPresentForGivenCategoryAndRule {
+				detail := Details{category, rule, make([]string, 0), severity.Low}
 				r.Results[resultIndex].IgnoreList = append(r.Results[resultIndex].IgnoreList, detail)
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		ignoreDetails := Details{category, "", make([]string, 0), severity.Low}
+		ignoreDetails := Details{category, rule, make([]string, 0), severity.Low}
 		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
 		resultDetails.IgnoreList = append(resultDetails.IgnoreList, ignoreDetails)
 		r.Results = append(r.Results, resultDetails)
@@ -205,6 +217,15 @@ func (r *DetectionResults) GetFailures(fileName gitrepo.FilePath) []Details {
 	return results.FailureList
 }
 
+// GetWarnings returns the various reasons that a given FilePath was warned about by all the detectors in the current run
+func (r *DetectionResults) GetWarnings(fileName gitrepo.FilePath) []Details {
+	results := r.getResultDetailsForFilePath(fileName)
+	if results == nil {
+		return []Details{}
+	}
+	return results.WarningList
+}
+
 func (r *DetectionResults) ReportWarnings() string {
 	var data [][]string
 
@@ -259,9 +280,48 @@ func (r *DetectionResults) Report(promptContext prompt.PromptContext, mode strin
 	return result
 }
 
+// SetRepository sets the repository whose .talismanrc is updated when failures are interactively ignored
+func (r *DetectionResults) SetRepository(repo gitrepo.GitRepo) {
+	r.repo = &repo
+}
+
+// UseHashers sets the hashers of the run, which calculate the checksums of the .talismanrc entries suggested for failures.
+// Without them, hashers are made for the suggestions alone.
+func (r *DetectionResults) UseHashers(hashers *utility.Hashers) {
+	r.hashers = hashers
+}
+
+func (r *DetectionResults) repository() gitrepo.GitRepo {
+	if r.repo == nil {
+		return gitrepo.RepoLocatedAt(".")
+	}
+	return *r.repo
+}
+
+func (r *DetectionResults) loadTalismanRC() (*talismanrc.TalismanRC, error) {
+	if r.repo == nil {
+		return talismanrc.Load()
+	}
+	return talismanrc.LoadFrom(r.repo.Root())
+}
+
 func (r *DetectionResults) suggestTalismanRC(filePaths []string, promptContext prompt.PromptContext, mode string) {
 	var entriesToAdd []talismanrc.FileIgnoreConfig
-	hasher := utility.MakeHasher(mode, ".")
+	filePaths = containersOf(r.withoutMessages(filePaths))
+	if len(filePaths) == 0 {
+		return
+	}
+	repo := r.repository()
+	hashers := r.hashers
+	if hashers == nil {
+		hashers = utility.NewHashers()
+		defer hashers.Shutdown()
+	}
+	hasher, err := hashers.Make(mode, repo)
+	if err != nil {
+		logrus.Errorf("unable to calculate checksums to suggest .talismanrc entries: %v", err)
+		return
+	}
 	for _, filePath := range filePaths {
 		currentChecksum := hasher.CollectiveSHA256Hash([]string{filePath})
 		fileIgnoreConfig := talismanrc.IgnoreFileWithChecksum(filePath, currentChecksum)
@@ -270,17 +330,21 @@ func (r *DetectionResults) suggestTalismanRC(filePaths []string, promptContext p
 
 	if promptContext.Interactive && runtime.GOOS != "windows" {
 		confirmedEntries := getUserConfirmation(entriesToAdd, promptContext)
-		talismanrcConfig, _ := talismanrc.Load()
+		talismanrcConfig, _ := r.loadTalismanRC()
 		talismanrcConfig.AddIgnores(confirmedEntries)
 
 		for _, confirmedEntry := range confirmedEntries {
-			resultsDetails := r.getResultDetailsForFilePath(gitrepo.FilePath(confirmedEntry.GetFileName()))
-			for _, failure := range resultsDetails.FailureList {
-				r.updateResultsSummary(failure.Category, true)
+			for _, resultsDetails := range r.Results {
+				if gitrepo.ContainerOf(resultsDetails.Filename) != gitrepo.FilePath(confirmedEntry.GetFileName()) {
+					continue
+				}
+				for _, failure := range resultsDetails.FailureList {
+					r.updateResultsSummary(failure.Category, true)
+				}
 			}
 		}
 
-		output, err := exec.Command("git", "add", ".talismanrc").CombinedOutput()
+		output, err := repo.GitCommand("add", talismanrc.RCFileName).CombinedOutput()
 		if err != nil {
 			logrus.Errorf("Error appending to talismanrc %v", output)
 		}
@@ -291,6 +355,35 @@ func (r *DetectionResults) suggestTalismanRC(filePaths []string, promptContext p
 
 }
 
+// withoutMessages drops commit messages and tag annotations from the paths to suggest .talismanrc entries for.
+// Their failures can only be resolved by rewording them, or by allowing the flagged contents with allowed_patterns.
+func (r *DetectionResults) withoutMessages(filePaths []string) []string {
+	var files []string
+	for _, filePath := range filePaths {
+		if !gitrepo.IsMessagePath(gitrepo.FilePath(filePath)) {
+			files = append(files, filePath)
+		}
+	}
+	if len(files) < len(filePaths) {
+		fmt.Printf("\n\x1b[33mPlease reword the above commit message(s) or tag annotation(s), or add allowed_patterns to .talismanrc if the flagged contents are safe\x1b[0m\n")
+	}
+	return files
+}
+
+// containersOf replaces the paths of archive entries with the paths of the files holding the archives, as only those can be ignored
+func containersOf(filePaths []string) []string {
+	var containers []string
+	seen := map[gitrepo.FilePath]bool{}
+	for _, filePath := range filePaths {
+		container := gitrepo.ContainerOf(gitrepo.FilePath(filePath))
+		if !seen[container] {
+			seen[container] = true
+			containers = append(containers, string(container))
+		}
+	}
+	return containers
+}
+
 func getUserConfirmation(configs []talismanrc.FileIgnoreConfig, promptContext prompt.PromptContext) []talismanrc.FileIgnoreConfig {
 	confirmed := []talismanrc.FileIgnoreConfig{}
 	if len(configs) != 0 {
@@ -98,6 +98,8 @@ func TestSuccessExitCodeInInteractive(t *testing.T) {
 
 	prompter := mock.NewMockPrompt(ctrl)
 	results := NewDetectionResults()
+	// Accepted suggestions are written to the .talismanrc, which must not end up in the package directory
+	talismanrc.SetFs__(afero.NewMemMapFs())
 
 	promptContext := prompt.NewPromptContext(true, prompter)
 	prompter.EXPECT().Confirm(gomock.Any()).Return(true).Times(2)
@@ -232,3 +234,14 @@ version: "1.0"
 	err = fs.Remove(talismanrc.RCFileName)
 	assert.NoError(t, err)
 }
+
+func TestSuggestionsSkipCommitMessagesAndTagAnnotations(t *testing.T) {
+	results := NewDetectionResults()
+	filePaths := results.withoutMessages([]string{"commit-message:abc", "secret.pem", "tag-annotation:v1"})
+	assert.Equal(t, []string{"secret.pem"}, filePaths)
+}
+
+func TestSuggestionsIgnoreTheFilesHoldingArchiveEntries(t *testing.T) {
+	filePaths := containersOf([]string{"lib.jar!/config/app.properties", "app.war!/WEB-INF/lib/db.jar!/db.properties", "lib.jar!/secret.pem", "secret.pem"})
+	assert.Equal(t, []string{"lib.jar", "app.war", "secret.pem"}, filePaths)
+}
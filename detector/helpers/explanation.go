@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Explanation records a single decision taken by a detector (or by the .talismanrc scopes) for an addition
+type Explanation struct {
+	Detector string `json:"detector"`
+	Decision string `json:"decision"`
+}
+
+// EnableExplain makes the results record why each addition was scanned, ignored or failed
+func (r *DetectionResults) EnableExplain() {
+	r.explain = true
+	r.Explanations = make(map[gitrepo.FilePath][]Explanation)
+}
+
+// Explaining answers if decisions should be recorded for the current run
+func (r *DetectionResults) Explaining() bool {
+	return r.explain
+}
+
+// Explain records a decision taken by a detector for the supplied FilePath, if explain mode is enabled.
+// Explain is safe to call from concurrently running detectors.
+func (r *DetectionResults) Explain(filePath gitrepo.FilePath, detector string, format string, args ...interface{}) {
+	if !r.explain {
+		return
+	}
+	explanation := Explanation{detector, fmt.Sprintf(format, args...)}
+	r.explanationsLock.Lock()
+	defer r.explanationsLock.Unlock()
+	for _, existing := range r.Explanations[filePath] {
+		if existing == explanation {
+			return
+		}
+	}
+	r.Explanations[filePath] = append(r.Explanations[filePath], explanation)
+}
+
+// ExplainThreshold records whether a finding of the given severity fails the run or is only reported as a warning
+func (r *DetectionResults) ExplainThreshold(filePath gitrepo.FilePath, detector string, finding string, findingSeverity severity.Severity, threshold severity.Severity) {
+	if findingSeverity.ExceedsThreshold(threshold) {
+		r.Explain(filePath, detector, "%s: severity %s meets threshold %s, failing", finding, findingSeverity, describeThreshold(threshold))
+	} else {
+		r.Explain(filePath, detector, "%s: severity %s is below threshold %s, warning only", finding, findingSeverity, describeThreshold(threshold))
+	}
+}
+
+func describeThreshold(threshold severity.Severity) string {
+	if threshold.String() == "" {
+		return "low (default)"
+	}
+	return threshold.String()
+}
+
+// EvaluateIgnore answers if the named detector should skip an Addition for the given category of ignores,
+// along with the rule that suppressed it. The decision is explained in the results when requested.
+func EvaluateIgnore(comparator IgnoreEvaluator, addition gitrepo.Addition, category string, detector string, result *DetectionResults) (bool, string) {
+	ignored, reasons := comparator.ExplainIgnore(addition, category)
+	for _, reason := range reasons {
+		result.Explain(addition.Path, detector, "%s", reason)
+	}
+	if !ignored {
+		result.Explain(addition.Path, detector, "scanned")
+		return false, ""
+	}
+	return true, reasons[len(reasons)-1]
+}
+
+// RemoveScopedFiles removes the Additions covered by the scopes in the .talismanrc, and explains which scope applied when requested
+func RemoveScopedFiles(talismanRC *talismanrc.TalismanRC, additions []gitrepo.Addition, result *DetectionResults) []gitrepo.Addition {
+	if result.Explaining() {
+		for _, addition := range additions {
+			if scope := talismanRC.ScopeOf(addition); scope != nil {
+				result.Explain(addition.Path, "scope", "excluded by scope %q", scope.ScopeName)
+			} else {
+				result.Explain(addition.Path, "scope", "not covered by any scope")
+			}
+		}
+	}
+	return talismanRC.RemoveScopedFiles(additions)
+}
+
+// ReportExplanations prints a table with the decisions recorded for every FilePath in the current run
+func (r *DetectionResults) ReportExplanations() {
+	if len(r.Explanations) == 0 {
+		return
+	}
+	var data [][]string
+	for _, filePath := range r.explainedFilePaths() {
+		for _, explanation := range r.Explanations[filePath] {
+			data = append(data, []string{string(filePath), explanation.Detector, explanation.Decision})
+		}
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"File", "Detector", "Decision"})
+	table.SetRowLine(true)
+	fmt.Printf("\n\x1b[1mTalisman Explanations:\x1b[0m\n")
+	table.AppendBulk(data)
+	table.Render()
+}
+
+func (r *DetectionResults) explainedFilePaths() []gitrepo.FilePath {
+	var filePaths []gitrepo.FilePath
+	for filePath := range r.Explanations {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Slice(filePaths, func(i, j int) bool { return filePaths[i] < filePaths[j] })
+	return filePaths
+}
@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplanationsAreOnlyRecordedWhenEnabled(t *testing.T) {
+	results := NewDetectionResults()
+	results.Explain("some_file", "filename", "scanned")
+	assert.Empty(t, results.Explanations)
+
+	results.EnableExplain()
+	results.Explain("some_file", "filename", "scanned")
+	results.Explain("some_file", "filename", "scanned")
+	assert.Equal(t, []Explanation{{"filename", "scanned"}}, results.Explanations["some_file"], "Identical decisions should only be recorded once")
+}
+
+func TestExplainIsSafeForConcurrentDetectors(t *testing.T) {
+	results := NewDetectionResults()
+	results.EnableExplain()
+	waitGroup := &sync.WaitGroup{}
+	for _, filePath := range []gitrepo.FilePath{"a", "b", "c", "d"} {
+		waitGroup.Add(1)
+		go func(filePath gitrepo.FilePath) {
+			defer waitGroup.Done()
+			results.Explain(filePath, "filecontent", "scanned")
+		}(filePath)
+	}
+	waitGroup.Wait()
+	assert.Len(t, results.Explanations, 4)
+}
+
+func TestExplainThreshold(t *testing.T) {
+	results := NewDetectionResults()
+	results.EnableExplain()
+
+	results.ExplainThreshold("some_file", "pattern", "finding", severity.High, severity.Medium)
+	results.ExplainThreshold("other_file", "pattern", "finding", severity.Low, severity.Medium)
+
+	assert.Equal(t, "finding: severity high meets threshold medium, failing", results.Explanations["some_file"][0].Decision)
+	assert.Equal(t, "finding: severity low is below threshold medium, warning only", results.Explanations["other_file"][0].Decision)
+}
+
+func TestEvaluateIgnoreExplainsDecisions(t *testing.T) {
+	tRC := talismanrc.TalismanRC{
+		FileIgnoreConfig: []talismanrc.FileIgnoreConfig{
+			{FileName: "some.txt", Checksum: "silly"},
+			{FileName: "other.txt", Checksum: "serious"},
+			{FileName: "ignore-contents", IgnoreDetectors: []string{"filecontent"}},
+		},
+	}
+	ie := &ignoreEvaluator{&sillyChecksumCalculator{}, &tRC}
+	results := NewDetectionResults()
+	results.EnableExplain()
+
+	ignored, rule := EvaluateIgnore(ie, gitrepo.NewAddition("some.txt", nil), "filecontent", "pattern", results)
+	assert.True(t, ignored)
+	assert.Equal(t, `ignored by fileignoreconfig entry "some.txt": checksum silly matches`, rule)
+	ignored, rule = EvaluateIgnore(ie, gitrepo.NewAddition("other.txt", nil), "filecontent", "pattern", results)
+	assert.False(t, ignored)
+	assert.Empty(t, rule)
+	ignored, _ = EvaluateIgnore(ie, gitrepo.NewAddition("ignore-contents", nil), "filecontent", "pattern", results)
+	assert.True(t, ignored)
+	ignored, _ = EvaluateIgnore(ie, gitrepo.NewAddition("new.txt", nil), "filecontent", "pattern", results)
+	assert.False(t, ignored)
+
+	assert.Equal(t, []Explanation{{"pattern", `ignored by fileignoreconfig entry "some.txt": checksum silly matches`}}, results.Explanations["some.txt"])
+	assert.Equal(t, []Explanation{
+		{"pattern", `fileignoreconfig entry "other.txt" matches, but its checksum "serious" differs from the current checksum silly`},
+		{"pattern", "scanned"},
+	}, results.Explanations["other.txt"])
+	assert.Equal(t, []Explanation{{"pattern", `ignored by fileignoreconfig entry "ignore-contents", which ignores the filecontent detector`}}, results.Explanations["ignore-contents"])
+	assert.Equal(t, []Explanation{{"pattern", "no fileignoreconfig entry matches"}, {"pattern", "scanned"}}, results.Explanations["new.txt"])
+}
+
+func TestRemoveScopedFilesExplainsScopes(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{ScopeConfig: []talismanrc.ScopeConfig{{ScopeName: "node"}}}
+	results := NewDetectionResults()
+	results.EnableExplain()
+
+	remaining := RemoveScopedFiles(tRC, []gitrepo.Addition{gitrepo.NewAddition("yarn.lock", nil), gitrepo.NewAddition("app.js", nil)}, results)
+
+	assert.Equal(t, []gitrepo.Addition{gitrepo.NewAddition("app.js", nil)}, remaining)
+	assert.Equal(t, []Explanation{{"scope", `excluded by scope "node"`}}, results.Explanations["yarn.lock"])
+	assert.Equal(t, []Explanation{{"scope", "not covered by any scope"}}, results.Explanations["app.js"])
+}
+
+func TestExplanationsAreEmbeddedInJsonReport(t *testing.T) {
+	results := NewDetectionResults()
+	jsonWithoutExplain, _ := json.Marshal(results)
+	assert.NotContains(t, string(jsonWithoutExplain), "explanations")
+
+	results.EnableExplain()
+	results.Explain("some_file", "filename", "scanned")
+	jsonWithExplain, _ := json.Marshal(results)
+	assert.Contains(t, string(jsonWithExplain), `"explanations":{"some_file":[{"detector":"filename","decision":"scanned"}]}`)
+}
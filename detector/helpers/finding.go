@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"talisman/gitrepo"
+)
+
+// Finding is a candidate secret detected in the contents of an Addition.
+// Line holds the line (or lines, for secrets spanning several of them) the secret was found in,
+// and Start and End are the offsets of the secret within Line.
+type Finding struct {
+	Secret string
+	Line   string
+	Start  int
+	End    int
+}
+
+// NewFinding returns the Finding for the secret at content[start:end], along with the lines surrounding it
+func NewFinding(content string, start, end int) Finding {
+	lineStart := strings.LastIndex(content[:start], "\n") + 1
+	lineEnd := len(content)
+	if index := strings.Index(content[end:], "\n"); index >= 0 {
+		lineEnd = end + index
+	}
+	return Finding{
+		Secret: content[start:end],
+		Line:   content[lineStart:lineEnd],
+		Start:  start - lineStart,
+		End:    end - lineStart,
+	}
+}
+
+// IgnoreAllowedFinding records a finding that was suppressed by an allowed pattern from the .talismanrc as ignored.
+// The secret is masked unless secrets are revealed.
+func (r *DetectionResults) IgnoreAllowedFinding(filePath gitrepo.FilePath, category string, detector string, secret string, pattern string) {
+	rule := fmt.Sprintf("finding %q is allowed by pattern %q", r.Masked(secret), pattern)
+	r.IgnoreWithRule(filePath, category, rule)
+	r.Explain(filePath, detector, "%s", rule)
+}
@@ -0,0 +1,15 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFindingLocatesSecretWithinItsLine(t *testing.T) {
+	content := "first line\nkey=secret value\nlast line"
+
+	finding := NewFinding(content, 15, 21)
+
+	assert.Equal(t, Finding{Secret: "secret", Line: "key=secret value", Start: 4, End: 10}, finding)
+}
@@ -1,7 +1,7 @@
 package helpers
 
 import (
-	"os"
+	"fmt"
 	"talisman/checksumcalculator"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
@@ -10,6 +10,8 @@ import (
 
 type IgnoreEvaluator interface {
 	ShouldIgnore(addition gitrepo.Addition, detectorType string) bool
+	// ExplainIgnore answers the same question as ShouldIgnore, along with the rules that decided it
+	ExplainIgnore(addition gitrepo.Addition, detectorType string) (bool, []string)
 }
 
 type scanAllAdditions struct{}
@@ -24,18 +26,63 @@ func (ie *scanAllAdditions) ShouldIgnore(gitrepo.Addition, string) bool {
 	return false
 }
 
+func (ie *scanAllAdditions) ExplainIgnore(gitrepo.Addition, string) (bool, []string) {
+	return false, []string{"history scans do not apply the fileignoreconfig entries of .talismanrc"}
+}
+
+type historicBlobEvaluator struct {
+	talismanRC *talismanrc.TalismanRC
+}
+
+// Returns an IgnoreEvaluator that applies the rules defined in the current .talismanrc file to blobs from the history of a repo.
+// As historic blobs are not part of the working tree, checksums are compared against the contents of each blob on its own.
+func HistoricBlobEvaluator(talismanRC *talismanrc.TalismanRC) IgnoreEvaluator {
+	return &historicBlobEvaluator{talismanRC: talismanRC}
+}
+
+// ShouldIgnore returns true if the talismanRC indicates that a Detector should ignore a historic blob
+func (ie *historicBlobEvaluator) ShouldIgnore(addition gitrepo.Addition, detectorType string) bool {
+	ignored, _ := ie.ExplainIgnore(addition, detectorType)
+	return ignored
+}
+
+// ExplainIgnore returns ShouldIgnore along with the fileignoreconfig entry and checksum comparison that decided it
+func (ie *historicBlobEvaluator) ExplainIgnore(addition gitrepo.Addition, detectorType string) (bool, []string) {
+	if ignore := ie.talismanRC.DeniedBy(addition, detectorType); ignore != nil {
+		return true, []string{fmt.Sprintf("ignored by fileignoreconfig entry %q, which ignores the %s detector", ignore.GetFileName(), detectorType)}
+	}
+	ignore, _ := ie.talismanRC.FileIgnoreConfigFor(addition)
+	if ignore == nil {
+		return false, []string{"no fileignoreconfig entry matches"}
+	}
+	blobChecksum := utility.SHA256HashOfContents(string(addition.Path), addition.Data)
+	if ignore.ChecksumMatches(blobChecksum) {
+		return true, []string{fmt.Sprintf("ignored by fileignoreconfig entry %q: checksum %s of the blob matches", ignore.GetFileName(), blobChecksum)}
+	}
+	return false, []string{fmt.Sprintf("fileignoreconfig entry %q matches, but its checksum %q differs from the checksum %s of the blob", ignore.GetFileName(), ignore.Checksum, blobChecksum)}
+}
+
 type ignoreEvaluator struct {
 	calculator checksumcalculator.ChecksumCalculator
 	talismanRC *talismanrc.TalismanRC
 }
 
-// Returns an IgnoreEvaluator around the rules defined in the current .talismanrc file
-func BuildIgnoreEvaluator(hasherMode string, talismanRC *talismanrc.TalismanRC, repo gitrepo.GitRepo) IgnoreEvaluator {
-	wd, _ := os.Getwd()
-	hasher := utility.MakeHasher(hasherMode, wd)
-	allTrackedFiles := append(repo.TrackedFilesAsAdditions(), repo.StagedAdditions()...)
-	calculator := checksumcalculator.NewChecksumCalculator(hasher, allTrackedFiles)
-	return &ignoreEvaluator{calculator: calculator, talismanRC: talismanRC}
+// Returns an IgnoreEvaluator around the rules defined in the current .talismanrc file, calculating checksums with a hasher of hashers
+func BuildIgnoreEvaluator(hashers *utility.Hashers, hasherMode string, talismanRC *talismanrc.TalismanRC, repo gitrepo.GitRepo) (IgnoreEvaluator, error) {
+	hasher, err := hashers.Make(hasherMode, repo)
+	if err != nil {
+		return nil, err
+	}
+	trackedFiles, err := repo.TrackedFilesAsAdditions()
+	if err != nil {
+		return nil, err
+	}
+	stagedFiles, err := repo.StagedAdditions()
+	if err != nil {
+		return nil, err
+	}
+	calculator := checksumcalculator.NewChecksumCalculator(hasher, append(trackedFiles, stagedFiles...))
+	return &ignoreEvaluator{calculator: calculator, talismanRC: talismanRC}, nil
 }
 
 // ShouldIgnore returns true if the talismanRC indicates that a Detector should ignore an Addition
@@ -43,13 +90,28 @@ func (ie *ignoreEvaluator) ShouldIgnore(addition gitrepo.Addition, detectorType
 	return ie.talismanRC.Deny(addition, detectorType) || ie.isScanNotRequired(addition)
 }
 
+// ExplainIgnore returns ShouldIgnore along with the fileignoreconfig entry and checksum comparison that decided it
+func (ie *ignoreEvaluator) ExplainIgnore(addition gitrepo.Addition, detectorType string) (bool, []string) {
+	if ignore := ie.talismanRC.DeniedBy(addition, detectorType); ignore != nil {
+		return true, []string{fmt.Sprintf("ignored by fileignoreconfig entry %q, which ignores the %s detector", ignore.GetFileName(), detectorType)}
+	}
+	ignore, patterns := ie.talismanRC.FileIgnoreConfigFor(addition)
+	if ignore == nil {
+		return false, []string{"no fileignoreconfig entry matches"}
+	}
+	currentCollectiveChecksum := ie.calculator.CalculateCollectiveChecksumForPatterns(patterns)
+	if ignore.ChecksumMatches(currentCollectiveChecksum) {
+		return true, []string{fmt.Sprintf("ignored by fileignoreconfig entry %q: checksum %s matches", ignore.GetFileName(), currentCollectiveChecksum)}
+	}
+	return false, []string{fmt.Sprintf("fileignoreconfig entry %q matches, but its checksum %q differs from the current checksum %s", ignore.GetFileName(), ignore.Checksum, currentCollectiveChecksum)}
+}
+
 // isScanNotRequired returns true if an Addition's checksum matches one ignored by the .talismanrc file
 func (ie *ignoreEvaluator) isScanNotRequired(addition gitrepo.Addition) bool {
-	for _, ignore := range ie.talismanRC.FileIgnoreConfig {
-		if addition.Matches(ignore.GetFileName()) {
-			currentCollectiveChecksum := ie.calculator.CalculateCollectiveChecksumForPattern(ignore.GetFileName())
-			return ignore.ChecksumMatches(currentCollectiveChecksum)
-		}
+	ignore, patterns := ie.talismanRC.FileIgnoreConfigFor(addition)
+	if ignore == nil {
+		return false
 	}
-	return false
+	currentCollectiveChecksum := ie.calculator.CalculateCollectiveChecksumForPatterns(patterns)
+	return ignore.ChecksumMatches(currentCollectiveChecksum)
 }
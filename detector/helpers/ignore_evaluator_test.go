@@ -5,6 +5,7 @@ import (
 	"talisman/gitrepo"
 	mockchecksumcalculator "talisman/internal/mock/checksumcalculator"
 	"talisman/talismanrc"
+	"talisman/utility"
 
 	"github.com/golang/mock/gomock"
 	logr "github.com/sirupsen/logrus"
@@ -44,7 +45,7 @@ func TestIsScanNotRequired(t *testing.T) {
 		}
 		ie := ignoreEvaluator{calculator: checksumCalculator, talismanRC: &ignoreConfig}
 		addition := gitrepo.Addition{Name: "some.txt", Path: "some.txt"}
-		checksumCalculator.EXPECT().CalculateCollectiveChecksumForPattern("some.txt").Return("sha1")
+		checksumCalculator.EXPECT().CalculateCollectiveChecksumForPatterns([]string{"some.txt"}).Return("sha1")
 
 		required := ie.isScanNotRequired(addition)
 
@@ -58,6 +59,9 @@ type sillyChecksumCalculator struct{}
 func (scc *sillyChecksumCalculator) CalculateCollectiveChecksumForPattern(fileNamePattern string) string {
 	return "silly"
 }
+func (scc *sillyChecksumCalculator) CalculateCollectiveChecksumForPatterns(fileNamePatterns []string) string {
+	return "silly"
+}
 func (scc *sillyChecksumCalculator) SuggestTalismanRC(fileNamePatterns []string) string {
 	return ""
 }
@@ -102,3 +106,36 @@ func TestNeverIgnoreFilesForHistory(t *testing.T) {
 	scanAllEvaluator := ScanHistoryEvaluator()
 	assert.False(t, scanAllEvaluator.ShouldIgnore(gitrepo.Addition{Name: "any-file"}, "any_detector"))
 }
+
+func TestExplainingHistoryScans(t *testing.T) {
+	ignored, reasons := ScanHistoryEvaluator().ExplainIgnore(gitrepo.Addition{Name: "any-file"}, "any_detector")
+	assert.False(t, ignored)
+	assert.Equal(t, []string{"history scans do not apply the fileignoreconfig entries of .talismanrc"}, reasons)
+}
+
+func TestHistoricBlobEvaluator(t *testing.T) {
+	blob := gitrepo.NewScannerAddition("some.txt", []string{"commit"}, []byte("reviewed contents"))
+	tRC := talismanrc.TalismanRC{
+		FileIgnoreConfig: []talismanrc.FileIgnoreConfig{
+			{FileName: "some.txt", Checksum: utility.SHA256HashOfContents("some.txt", []byte("reviewed contents"))},
+			{FileName: "ignore-contents", IgnoreDetectors: []string{"filecontent"}},
+		},
+	}
+	ie := HistoricBlobEvaluator(&tRC)
+
+	t.Run("Should ignore blob if its own checksum matches", func(t *testing.T) {
+		assert.True(t, ie.ShouldIgnore(blob, "filecontent"))
+	})
+
+	t.Run("Should not ignore other versions of the same file", func(t *testing.T) {
+		changedBlob := gitrepo.NewScannerAddition("some.txt", []string{"other commit"}, []byte("new contents"))
+		ignored, reasons := ie.ExplainIgnore(changedBlob, "filecontent")
+		assert.False(t, ignored)
+		assert.Contains(t, reasons[0], "differs from the checksum")
+	})
+
+	t.Run("Should ignore if detector is disabled for file", func(t *testing.T) {
+		assert.True(t, ie.ShouldIgnore(gitrepo.NewScannerAddition("ignore-contents", nil, []byte("any")), "filecontent"))
+		assert.False(t, ie.ShouldIgnore(gitrepo.NewScannerAddition("ignore-contents", nil, []byte("any")), "filename"))
+	})
+}
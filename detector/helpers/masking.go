@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// minPreviewLength is the length below which a secret is previewed as *** alone. Its first and last characters would tell too much of it,
+// and a hash of so few characters could be brute-forced.
+const minPreviewLength = 12
+
+// Preview returns a masked preview of a secret, which is safe to report: its first and last two characters along with the start of its SHA-256 hash,
+// e.g. AK...LE (sha256:1a2b3c4d). The hash tells findings of the same secret apart from findings of other ones.
+func Preview(secret string) string {
+	characters := []rune(secret)
+	if len(characters) < minPreviewLength {
+		return "***"
+	}
+	sum := sha256.Sum256([]byte(secret))
+	hash := "sha256:" + hex.EncodeToString(sum[:])[:8]
+	return fmt.Sprintf("%s...%s (%s)", string(characters[:2]), string(characters[len(characters)-2:]), hash)
+}
+
+// RevealSecrets makes the results report secrets in full rather than masked. It is meant for local runs only,
+// as reports of other runs end up in terminals, CI logs and report files that others can read.
+func (r *DetectionResults) RevealSecrets() {
+	r.revealSecrets = true
+}
+
+// RevealingSecrets answers if secrets are reported in full for the current run
+func (r *DetectionResults) RevealingSecrets() bool {
+	return r.revealSecrets
+}
+
+// Masked returns a secret as the results may report it, i.e. its Preview unless secrets are revealed
+func (r *DetectionResults) Masked(secret string) string {
+	if r.revealSecrets {
+		return secret
+	}
+	return Preview(secret)
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewShowsTheEndsOfASecretAlongWithItsHash(t *testing.T) {
+	assert.Equal(t, "wJ...EY (sha256:78314b11)", Preview("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"))
+	assert.NotEqual(t, Preview("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"), Preview("wJalrXUtnFEMI/K7MDENG/bPxRfiCYOTHERKEY"))
+}
+
+func TestPreviewShowsNothingOfShortSecrets(t *testing.T) {
+	assert.Equal(t, "***", Preview("hunter2!"))
+}
+
+func TestPreviewDoesNotSplitCharacters(t *testing.T) {
+	assert.Regexp(t, `^па\.\.\.ль \(sha256:[0-9a-f]{8}\)$`, Preview("пароль-пароль-пароль"))
+}
+
+func TestResultsOnlyReportSecretsInFullWhenRevealed(t *testing.T) {
+	results := NewDetectionResults()
+	assert.False(t, results.RevealingSecrets())
+	assert.Equal(t, Preview("hunter2secret"), results.Masked("hunter2secret"))
+
+	results.RevealSecrets()
+
+	assert.True(t, results.RevealingSecrets())
+	assert.Equal(t, "hunter2secret", results.Masked("hunter2secret"))
+}
+
+func TestAllowedFindingsAreMasked(t *testing.T) {
+	results := NewDetectionResults()
+
+	results.IgnoreAllowedFinding("app.env", "filecontent", "pattern", "password=hunter2secret", "hunter2")
+
+	assert.Equal(t, `finding "pa...et (sha256:fde83c8a)" is allowed by pattern "hunter2"`, results.Results[0].IgnoreList[0].Message)
+}
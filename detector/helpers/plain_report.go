@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReportPlainLines returns the failures, warnings and (in explain mode) explanations of a run as one line each.
+// Unlike the tables of Report, the lines contain no colours or box drawing, so that they stay readable
+// when relayed line by line, e.g. by a git server to a pushing client through the remote sideband.
+func (r *DetectionResults) ReportPlainLines() []string {
+	var lines []string
+	for _, resultDetails := range r.Results {
+		for _, failure := range resultDetails.FailureList {
+			lines = append(lines, plainLine("failure", string(resultDetails.Filename), failure))
+		}
+	}
+	for _, resultDetails := range r.Results {
+		for _, warning := range resultDetails.WarningList {
+			lines = append(lines, plainLine("warning", string(resultDetails.Filename), warning))
+		}
+	}
+	for _, filePath := range r.explainedFilePaths() {
+		for _, explanation := range r.Explanations[filePath] {
+			lines = append(lines, fmt.Sprintf("explain: %s: [%s] %s", filePath, explanation.Detector, singleLine(explanation.Decision)))
+		}
+	}
+	return lines
+}
+
+func plainLine(kind, filePath string, details Details) string {
+	return fmt.Sprintf("%s: %s: [%s, %s] %s", kind, filePath, details.Category, details.Severity, singleLine(details.Message))
+}
+
+func singleLine(message string) string {
+	return strings.Join(strings.Fields(message), " ")
+}
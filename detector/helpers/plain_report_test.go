@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"testing"
+
+	"talisman/detector/severity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportPlainLines(t *testing.T) {
+	results := NewDetectionResults()
+	results.EnableExplain()
+	results.Fail("some_file.pem", "filename", "The file name \"some_file.pem\"\nfailed checks", []string{}, severity.High)
+	results.Warn("other_file", "filecontent", "Potential secret", []string{}, severity.Low)
+	results.Explain("some_file.pem", "filename", "matched pattern %s", "\\.pem$")
+
+	assert.Equal(t, []string{
+		"failure: some_file.pem: [filename, high] The file name \"some_file.pem\" failed checks",
+		"warning: other_file: [filecontent, low] Potential secret",
+		"explain: some_file.pem: [filename] matched pattern \\.pem$",
+	}, results.ReportPlainLines())
+}
+
+func TestReportPlainLinesWithoutFindings(t *testing.T) {
+	assert.Empty(t, NewDetectionResults().ReportPlainLines())
+}
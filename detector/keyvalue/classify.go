@@ -0,0 +1,114 @@
+package keyvalue
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// secretWords are words that make a key name a secret one on their own, e.g. db_password or authToken
+	secretWords = map[string]bool{
+		"password":    true,
+		"passwd":      true,
+		"pass":        true,
+		"pwd":         true,
+		"passphrase":  true,
+		"secret":      true,
+		"token":       true,
+		"credential":  true,
+		"credentials": true,
+		"apikey":      true,
+		"privatekey":  true,
+	}
+	// secretPairs are consecutive words that make a key name a secret one, e.g. api_key or AccessKey
+	secretPairs = map[string]bool{
+		"api key":        true,
+		"access key":     true,
+		"private key":    true,
+		"secret key":     true,
+		"auth key":       true,
+		"signing key":    true,
+		"encryption key": true,
+	}
+	// describingWords end the names of keys that describe a secret rather than hold it, e.g. password_file or token_ttl
+	describingWords = map[string]bool{
+		"file":     true,
+		"path":     true,
+		"dir":      true,
+		"url":      true,
+		"uri":      true,
+		"endpoint": true,
+		"name":     true,
+		"type":     true,
+		"length":   true,
+		"ttl":      true,
+		"expiry":   true,
+		"expires":  true,
+		"timeout":  true,
+		"policy":   true,
+		"enabled":  true,
+		"required": true,
+		"env":      true,
+		"var":      true,
+		"header":   true,
+	}
+)
+
+// words splits the name of a key into lower case words, at separators and at camel case boundaries,
+// e.g. both "DB_PASSWORD" and "dbPassword" are split into "db" and "password"
+func words(name string) []string {
+	var result []string
+	var current []rune
+	runes := []rune(name)
+	for index, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				result = append(result, strings.ToLower(string(current)))
+				current = nil
+			}
+			continue
+		}
+		startsWord := unicode.IsUpper(r) && len(current) > 0 &&
+			(unicode.IsLower(current[len(current)-1]) || (index+1 < len(runes) && unicode.IsLower(runes[index+1])))
+		if startsWord {
+			result = append(result, strings.ToLower(string(current)))
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		result = append(result, strings.ToLower(string(current)))
+	}
+	return result
+}
+
+// IsSecretKey answers if the name of a key tells that its value is a secret, such as a password, token or API key
+func IsSecretKey(name string) bool {
+	keyWords := words(name)
+	if len(keyWords) == 0 || describingWords[keyWords[len(keyWords)-1]] {
+		return false
+	}
+	for index, word := range keyWords {
+		if secretWords[word] {
+			return true
+		}
+		if index > 0 && secretPairs[keyWords[index-1]+" "+word] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLiteral answers if a value could be a secret: values that are empty, null, booleans or numbers cannot be
+func IsLiteral(value string) bool {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "", "null", "nil", "none", "~", "true", "false", "yes", "no", "on", "off":
+		return false
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return false
+	}
+	return true
+}
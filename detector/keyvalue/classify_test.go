@@ -0,0 +1,30 @@
+package keyvalue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordsSplitsKeyNames(t *testing.T) {
+	assert.Equal(t, []string{"db", "password"}, words("DB_PASSWORD"))
+	assert.Equal(t, []string{"db", "password"}, words("dbPassword"))
+	assert.Equal(t, []string{"aws", "secret", "access", "key"}, words("aws-secret.access_key"))
+	assert.Equal(t, []string{"api", "key"}, words("APIKey"))
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for _, name := range []string{"password", "db_password", "DB_PASSWD", "pwd", "clientSecret", "authToken", "api_key", "AccessKey", "private-key", "credentials"} {
+		assert.True(t, IsSecretKey(name), name)
+	}
+	for _, name := range []string{"username", "host", "password_file", "tokenTTL", "secretName", "api_key_header", "keyboard", "bypass", "monkey"} {
+		assert.False(t, IsSecretKey(name), name)
+	}
+}
+
+func TestIsLiteral(t *testing.T) {
+	for _, value := range []string{"", "null", "~", "true", "off", "42", "3.14"} {
+		assert.False(t, IsLiteral(value), value)
+	}
+	assert.True(t, IsLiteral("hunter2!"))
+}
@@ -0,0 +1,216 @@
+package keyvalue
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func joinKey(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// parseYAML extracts the scalar values of all documents in a YAML stream, keyed by their path, e.g. "spring.datasource.password"
+func parseYAML(content string) ([]Pair, error) {
+	l := &locator{content: content}
+	var pairs []Pair
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var document yamlNode
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return pairs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pairs = walkYAML(l, pairs, "", "", document.value)
+	}
+}
+
+// yamlNode decodes a YAML node of any kind, e.g. a document that is a list of tasks as in Ansible playbooks.
+// Mappings are decoded into MapSlices, which keep the order of the document, and so are the mappings nested in them.
+type yamlNode struct {
+	value interface{}
+}
+
+func (n *yamlNode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Sequences are tried first, as they would also be decoded into the items of a MapSlice
+	var sequence []yamlNode
+	if unmarshal(&sequence) == nil {
+		items := make([]interface{}, len(sequence))
+		for index, item := range sequence {
+			items[index] = item.value
+		}
+		n.value = items
+		return nil
+	}
+	var mapping yaml.MapSlice
+	if unmarshal(&mapping) == nil {
+		n.value = mapping
+		return nil
+	}
+	return unmarshal(&n.value)
+}
+
+func walkYAML(l *locator, pairs []Pair, path, name string, node interface{}) []Pair {
+	switch value := node.(type) {
+	case yaml.MapSlice:
+		for _, item := range value {
+			key := fmt.Sprint(item.Key)
+			pairs = walkYAML(l, pairs, joinKey(path, key), key, item.Value)
+		}
+	case map[interface{}]interface{}:
+		for key, item := range value {
+			pairs = walkYAML(l, pairs, joinKey(path, fmt.Sprint(key)), fmt.Sprint(key), item)
+		}
+	case []interface{}:
+		for index, item := range value {
+			pairs = walkYAML(l, pairs, fmt.Sprintf("%s[%d]", path, index), name, item)
+		}
+	case nil:
+	default:
+		if path != "" {
+			pairs = append(pairs, l.pair(path, name, fmt.Sprint(value)))
+		}
+	}
+	return pairs
+}
+
+// parseJSON extracts the scalar values of a JSON document, keyed by their path, e.g. "credentials.apiKey"
+func parseJSON(content string) ([]Pair, error) {
+	l := &locator{content: content}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	var pairs []Pair
+	var walk func(path, name string) error
+	walk = func(path, name string) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch value := token.(type) {
+		case json.Delim:
+			if value == '{' {
+				for decoder.More() {
+					keyToken, err := decoder.Token()
+					if err != nil {
+						return err
+					}
+					key := fmt.Sprint(keyToken)
+					if err := walk(joinKey(path, key), key); err != nil {
+						return err
+					}
+				}
+			} else {
+				for index := 0; decoder.More(); index++ {
+					if err := walk(fmt.Sprintf("%s[%d]", path, index), name); err != nil {
+						return err
+					}
+				}
+			}
+			// the closing delimiter
+			_, err = decoder.Token()
+			return err
+		case string:
+			pairs = append(pairs, l.pair(path, name, value))
+		case json.Number:
+			pairs = append(pairs, l.pair(path, name, value.String()))
+		case bool:
+			pairs = append(pairs, l.pair(path, name, strconv.FormatBool(value)))
+		}
+		return nil
+	}
+	if err := walk("", ""); err != nil {
+		return nil, err
+	}
+	return withKeys(pairs), nil
+}
+
+func withKeys(pairs []Pair) []Pair {
+	var result []Pair
+	for _, pair := range pairs {
+		if pair.Key != "" {
+			result = append(result, pair)
+		}
+	}
+	return result
+}
+
+type xmlElement struct {
+	path        string
+	name        string
+	text        strings.Builder
+	hasChildren bool
+}
+
+// parseXML extracts the texts of leaf elements and the attributes of an XML document, keyed by their path, e.g. "settings.db.password".
+// Elements naming a setting in a key (or name) attribute along with a value attribute, as in <add key="ApiKey" value="..."/>,
+// are keyed by the name of the setting.
+func parseXML(content string) ([]Pair, error) {
+	l := &locator{content: content}
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	var pairs []Pair
+	var stack []*xmlElement
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return pairs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			path := element.Name.Local
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.hasChildren = true
+				path = joinKey(parent.path, path)
+			}
+			stack = append(stack, &xmlElement{path: path, name: element.Name.Local})
+			pairs = append(pairs, xmlAttributePairs(l, path, element.Attr)...)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(element)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if text := strings.TrimSpace(current.text.String()); !current.hasChildren && text != "" {
+				pairs = append(pairs, l.pair(current.path, current.name, text))
+			}
+		}
+	}
+}
+
+func xmlAttributePairs(l *locator, path string, attributes []xml.Attr) []Pair {
+	var setting, value *xml.Attr
+	for index, attribute := range attributes {
+		switch strings.ToLower(attribute.Name.Local) {
+		case "key", "name":
+			setting = &attributes[index]
+		case "value":
+			value = &attributes[index]
+		}
+	}
+	if setting != nil && value != nil {
+		return []Pair{l.pair(setting.Value, setting.Value, value.Value)}
+	}
+	var pairs []Pair
+	for _, attribute := range attributes {
+		pairs = append(pairs, l.pair(path+"@"+attribute.Name.Local, attribute.Name.Local, attribute.Value))
+	}
+	return pairs
+}
@@ -0,0 +1,88 @@
+package keyvalue
+
+import (
+	"fmt"
+	"talisman/detector/helpers"
+	"talisman/detector/placeholder"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// KeyValueDetector flags literal values of secret keys, e.g. db_password: "hunter2!", in structured configuration files.
+// Placeholders, e.g. password: ${DB_PASSWORD}, are not flagged.
+type KeyValueDetector struct{}
+
+// NewKeyValueDetector returns a KeyValueDetector
+func NewKeyValueDetector() *KeyValueDetector {
+	return &KeyValueDetector{}
+}
+
+type keyValues struct {
+	name    gitrepo.FileName
+	path    gitrepo.FilePath
+	commits []string
+	secrets []Pair
+	allowed []helpers.AllowedFinding
+}
+
+// Test tests the values of secret keys in the structured files among the Additions
+func (detector KeyValueDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, tRC *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	findingSeverity := tRC.SeverityConfiguration().SeverityOf("SecretKeyValue")
+	placeholders := placeholder.NewClassifier(tRC.Placeholders)
+	helpers.CheckContents(comparator, currentAdditions, "keyvalue", result, additionCompletionCallback, func(addition gitrepo.Addition) func() {
+		kv := detector.secretValues(addition, placeholders, tRC, result)
+		return func() { processKeyValues(kv, findingSeverity, tRC, result) }
+	})
+}
+
+func (detector KeyValueDetector) secretValues(addition gitrepo.Addition, placeholders *placeholder.Classifier, tRC *talismanrc.TalismanRC, result *helpers.DetectionResults) keyValues {
+	kv := keyValues{name: addition.Name, path: addition.Path, commits: addition.Commits}
+	pairs, format, parsed := Parse(addition)
+	if !parsed {
+		if format != "" {
+			result.Explain(addition.Path, "keyvalue", "not checking keys as the file could not be parsed as %s", format)
+		}
+		return kv
+	}
+	result.Explain(addition.Path, "keyvalue", "parsed %d key(s) as %s", len(pairs), format)
+	for _, pair := range pairs {
+		if !IsSecretKey(pair.Name()) {
+			continue
+		}
+		if !IsLiteral(pair.Value) {
+			continue
+		}
+		if kind, isPlaceholder := placeholders.Classify(pair.Value); isPlaceholder {
+			result.Explain(addition.Path, "keyvalue", "value %s of secret key %q is a placeholder (%s), not flagging it", pair.Value, pair.Key, kind)
+			continue
+		}
+		if pattern, allowed := tRC.AllowedPatternFor(addition, pair.Finding.Line, pair.Finding.Start, pair.Finding.End); allowed {
+			kv.allowed = append(kv.allowed, helpers.AllowedFinding{Secret: pair.Value, Pattern: pattern})
+			continue
+		}
+		kv.secrets = append(kv.secrets, pair)
+	}
+	return kv
+}
+
+func processKeyValues(kv keyValues, findingSeverity severity.Severity, tRC *talismanrc.TalismanRC, result *helpers.DetectionResults) {
+	for _, allowed := range kv.allowed {
+		result.IgnoreAllowedFinding(kv.path, "filecontent", "keyvalue", allowed.Secret, allowed.Pattern)
+	}
+	for _, pair := range kv.secrets {
+		log.WithFields(log.Fields{
+			"filePath": kv.path,
+			"key":      pair.Key,
+		}).Info("KeyValueDetector: Failing file as it contains a literal value for a secret key.")
+		message := fmt.Sprintf("Expected file to not contain a literal value for secret key %q such as: %s", pair.Key, helpers.FormatForReporting(result.Masked(pair.Value)))
+		addition := gitrepo.Addition{Path: kv.path, Name: kv.name}
+		if assessedSeverity, fails := result.AssessFinding(tRC, addition, "keyvalue", message, findingSeverity, tRC.Threshold); fails {
+			result.Fail(kv.path, "filecontent", message, kv.commits, assessedSeverity)
+		} else {
+			result.Warn(kv.path, "filecontent", message, kv.commits, assessedSeverity)
+		}
+	}
+}
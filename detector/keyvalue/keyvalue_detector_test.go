@@ -0,0 +1,101 @@
+package keyvalue
+
+import (
+	"fmt"
+	"regexp"
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+	"talisman/utility"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var dummyCallback = func() {}
+
+func ignoreEvaluatorWithTalismanRC(tRC *talismanrc.TalismanRC) helpers.IgnoreEvaluator {
+	ie, _ := helpers.BuildIgnoreEvaluator(utility.NewHashers(), "default", tRC, gitrepo.RepoLocatedAt("."))
+	return ie
+}
+
+func testKeyValues(tRC *talismanrc.TalismanRC, additions ...gitrepo.Addition) *helpers.DetectionResults {
+	results := helpers.NewDetectionResults()
+	results.EnableExplain()
+	NewKeyValueDetector().Test(ignoreEvaluatorWithTalismanRC(tRC), additions, tRC, results, dummyCallback)
+	return results
+}
+
+func messagesOf(details []helpers.Details) []string {
+	var messages []string
+	for _, detail := range details {
+		messages = append(messages, detail.Message)
+	}
+	return messages
+}
+
+func TestShouldFlagLiteralValuesOfSecretKeys(t *testing.T) {
+	results := testKeyValues(&talismanrc.TalismanRC{}, gitrepo.NewAddition("config/database.yml", []byte(`production:
+  host: db.example.com
+  db_password: "hunter2!"
+  token: ${API_TOKEN}
+  pool: 5
+`)))
+
+	assert.Equal(t, []string{`Expected file to not contain a literal value for secret key "production.db_password" such as: ` + helpers.Preview("hunter2!")},
+		messagesOf(results.GetFailures("config/database.yml")))
+	assert.Contains(t, results.Explanations["config/database.yml"],
+		helpers.Explanation{Detector: "keyvalue", Decision: `value ${API_TOKEN} of secret key "production.token" is a placeholder (environment variable reference), not flagging it`})
+}
+
+func TestShouldFlagSecretKeysInEachFormat(t *testing.T) {
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("app.json", []byte(`{"apiKey": "abc123def"}`)),
+		gitrepo.NewAddition("app.properties", []byte("db.password=hunter2!")),
+		gitrepo.NewAddition(".env", []byte("export SECRET_KEY=abc123def")),
+		gitrepo.NewAddition("app.ini", []byte("[auth]\nclient_secret = abc123def")),
+		gitrepo.NewAddition("app.toml", []byte("[auth]\naccess_token = \"abc123def\"")),
+		gitrepo.NewAddition("app.config", []byte(`<configuration><add key="DbPassword" value="hunter2!"/></configuration>`)),
+	}
+
+	results := testKeyValues(&talismanrc.TalismanRC{}, additions...)
+
+	for _, addition := range additions {
+		assert.Len(t, results.GetFailures(addition.Path), 1, string(addition.Path))
+	}
+}
+
+func TestShouldNotFlagFilesThatAreNotStructured(t *testing.T) {
+	results := testKeyValues(&talismanrc.TalismanRC{},
+		gitrepo.NewAddition("main.go", []byte(`password := "hunter2!"`)),
+		gitrepo.NewAddition("broken.json", []byte(`{"password": "hunter2!"`)))
+
+	assert.False(t, results.HasFailures())
+	assert.Contains(t, results.Explanations["broken.json"],
+		helpers.Explanation{Detector: "keyvalue", Decision: "not checking keys as the file could not be parsed as json"})
+}
+
+func TestShouldHonourAllowedPatternsAndThresholdForSecretKeyValues(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		AllowedPatterns: []*talismanrc.Pattern{{Regexp: regexp.MustCompile("hunter2!")}},
+		Threshold:       severity.High,
+	}
+
+	results := testKeyValues(tRC, gitrepo.NewAddition(".env", []byte("DB_PASSWORD=hunter2!\nAPI_TOKEN=abc123def\n")))
+
+	assert.False(t, results.HasFailures())
+	assert.Equal(t, []string{`Expected file to not contain a literal value for secret key "API_TOKEN" such as: ` + helpers.Preview("abc123def")},
+		messagesOf(results.GetWarnings(".env")))
+	assert.Contains(t, results.Explanations[".env"],
+		helpers.Explanation{Detector: "keyvalue", Decision: fmt.Sprintf(`finding %q is allowed by pattern "hunter2!"`, helpers.Preview("hunter2!"))})
+}
+
+func TestShouldNotFlagSecretKeyValuesInIgnoredFiles(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{FileName: ".env", IgnoreDetectors: []string{"filecontent"}}}}
+
+	results := testKeyValues(tRC, gitrepo.NewAddition(".env", []byte("DB_PASSWORD=hunter2!")))
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasIgnores())
+}
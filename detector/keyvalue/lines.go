@@ -0,0 +1,184 @@
+package keyvalue
+
+import (
+	"strings"
+)
+
+// line is a line of a file, along with the offset it starts at in the contents of the file
+type line struct {
+	text  string
+	start int
+}
+
+func linesOf(content string) []line {
+	var lines []line
+	start := 0
+	for _, text := range strings.SplitAfter(content, "\n") {
+		lines = append(lines, line{text: strings.TrimRight(text, "\r\n"), start: start})
+		start += len(text)
+	}
+	return lines
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+func skipBlanks(text string, from int) int {
+	for from < len(text) && isBlank(text[from]) {
+		from++
+	}
+	return from
+}
+
+// valueBounds returns where the value starting at a position of a line begins and ends, without surrounding whitespace and quotes.
+// Unquoted values end at an inline comment, i.e. one of the commentMarkers following whitespace.
+func valueBounds(text string, from int, commentMarkers string) (int, int) {
+	start := skipBlanks(text, from)
+	if start < len(text) && (text[start] == '"' || text[start] == '\'') {
+		quote := text[start]
+		for end := start + 1; end < len(text); end++ {
+			if text[end] == '\\' && quote == '"' {
+				end++
+				continue
+			}
+			if text[end] == quote {
+				return start + 1, end
+			}
+		}
+	}
+	end := len(text)
+	for i := start + 1; i < len(text); i++ {
+		if strings.IndexByte(commentMarkers, text[i]) >= 0 && isBlank(text[i-1]) {
+			end = i
+			break
+		}
+	}
+	for end > start && isBlank(text[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+func unquoteKey(key string) string {
+	return strings.NewReplacer(`"`, "", "'", "").Replace(strings.TrimSpace(key))
+}
+
+// parseProperties extracts the pairs of a Java .properties file, whose keys are separated from values by '=', ':' or whitespace
+func parseProperties(content string) []Pair {
+	var pairs []Pair
+	lines := linesOf(content)
+	for index := 0; index < len(lines); index++ {
+		text := lines[index].text
+		keyStart := skipBlanks(text, 0)
+		if keyStart == len(text) || text[keyStart] == '#' || text[keyStart] == '!' {
+			continue
+		}
+		keyEnd := keyStart
+		for keyEnd < len(text) && !strings.ContainsRune("=: \t\f", rune(text[keyEnd])) {
+			if text[keyEnd] == '\\' {
+				keyEnd++
+			}
+			keyEnd++
+		}
+		keyEnd = min(keyEnd, len(text))
+		valueStart := skipBlanks(text, keyEnd)
+		if valueStart < len(text) && (text[valueStart] == '=' || text[valueStart] == ':') {
+			valueStart = skipBlanks(text, valueStart+1)
+		}
+		key := strings.ReplaceAll(text[keyStart:keyEnd], `\`, "")
+		if !continues(text) {
+			pairs = append(pairs, linePair(content, lines[index].start, key, valueStart, len(text)))
+			continue
+		}
+		// the value goes on in the following lines, without their leading whitespace
+		first := lines[index]
+		value := ""
+		if valueStart <= len(text)-1 {
+			value = text[valueStart : len(text)-1]
+		}
+		for continues(lines[index].text) && index+1 < len(lines) {
+			index++
+			next := lines[index].text
+			value += strings.TrimRight(next[skipBlanks(next, 0):], `\`)
+		}
+		pair := linePair(content, first.start, key, valueStart, lines[index].start-first.start+len(lines[index].text))
+		pair.Value = value
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// continues answers if a line of a .properties file ends with an unescaped backslash
+func continues(text string) bool {
+	backslashes := len(text) - len(strings.TrimRight(text, `\`))
+	return backslashes%2 == 1
+}
+
+// parseEnv extracts the pairs of a dotenv file, i.e. lines of KEY=value, optionally prefixed with export
+func parseEnv(content string) []Pair {
+	var pairs []Pair
+	for _, l := range linesOf(content) {
+		text := l.text
+		keyStart := skipBlanks(text, 0)
+		if strings.HasPrefix(text[keyStart:], "export ") {
+			keyStart = skipBlanks(text, keyStart+len("export "))
+		}
+		separator := strings.IndexByte(text, '=')
+		if separator < keyStart || strings.HasPrefix(text[keyStart:], "#") {
+			continue
+		}
+		valueStart, valueEnd := valueBounds(text, separator+1, "#")
+		pairs = append(pairs, linePair(content, l.start, strings.TrimSpace(text[keyStart:separator]), valueStart, valueEnd))
+	}
+	return pairs
+}
+
+// parseINI extracts the pairs of an INI file, keyed by their section, e.g. "database.password"
+func parseINI(content string) []Pair {
+	var pairs []Pair
+	section := ""
+	for _, l := range linesOf(content) {
+		text := l.text
+		keyStart := skipBlanks(text, 0)
+		if keyStart == len(text) || text[keyStart] == ';' || text[keyStart] == '#' {
+			continue
+		}
+		if text[keyStart] == '[' {
+			section = strings.TrimSpace(strings.Trim(strings.TrimSpace(text), "[]"))
+			continue
+		}
+		separator := strings.IndexAny(text, "=:")
+		if separator < 0 {
+			continue
+		}
+		valueStart, valueEnd := valueBounds(text, separator+1, ";#")
+		pairs = append(pairs, linePair(content, l.start, joinKey(section, strings.TrimSpace(text[keyStart:separator])), valueStart, valueEnd))
+	}
+	return pairs
+}
+
+// parseTOML extracts the pairs of a TOML file, keyed by their table, e.g. "database.password".
+// Values spanning multiple lines, such as multi-line strings and arrays, are only extracted up to the end of their first line.
+func parseTOML(content string) []Pair {
+	var pairs []Pair
+	table := ""
+	for _, l := range linesOf(content) {
+		text := l.text
+		keyStart := skipBlanks(text, 0)
+		if keyStart == len(text) || text[keyStart] == '#' {
+			continue
+		}
+		if text[keyStart] == '[' {
+			table = unquoteKey(strings.Trim(strings.TrimSpace(text), "[]"))
+			continue
+		}
+		separator := strings.IndexByte(text, '=')
+		if separator < 0 {
+			continue
+		}
+		valueStart, valueEnd := valueBounds(text, separator+1, "#")
+		pairs = append(pairs, linePair(content, l.start, joinKey(table, unquoteKey(text[keyStart:separator])), valueStart, valueEnd))
+	}
+	return pairs
+}
@@ -0,0 +1,135 @@
+package keyvalue
+
+import (
+	"path/filepath"
+	"strings"
+
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+)
+
+// Format is a structured file format that key/value pairs can be extracted from
+type Format string
+
+const (
+	YAML       Format = "yaml"
+	JSON       Format = "json"
+	Properties Format = "properties"
+	Env        Format = "env"
+	INI        Format = "ini"
+	TOML       Format = "toml"
+	XML        Format = "xml"
+)
+
+// Pair is a key along with its value, as found in a structured file.
+// Key is the path of the key within the file, e.g. "database.password", while Finding locates the value in the contents.
+type Pair struct {
+	Key     string
+	Value   string
+	Finding helpers.Finding
+}
+
+// Name returns the last segment of the path of the key, i.e. the name that tells what the value holds
+func (p Pair) Name() string {
+	key := p.Key
+	for strings.HasSuffix(key, "]") {
+		if index := strings.LastIndex(key, "["); index >= 0 {
+			key = key[:index]
+		} else {
+			break
+		}
+	}
+	if index := strings.LastIndexAny(key, ".@"); index >= 0 {
+		return key[index+1:]
+	}
+	return key
+}
+
+// FormatOf returns the structured format of a file, judging by its name
+func FormatOf(name gitrepo.FileName) (Format, bool) {
+	fileName := strings.ToLower(string(name))
+	switch filepath.Ext(fileName) {
+	case ".yaml", ".yml":
+		return YAML, true
+	case ".json":
+		return JSON, true
+	case ".properties":
+		return Properties, true
+	case ".env":
+		return Env, true
+	case ".ini", ".cfg":
+		return INI, true
+	case ".toml":
+		return TOML, true
+	case ".xml", ".config":
+		return XML, true
+	}
+	if strings.HasPrefix(fileName, ".env.") {
+		return Env, true
+	}
+	return "", false
+}
+
+// Parse extracts the key/value pairs from an Addition in one of the structured formats.
+// It returns false for other files, as well as for files that are not valid in the format their name suggests.
+func Parse(addition gitrepo.Addition) ([]Pair, Format, bool) {
+	format, ok := FormatOf(addition.Name)
+	if !ok {
+		return nil, "", false
+	}
+	content := string(addition.Data)
+	var pairs []Pair
+	var err error
+	switch format {
+	case YAML:
+		pairs, err = parseYAML(content)
+	case JSON:
+		pairs, err = parseJSON(content)
+	case XML:
+		pairs, err = parseXML(content)
+	case Properties:
+		pairs = parseProperties(content)
+	case Env:
+		pairs = parseEnv(content)
+	case INI:
+		pairs = parseINI(content)
+	case TOML:
+		pairs = parseTOML(content)
+	}
+	if err != nil {
+		return nil, format, false
+	}
+	return pairs, format, true
+}
+
+// locator finds the values of pairs in the contents of a document that was parsed by a decoder which does not report positions.
+// Pairs are expected in document order, so that each search starts after the value that was located last.
+type locator struct {
+	content string
+	offset  int
+}
+
+// pair returns a Pair whose value is located after the next occurrence of the name of its key
+func (l *locator) pair(key, name, value string) Pair {
+	for _, from := range []int{l.offset, 0} {
+		start := from
+		if index := strings.Index(l.content[from:], name); index >= 0 {
+			start = from + index + len(name)
+		}
+		if index := strings.Index(l.content[start:], value); index >= 0 && value != "" {
+			valueStart := start + index
+			l.offset = valueStart + len(value)
+			return Pair{Key: key, Value: value, Finding: helpers.NewFinding(l.content, valueStart, l.offset)}
+		}
+	}
+	return Pair{Key: key, Value: value, Finding: helpers.Finding{Secret: value}}
+}
+
+// linePair returns a Pair for a value found at the given offsets of a line, which starts at lineStart in the content
+func linePair(content string, lineStart int, key string, valueStart, valueEnd int) Pair {
+	return Pair{
+		Key:     key,
+		Value:   content[lineStart+valueStart : lineStart+valueEnd],
+		Finding: helpers.NewFinding(content, lineStart+valueStart, lineStart+valueEnd),
+	}
+}
@@ -0,0 +1,184 @@
+package keyvalue
+
+import (
+	"strings"
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func valuesByKey(t *testing.T, name string, content string) map[string]string {
+	pairs, _, parsed := Parse(gitrepo.NewAddition(name, []byte(content)))
+	assert.True(t, parsed, "expected %s to be parsed", name)
+	values := map[string]string{}
+	for _, pair := range pairs {
+		values[pair.Key] = pair.Value
+		if pair.Finding.Line != "" && !strings.Contains(pair.Finding.Secret, "\n") {
+			assert.Equal(t, pair.Value, pair.Finding.Line[pair.Finding.Start:pair.Finding.End], "expected the value of %s to be located", pair.Key)
+		}
+	}
+	return values
+}
+
+func TestFormatOfStructuredFiles(t *testing.T) {
+	for name, expected := range map[string]Format{
+		"config/app.yml":         YAML,
+		"values.YAML":            YAML,
+		"package.json":           JSON,
+		"application.properties": Properties,
+		".env":                   Env,
+		".env.production":        Env,
+		"setup.cfg":              INI,
+		"php.ini":                INI,
+		"Cargo.toml":             TOML,
+		"pom.xml":                XML,
+		"web.config":             XML,
+	} {
+		format, ok := FormatOf(gitrepo.FileName(name))
+		assert.True(t, ok, name)
+		assert.Equal(t, expected, format, name)
+	}
+	_, ok := FormatOf("main.go")
+	assert.False(t, ok)
+}
+
+func TestParseYAMLKeysByPathAcrossDocuments(t *testing.T) {
+	values := valuesByKey(t, "app.yml", `spring:
+  datasource:
+    password: "hunter2!"
+    hosts: [one, two]
+---
+db_password: other
+`)
+
+	assert.Equal(t, map[string]string{
+		"spring.datasource.password": "hunter2!",
+		"spring.datasource.hosts[0]": "one",
+		"spring.datasource.hosts[1]": "two",
+		"db_password":                "other",
+	}, values)
+}
+
+func TestParseYAMLDocumentsThatAreSequences(t *testing.T) {
+	values := valuesByKey(t, "tasks.yml", `- name: create the database user
+  mysql_user:
+    name: admin
+    password: "hunter2!"
+- name: restart the database
+  service: {name: mysql, state: restarted}
+`)
+
+	assert.Equal(t, map[string]string{
+		"[0].name":                "create the database user",
+		"[0].mysql_user.name":     "admin",
+		"[0].mysql_user.password": "hunter2!",
+		"[1].name":                "restart the database",
+		"[1].service.name":        "mysql",
+		"[1].service.state":       "restarted",
+	}, values)
+}
+
+func TestParseJSONKeysByPath(t *testing.T) {
+	values := valuesByKey(t, "secrets.json", `{"credentials": {"apiKey": "abc123", "retries": 3, "hosts": [{"password": "p4ss"}]}}`)
+
+	assert.Equal(t, map[string]string{
+		"credentials.apiKey":            "abc123",
+		"credentials.retries":           "3",
+		"credentials.hosts[0].password": "p4ss",
+	}, values)
+}
+
+func TestParseProperties(t *testing.T) {
+	values := valuesByKey(t, "app.properties", "# comment\n! comment\ndb.password=hunter2!\ndb.user : admin\nmessage = first \\\n    second\nspaced value\n")
+
+	assert.Equal(t, map[string]string{
+		"db.password": "hunter2!",
+		"db.user":     "admin",
+		"message":     "first second",
+		"spaced":      "value",
+	}, values)
+}
+
+func TestParsePropertiesWithContinuationsWithoutValues(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected map[string]string
+	}{
+		{"key ending with a backslash", "path\\", map[string]string{"path": ""}},
+		{"key continued on the next line", "foo\\\nbar\n", map[string]string{"foo": "bar"}},
+		{"lone backslash", "\\", map[string]string{"": ""}},
+		{"separator followed by a backslash", "key=\\\n", map[string]string{"key": ""}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, valuesByKey(t, "app.properties", tc.content))
+		})
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	values := valuesByKey(t, ".env", "# comment\nexport API_TOKEN=abc123 # inline comment\nDB_PASSWORD=\"hunter2!\"\nEMPTY=\n")
+
+	assert.Equal(t, map[string]string{
+		"API_TOKEN":   "abc123",
+		"DB_PASSWORD": "hunter2!",
+		"EMPTY":       "",
+	}, values)
+}
+
+func TestParseINIKeysBySection(t *testing.T) {
+	values := valuesByKey(t, "settings.ini", "; comment\nglobal = yes\n[database]\npassword = 'hunter2!'\nuser: admin ; inline comment\n")
+
+	assert.Equal(t, map[string]string{
+		"global":            "yes",
+		"database.password": "hunter2!",
+		"database.user":     "admin",
+	}, values)
+}
+
+func TestParseTOMLKeysByTable(t *testing.T) {
+	values := valuesByKey(t, "config.toml", "title = \"app\"\n[database]\n\"password\" = \"hunter2!\" # comment\nport = 5432\n[[servers]]\ntoken = 'abc'\n")
+
+	assert.Equal(t, map[string]string{
+		"title":             "app",
+		"database.password": "hunter2!",
+		"database.port":     "5432",
+		"servers.token":     "abc",
+	}, values)
+}
+
+func TestParseXMLElementsAndSettings(t *testing.T) {
+	values := valuesByKey(t, "web.config", `<?xml version="1.0"?>
+<configuration>
+  <appSettings>
+    <add key="ApiKey" value="abc123"/>
+  </appSettings>
+  <database host="db.local">
+    <password>hunter2!</password>
+  </database>
+</configuration>
+`)
+
+	assert.Equal(t, map[string]string{
+		"ApiKey":                          "abc123",
+		"configuration.database@host":     "db.local",
+		"configuration.database.password": "hunter2!",
+	}, values)
+}
+
+func TestParseFailsForInvalidDocuments(t *testing.T) {
+	_, format, parsed := Parse(gitrepo.NewAddition("broken.json", []byte(`{"password": `)))
+	assert.False(t, parsed)
+	assert.Equal(t, JSON, format)
+
+	_, _, parsed = Parse(gitrepo.NewAddition("main.go", []byte(`password := "hunter2!"`)))
+	assert.False(t, parsed)
+}
+
+func TestPairNameIsTheLastSegmentOfItsKey(t *testing.T) {
+	assert.Equal(t, "password", Pair{Key: "database.password"}.Name())
+	assert.Equal(t, "tokens", Pair{Key: "auth.tokens[0][1]"}.Name())
+	assert.Equal(t, "secret", Pair{Key: "config.client@secret"}.Name())
+	assert.Equal(t, "DB_PASSWORD", Pair{Key: "DB_PASSWORD"}.Name())
+}
@@ -3,6 +3,7 @@ package pattern
 import (
 	"fmt"
 	"regexp"
+	"talisman/detector/helpers"
 	"talisman/detector/severity"
 	"talisman/talismanrc"
 
@@ -14,20 +15,23 @@ type PatternMatcher struct {
 }
 
 type DetectionsWithSeverity struct {
-	detections []string
+	detections []helpers.Finding
 	severity   severity.Severity
+	rule       string
 }
 
-func (pm *PatternMatcher) check(content string, thresholdValue severity.Severity) []DetectionsWithSeverity {
+func (pm *PatternMatcher) check(content string, severities severity.Configuration) []DetectionsWithSeverity {
 	var detectionsWithSeverity []DetectionsWithSeverity
 	for _, pattern := range pm.regexes {
-		var detected []string
+		var detected []helpers.Finding
 		regex := pattern.Pattern
 		logrus.Debugf("checking for pattern %v", regex)
-		matches := regex.FindAllString(content, -1)
+		matches := regex.FindAllStringIndex(content, -1)
 		if matches != nil {
-			detected = append(detected, matches...)
-			detectionsWithSeverity = append(detectionsWithSeverity, DetectionsWithSeverity{detections: detected, severity: pattern.Severity})
+			for _, match := range matches {
+				detected = append(detected, helpers.NewFinding(content, match[0], match[1]))
+			}
+			detectionsWithSeverity = append(detectionsWithSeverity, DetectionsWithSeverity{detections: detected, severity: pattern.SeverityIn(severities), rule: pattern.Rule})
 		}
 	}
 	return detectionsWithSeverity
@@ -39,8 +43,8 @@ func (pm *PatternMatcher) add(ps talismanrc.PatternString) {
 		logrus.Warnf("ignoring invalid pattern '%s'", ps)
 		return
 	}
-	logrus.Infof("added custom pattern '%s' with high severity", ps)
-	pm.regexes = append(pm.regexes, &severity.PatternSeverity{Pattern: re, Severity: severity.SeverityConfiguration["CustomPattern"]})
+	logrus.Infof("added custom pattern '%s' with the CustomPattern severity", ps)
+	pm.regexes = append(pm.regexes, &severity.PatternSeverity{Pattern: re, Rule: "CustomPattern"})
 }
 
 func NewPatternMatcher(patterns []*severity.PatternSeverity) *PatternMatcher {
@@ -3,6 +3,7 @@ package pattern
 import (
 	"io/ioutil"
 	"regexp"
+	"talisman/detector/helpers"
 	"talisman/detector/severity"
 	"talisman/talismanrc"
 	"testing"
@@ -23,22 +24,22 @@ var (
 )
 
 func TestShouldReturnEmptyStringWhenDoesNotMatchAnyRegex(t *testing.T) {
-	detections := NewPatternMatcher([]*severity.PatternSeverity{{Pattern: testRegexpPassword, Severity: severity.Low}}).check("safeString", severity.Low)
+	detections := NewPatternMatcher([]*severity.PatternSeverity{{Pattern: testRegexpPassword, Severity: severity.Low}}).check("safeString", severity.DefaultConfiguration())
 	assert.Equal(t, []DetectionsWithSeverity(nil), detections)
 }
 
 func TestShouldReturnStringWhenMatchedPasswordPattern(t *testing.T) {
-	detections1 := NewPatternMatcher([]*severity.PatternSeverity{{Pattern: testRegexpPassword, Severity: severity.Low}}).check("password\" :  123456789", severity.Low)
-	detections2 := NewPatternMatcher([]*severity.PatternSeverity{{Pattern: testRegexpPw, Severity: severity.Medium}}).check("pw\"  :  123456789", severity.Low)
-	assert.Equal(t, []DetectionsWithSeverity{{detections: []string{"password\" :  123456789"}, severity: severity.Low}}, detections1)
-	assert.Equal(t, []DetectionsWithSeverity{{detections: []string{"pw\"  :  123456789"}, severity: severity.Medium}}, detections2)
+	detections1 := NewPatternMatcher([]*severity.PatternSeverity{{Pattern: testRegexpPassword, Severity: severity.Low}}).check("password\" :  123456789", severity.DefaultConfiguration())
+	detections2 := NewPatternMatcher([]*severity.PatternSeverity{{Pattern: testRegexpPw, Severity: severity.Medium}}).check("pw\"  :  123456789", severity.DefaultConfiguration())
+	assert.Equal(t, []DetectionsWithSeverity{{detections: []helpers.Finding{{Secret: "password\" :  123456789", Line: "password\" :  123456789", Start: 0, End: 22}}, severity: severity.Low}}, detections1)
+	assert.Equal(t, []DetectionsWithSeverity{{detections: []helpers.Finding{{Secret: "pw\"  :  123456789", Line: "pw\"  :  123456789", Start: 0, End: 17}}, severity: severity.Medium}}, detections2)
 }
 
 func TestShouldAddGoodPatternWithHighToMatcher(t *testing.T) {
 	pm := NewPatternMatcher([]*severity.PatternSeverity{})
 	pm.add(talismanrc.PatternString(testRegexpPwPattern))
-	detections := pm.check("pw\"  :  123456789", severity.Low)
-	assert.Equal(t, []DetectionsWithSeverity{{detections: []string{"pw\"  :  123456789"}, severity: severity.High}}, detections)
+	detections := pm.check("pw\"  :  123456789", severity.DefaultConfiguration())
+	assert.Equal(t, []DetectionsWithSeverity{{detections: []helpers.Finding{{Secret: "pw\"  :  123456789", Line: "pw\"  :  123456789", Start: 0, End: 17}}, severity: severity.High, rule: "CustomPattern"}}, detections)
 }
 
 func TestShouldNotAddBadPatternToMatcher(t *testing.T) {
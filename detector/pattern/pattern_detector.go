@@ -3,8 +3,10 @@ package pattern
 import (
 	"fmt"
 	"regexp"
-	"sync"
+	"strings"
 	"talisman/detector/helpers"
+	"talisman/detector/keyvalue"
+	"talisman/detector/placeholder"
 	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
@@ -18,15 +20,19 @@ type PatternDetector struct {
 
 var (
 	detectorPatterns = []*severity.PatternSeverity{
-		{Pattern: regexp.MustCompile(`(?i)((.*)(password|passphrase|secret|key|pwd|pword|pass)(.*) *[:=>,][^,;\n]{8,})`), Severity: severity.SeverityConfiguration["PasswordPhrasePattern"]},
-		{Pattern: regexp.MustCompile(`(?i)((:)(password|passphrase|secret|key|pwd|pword|pass)(.*) *[ ][^,;\n]{8,})`), Severity: severity.SeverityConfiguration["PasswordPhrasePattern"]},
-		{Pattern: regexp.MustCompile(`(?i)(['"_]?pw['"]? *[:=][^,;\n]{8,})`), Severity: severity.SeverityConfiguration["PasswordPhrasePattern"]},
-		{Pattern: regexp.MustCompile(`(?i)(<ConsumerKey>\S*</ConsumerKey>)`), Severity: severity.SeverityConfiguration["ConsumerKeyPattern"]},
-		{Pattern: regexp.MustCompile(`(?i)(<ConsumerSecret>\S*</ConsumerSecret>)`), Severity: severity.SeverityConfiguration["ConsumerSecretParrern"]},
-		{Pattern: regexp.MustCompile(`(?i)(AWS[ \w]+key[ \w]+[:=])`), Severity: severity.SeverityConfiguration["AWSKeyPattern"]},
-		{Pattern: regexp.MustCompile(`(?i)(AWS[ \w]+secret[ \w]+[:=])`), Severity: severity.SeverityConfiguration["AWSSecretPattern"]},
-		{Pattern: regexp.MustCompile(`(?s)(BEGIN RSA PRIVATE KEY.*END RSA PRIVATE KEY)`), Severity: severity.SeverityConfiguration["RSAKeyPattern"]},
+		{Pattern: regexp.MustCompile(`(?i)((.*)(password|passphrase|secret|key|pwd|pword|pass)(.*) *[:=>,][^,;\n]{8,})`), Rule: "PasswordPhrasePattern"},
+		{Pattern: regexp.MustCompile(`(?i)((:)(password|passphrase|secret|key|pwd|pword|pass)(.*) *[ ][^,;\n]{8,})`), Rule: "PasswordPhrasePattern"},
+		{Pattern: regexp.MustCompile(`(?i)(['"_]?pw['"]? *[:=][^,;\n]{8,})`), Rule: "PasswordPhrasePattern"},
+		{Pattern: regexp.MustCompile(`(?i)(<ConsumerKey>\S*</ConsumerKey>)`), Rule: "ConsumerKeyPattern"},
+		{Pattern: regexp.MustCompile(`(?i)(<ConsumerSecret>\S*</ConsumerSecret>)`), Rule: "ConsumerSecretParrern"},
+		{Pattern: regexp.MustCompile(`(?i)(AWS[ \w]+key[ \w]+[:=])`), Rule: "AWSKeyPattern"},
+		{Pattern: regexp.MustCompile(`(?i)(AWS[ \w]+secret[ \w]+[:=])`), Rule: "AWSSecretPattern"},
+		{Pattern: regexp.MustCompile(`(?s)(BEGIN RSA PRIVATE KEY.*END RSA PRIVATE KEY)`), Rule: "RSAKeyPattern"},
 	}
+	// phraseValuePattern captures the value of a password phrase, i.e. what follows the separator after the first secret word
+	phraseValuePattern = regexp.MustCompile(`(?i)(?:password|passphrase|secret|key|pwd|pword|pass|pw)[^:=>,]*[:=>,]+\s*(.*)$`)
+	// phraseKeyPattern captures the name of the key right before the separator of a password phrase, e.g. DB_PASSWORD in "export DB_PASSWORD="
+	phraseKeyPattern = regexp.MustCompile(`([\w.-]+)['"]?[ \t]*[:=>,]+[ \t]*$`)
 )
 
 type match struct {
@@ -34,78 +40,165 @@ type match struct {
 	path       gitrepo.FilePath
 	commits    []string
 	detections []DetectionsWithSeverity
+	allowed    []helpers.AllowedFinding
 }
 
 // Test tests the contents of the Additions to ensure that they don't look suspicious
 func (detector PatternDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
-	matches := make(chan match, 512)
-	ignoredFilePaths := make(chan gitrepo.FilePath, 512)
-	waitGroup := &sync.WaitGroup{}
-	waitGroup.Add(len(currentAdditions))
-	for _, addition := range currentAdditions {
-		go func(addition gitrepo.Addition) {
-			defer waitGroup.Done()
-			defer additionCompletionCallback()
-			if comparator.ShouldIgnore(addition, "filecontent") {
-				ignoredFilePaths <- addition.Path
-				return
+	severities := ignoreConfig.SeverityConfiguration()
+	placeholders := placeholder.NewClassifier(ignoreConfig.Placeholders)
+	helpers.CheckContents(comparator, currentAdditions, "pattern", result, additionCompletionCallback, func(addition gitrepo.Addition) func() {
+		detections := withoutPlaceholders(detector.secretsPattern.check(string(addition.Data), severities), addition, placeholders, result)
+		m := detector.withoutAllowedFindings(match{name: addition.Name, path: addition.Path, detections: detections, commits: addition.Commits}, addition, ignoreConfig)
+		return func() { detector.processMatch(m, result, ignoreConfig) }
+	})
+}
+
+// withoutAllowedFindings moves the findings covered by an allowed pattern from the .talismanrc out of the detections of a match
+func (detector PatternDetector) withoutAllowedFindings(m match, addition gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC) match {
+	var detections []DetectionsWithSeverity
+	for _, detectionWithSeverity := range m.detections {
+		var findings []helpers.Finding
+		for _, finding := range detectionWithSeverity.detections {
+			if pattern, allowed := ignoreConfig.AllowedPatternFor(addition, finding.Line, finding.Start, finding.End); allowed {
+				m.allowed = append(m.allowed, helpers.AllowedFinding{Secret: finding.Secret, Pattern: pattern})
+			} else {
+				findings = append(findings, finding)
 			}
-			detections := detector.secretsPattern.check(ignoreConfig.RemoveAllowedPatterns(addition), ignoreConfig.Threshold)
-			matches <- match{name: addition.Name, path: addition.Path, detections: detections, commits: addition.Commits}
-		}(addition)
+		}
+		if len(findings) > 0 {
+			detections = append(detections, DetectionsWithSeverity{detections: findings, severity: detectionWithSeverity.severity, rule: detectionWithSeverity.rule})
+		}
 	}
-	go func() {
-		waitGroup.Wait()
-		close(matches)
-		close(ignoredFilePaths)
-	}()
-	for ignoredChanHasMore, matchChanHasMore := true, true; ignoredChanHasMore || matchChanHasMore; {
-		select {
-		case match, hasMore := <-matches:
-			if !hasMore {
-				matchChanHasMore = false
-				continue
-			}
-			detector.processMatch(match, result, ignoreConfig.Threshold)
-		case ignore, hasMore := <-ignoredFilePaths:
-			if !hasMore {
-				ignoredChanHasMore = false
-				continue
+	m.detections = detections
+	return m
+}
+
+// withoutPlaceholders drops the password phrases whose value is a placeholder, e.g. password = os.getenv("PASSWORD") or pwd: "xxxxxxxx",
+// as well as those without any value, such as the encrypted values that are blanked out of encrypted files. In structured files, the values are those of the keys found within the phrase, otherwise they are what follows the first separator.
+// Phrases holding the literal value of a secret key of a structured file are dropped as well, as the keyvalue detector reports that value.
+func withoutPlaceholders(detections []DetectionsWithSeverity, addition gitrepo.Addition, placeholders *placeholder.Classifier, result *helpers.DetectionResults) []DetectionsWithSeverity {
+	var pairs []keyvalue.Pair
+	parsed := false
+	for index, detectionWithSeverity := range detections {
+		if detectionWithSeverity.rule != "PasswordPhrasePattern" {
+			continue
+		}
+		if !parsed {
+			pairs, _, _ = keyvalue.Parse(addition)
+			parsed = true
+		}
+		var findings []helpers.Finding
+		for _, finding := range detectionWithSeverity.detections {
+			pair := pairOfPhrase(finding, pairs)
+			value := valueOfPhrase(finding, pair)
+			if hasNoValue(finding) {
+				result.Explain(addition.Path, "pattern", "not flagging %s as it has no value", describedPhrase(finding))
+			} else if kind, isPlaceholder := placeholders.Classify(value); isPlaceholder {
+				result.Explain(addition.Path, "pattern", "not flagging %s as its value %s is a placeholder (%s)", describedPhrase(finding), result.Masked(value), kind)
+			} else if pair != nil && keyvalue.IsSecretKey(pair.Name()) && keyvalue.IsLiteral(pair.Value) {
+				result.Explain(addition.Path, "pattern", "not flagging the password phrase of key %q, as the keyvalue detector reports its value", pair.Key)
+			} else {
+				findings = append(findings, finding)
 			}
-			detector.processIgnore(ignore, result)
 		}
+		detections[index].detections = findings
 	}
+	return detections
+}
+
+// hasNoValue answers if nothing but whitespace follows the separator of a password phrase, e.g. "password:" followed by blanks
+func hasNoValue(finding helpers.Finding) bool {
+	match := phraseValuePattern.FindStringSubmatch(finding.Secret)
+	return match != nil && strings.TrimSpace(match[1]) == ""
 }
 
-func (detector PatternDetector) processIgnore(ignoredFilePath gitrepo.FilePath, result *helpers.DetectionResults) {
-	log.WithFields(log.Fields{
-		"filePath": ignoredFilePath,
-	}).Info("Ignoring addition as it was specified to be ignored.")
-	result.Ignore(ignoredFilePath, "filecontent")
+// pairOfPhrase returns the key-value pair of a structured file whose value is found within a password phrase, if any
+func pairOfPhrase(finding helpers.Finding, pairs []keyvalue.Pair) *keyvalue.Pair {
+	for index, pair := range pairs {
+		if pair.Finding.Line == finding.Line && pair.Finding.Start >= finding.Start && pair.Finding.End <= finding.End {
+			return &pairs[index]
+		}
+	}
+	return nil
 }
 
-func (detector PatternDetector) processMatch(match match, result *helpers.DetectionResults, threshold severity.Severity) {
+func valueOfPhrase(finding helpers.Finding, pair *keyvalue.Pair) string {
+	if pair != nil {
+		return pair.Value
+	}
+	if match := phraseValuePattern.FindStringSubmatch(finding.Secret); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func (detector PatternDetector) processMatch(match match, result *helpers.DetectionResults, ignoreConfig *talismanrc.TalismanRC) {
+	for _, allowed := range match.allowed {
+		result.IgnoreAllowedFinding(match.path, "filecontent", "pattern", allowed.Secret, allowed.Pattern)
+	}
 	for _, detectionWithSeverity := range match.detections {
-		for _, detection := range detectionWithSeverity.detections {
-			if detection != "" {
-				if string(match.name) == talismanrc.RCFileName || !detectionWithSeverity.severity.ExceedsThreshold(threshold) {
+		for _, finding := range detectionWithSeverity.detections {
+			if finding.Secret != "" {
+				detection := masked(finding, detectionWithSeverity.rule, result)
+				finding := fmt.Sprintf("matches secret pattern %q", detection)
+				findingSeverity, fails := detectionWithSeverity.severity, false
+				if string(match.name) == talismanrc.RCFileName {
+					result.Explain(match.path, "pattern", "%s: findings in %s are only reported as warnings", finding, talismanrc.RCFileName)
+				} else {
+					addition := gitrepo.Addition{Path: match.path, Name: match.name}
+					findingSeverity, fails = result.AssessFinding(ignoreConfig, addition, "pattern", finding, findingSeverity, ignoreConfig.Threshold)
+				}
+				if !fails {
 					log.WithFields(log.Fields{
 						"filePath": match.path,
 						"pattern":  detection,
 					}).Warn("Warning file as it matched pattern.")
-					result.Warn(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), match.commits, detectionWithSeverity.severity)
+					result.Warn(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), match.commits, findingSeverity)
 				} else {
 					log.WithFields(log.Fields{
 						"filePath": match.path,
 						"pattern":  detection,
 					}).Info("Failing file as it matched pattern.")
-					result.Fail(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), match.commits, detectionWithSeverity.severity)
+					result.Fail(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), match.commits, findingSeverity)
 				}
 			}
 		}
 	}
 }
 
+// masked returns a detection as it may be reported. Password phrases only keep the name of their key and its separator before their masked value,
+// e.g. password=hu...et (sha256:1a2b3c4d), as the rest of the line they match may hold secrets too. Other detections are masked as a whole.
+func masked(finding helpers.Finding, rule string, result *helpers.DetectionResults) string {
+	if rule == "PasswordPhrasePattern" && !result.RevealingSecrets() {
+		if key, value, found := keyAndValueOfPhrase(finding); found {
+			return key + result.Masked(value)
+		}
+	}
+	return result.Masked(finding.Secret)
+}
+
+// describedPhrase names a password phrase by its key, without any of the text it matches that may be secret
+func describedPhrase(finding helpers.Finding) string {
+	if key, _, found := keyAndValueOfPhrase(finding); found {
+		return fmt.Sprintf("the password phrase of key %q", phraseKeyPattern.FindStringSubmatch(key)[1])
+	}
+	return "a password phrase"
+}
+
+// keyAndValueOfPhrase splits a password phrase into the name of its key along with its separator, e.g. "password: ", and its value
+func keyAndValueOfPhrase(finding helpers.Finding) (string, string, bool) {
+	match := phraseValuePattern.FindStringSubmatchIndex(finding.Secret)
+	if match == nil {
+		return "", "", false
+	}
+	key := phraseKeyPattern.FindString(finding.Secret[:match[2]])
+	if key == "" {
+		return "", "", false
+	}
+	return key, finding.Secret[match[2]:match[3]], true
+}
+
 // NewPatternDetector returns a PatternDetector that tests Additions against the pre-configured patterns
 func NewPatternDetector(custom []talismanrc.PatternString) *PatternDetector {
 	matcher := NewPatternMatcher(detectorPatterns)
@@ -0,0 +1,95 @@
+package placeholder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"talisman/talismanrc"
+)
+
+// Built-in kinds of placeholders, as reported when explaining why a value was not flagged
+const (
+	EnvReference = "environment variable reference"
+	Template     = "template expression"
+	Dummy        = "dummy value"
+	Redaction    = "redaction marker"
+)
+
+type builtIn struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+var (
+	// builtIns are checked in order, so that e.g. Terraform's ${var.name} is a template expression rather than a variable reference
+	builtIns = []builtIn{
+		{EnvReference, regexp.MustCompile(`^\$\{?env:[A-Za-z_][A-Za-z0-9_]*\}?$`)},
+		// Helm, Jinja, Mustache and GitHub Actions expressions, Jinja statements, ERB tags and Terraform interpolations
+		{Template, regexp.MustCompile(`^\$?\{\{.*\}\}$`)},
+		{Template, regexp.MustCompile(`^\{%.*%\}$`)},
+		{Template, regexp.MustCompile(`^<%=?.*%>$`)},
+		{Template, regexp.MustCompile(`^[$#]\{[^}]*[^A-Za-z0-9_}][^}]*\}$`)},
+		// shell, Docker Compose and Windows variables, as well as environment lookups of common languages
+		{EnvReference, regexp.MustCompile(`^\$\{?[A-Za-z_][A-Za-z0-9_]*\}?$`)},
+		{EnvReference, regexp.MustCompile(`^%[A-Za-z_][A-Za-z0-9_]*%$`)},
+		// lookups without a default or fallback value only, as e.g. os.getenv("PASSWORD", "hunter2") holds a literal secret
+		{EnvReference, regexp.MustCompile(`^(os\.getenv|os\.environ\.get|getenv|System\.getenv|ENV\.fetch|Environment\.GetEnvironmentVariable|env)\s*\(\s*["']?[A-Za-z_][A-Za-z0-9_]*["']?\s*\)$`)},
+		{EnvReference, regexp.MustCompile(`^(os\.environ|process\.env|ENV)\s*\[\s*["'][A-Za-z_][A-Za-z0-9_]*["']\s*\]$`)},
+		{EnvReference, regexp.MustCompile(`^process\.env\.[A-Za-z_][A-Za-z0-9_]*$`)},
+		{Redaction, regexp.MustCompile(`(?i)^[\[<(]?(redacted|removed|hidden|masked|filtered|omitted|censored)[\]>)]?$`)},
+		{Redaction, regexp.MustCompile(`^\*{3,}$`)},
+		{Dummy, regexp.MustCompile(`(?i)^[<\[]?(change[-_ ]?(me|it)|dummy|example|sample|placeholder|todo|fixme|tbd|foo|bar|foobar|your[-_ ](password|passwd|pass|key|token|secret|api[-_ ]?key|access[-_ ]?key|secret[-_ ]?key)([-_ ]here)?|(insert|enter|replace)[-_ ][a-z_-]+)[>\]]?$`)},
+		{Dummy, regexp.MustCompile(`^<[A-Za-z0-9 _.-]+>$`)},
+		{Dummy, regexp.MustCompile(`^\.{3,}$`)},
+	}
+)
+
+// Classifier tells values that are placeholders, e.g. ${DB_PASSWORD}, {{ .Values.password }} or <changeme>, from actual secrets.
+// Besides the built-in placeholders, it recognises the values matching the placeholders of the .talismanrc.
+type Classifier struct {
+	custom []*talismanrc.Pattern
+}
+
+// NewClassifier returns a Classifier that recognises the given custom placeholders along with the built-in ones
+func NewClassifier(custom []*talismanrc.Pattern) *Classifier {
+	return &Classifier{custom}
+}
+
+// Classify answers if a value is a placeholder, along with the kind of placeholder it is.
+// Surrounding whitespace and quotes, as well as trailing commas and semicolons, are not part of the value.
+func (c *Classifier) Classify(value string) (string, bool) {
+	value = normalize(value)
+	if value == "" {
+		return "", false
+	}
+	for _, pattern := range c.custom {
+		if pattern.MatchString(value) {
+			return fmt.Sprintf("placeholder matching %q", pattern.String()), true
+		}
+	}
+	for _, placeholder := range builtIns {
+		if placeholder.pattern.MatchString(value) {
+			return placeholder.kind, true
+		}
+	}
+	if isRepeatedCharacter(value) {
+		return Dummy, true
+	}
+	return "", false
+}
+
+func normalize(value string) string {
+	value = strings.TrimRight(strings.TrimSpace(value), ",;")
+	for len(value) >= 2 && strings.ContainsRune("\"'`", rune(value[0])) && value[len(value)-1] == value[0] {
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+	return value
+}
+
+// isRepeatedCharacter answers if a value is a single character repeated, e.g. xxxxxxxx or 00000000
+func isRepeatedCharacter(value string) bool {
+	if len(value) < 4 {
+		return false
+	}
+	return strings.Count(value, value[:1]) == len(value)
+}
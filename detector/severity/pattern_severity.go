@@ -4,7 +4,21 @@ import (
 	"regexp"
 )
 
+// PatternSeverity is a pattern along with the severity of its matches.
+// Patterns naming a Rule take their severity from the Configuration of the run, others use the fixed Severity.
 type PatternSeverity struct {
 	Pattern  *regexp.Regexp
+	Rule     string
 	Severity Severity
 }
+
+// SeverityIn returns the severity of the matches of the pattern under the given Configuration
+func (ps *PatternSeverity) SeverityIn(configuration Configuration) Severity {
+	if ps.Rule == "" {
+		return ps.Severity
+	}
+	if severity, ok := configuration[ps.Rule]; ok {
+		return severity
+	}
+	return defaultSeverities[ps.Rule]
+}
@@ -6,9 +6,11 @@ import (
 )
 
 var severityMap = map[Severity]string{
-	Low:    "low",
-	Medium: "medium",
-	High:   "high",
+	Info:     "info",
+	Low:      "low",
+	Medium:   "medium",
+	High:     "high",
+	Critical: "critical",
 }
 
 func String(severity Severity) string {
@@ -31,7 +33,12 @@ func (s Severity) String() string {
 	return String(s)
 }
 
+// ExceedsThreshold answers if a finding of this severity fails a run with the given threshold.
+// An unset threshold defaults to Low, so that Info findings are only ever reported as warnings.
 func (s Severity) ExceedsThreshold(threshold Severity) bool {
+	if threshold == 0 {
+		threshold = Low
+	}
 	return s >= threshold
 }
 
@@ -64,7 +71,9 @@ func (s Severity) MarshalJSON() ([]byte, error) {
 }
 
 const (
-	Low = Severity(iota + 1)
+	Info = Severity(iota + 1)
+	Low
 	Medium
 	High
+	Critical
 )
@@ -1,59 +1,85 @@
 package severity
 
-var SeverityConfiguration = map[string]Severity{
-	"ConsumerKeyPattern":        High,
-	"ConsumerSecretParrern":     High,
-	"AWSKeyPattern":             High,
-	"AWSSecretPattern":          High,
-	"RSAKeyPattern":             High,
-	"DSAFile":                   High,
-	"PrivateKeyFile":            High,
-	"PemFile":                   High,
-	"PpkFile":                   High,
-	"SecretToken":               High,
-	"KeyPairFile":               High,
-	"CustomPattern":             High,
-	"PKCSFile":                  High,
-	"PFXFile":                   High,
-	"P12File":                   High,
-	"NetrcFile":                 High,
-	"RSAFile":                   High,
-	"KeyChainFile":              High,
-	"KeyStoreFile":              High,
-	"OauthTokenFile":            High,
-	"HTPASSWDFile":              High,
-	"TunnelBlockFile":           High,
-	"CredentialsXML":            High,
-	"JenkinsPublishOverSSHFile": High,
-	"Base64Content":             High,
-	"HexContent":                High,
-	"s3Config":                  Medium,
-	"OpenVPNFile":               Medium,
-	"DatabaseYml":               Medium,
-	"ShellHistory":              Low,
-	"ASCFile":                   Low,
-	"KDBFile":                   Low,
-	"AgileKeyChainFile":         Low,
-	"PubXML":                    Low,
-	"GitRobRC":                  Low,
-	"ShellRC":                   Low,
-	"CreditCardContent":         Low,
-	"ShellProfile":              Low,
-	"ShellAlias":                Low,
-	"OmniAuth":                  Low,
-	"CarrierWaveRB":             Low,
-	"SchemaRB":                  Low,
-	"PythonSettings":            Low,
-	"PhpConfig":                 Low,
-	"PhpLocalSettings":          Low,
-	"EnvFile":                   Low,
-	"BDumpFile":                 Low,
-	"BSQLFile":                  Low,
-	"PasswordFile":              Low,
-	"BackupFile":                Low,
-	"LogFile":                   Low,
-	"KWallet":                   Low,
-	"GNUCash":                   Low,
-	"PasswordPhrasePattern":     Low,
-	"LargeFileSize":             Low,
+// defaultSeverities holds the built-in severity of each rule. It is never modified: overrides are applied to copies of it.
+var defaultSeverities = map[string]Severity{
+	"ConsumerKeyPattern":          High,
+	"ConsumerSecretParrern":       High,
+	"AWSKeyPattern":               High,
+	"AWSSecretPattern":            High,
+	"RSAKeyPattern":               High,
+	"DSAFile":                     High,
+	"PrivateKeyFile":              High,
+	"PemFile":                     High,
+	"PpkFile":                     High,
+	"SecretToken":                 High,
+	"KeyPairFile":                 High,
+	"CustomPattern":               High,
+	"PKCSFile":                    High,
+	"PFXFile":                     High,
+	"P12File":                     High,
+	"NetrcFile":                   High,
+	"RSAFile":                     High,
+	"KeyChainFile":                High,
+	"KeyStoreFile":                High,
+	"OauthTokenFile":              High,
+	"HTPASSWDFile":                High,
+	"TunnelBlockFile":             High,
+	"CredentialsXML":              High,
+	"JenkinsPublishOverSSHFile":   High,
+	"Base64Content":               High,
+	"HexContent":                  High,
+	"ConnectionStringCredentials": High,
+	"s3Config":                    Medium,
+	"OpenVPNFile":                 Medium,
+	"DatabaseYml":                 Medium,
+	"SecretKeyValue":              Medium,
+	"ShellHistory":                Low,
+	"ASCFile":                     Low,
+	"KDBFile":                     Low,
+	"AgileKeyChainFile":           Low,
+	"PubXML":                      Low,
+	"GitRobRC":                    Low,
+	"ShellRC":                     Low,
+	"CreditCardContent":           Low,
+	"ShellProfile":                Low,
+	"ShellAlias":                  Low,
+	"OmniAuth":                    Low,
+	"CarrierWaveRB":               Low,
+	"SchemaRB":                    Low,
+	"PythonSettings":              Low,
+	"PhpConfig":                   Low,
+	"PhpLocalSettings":            Low,
+	"EnvFile":                     Low,
+	"BDumpFile":                   Low,
+	"BSQLFile":                    Low,
+	"PasswordFile":                Low,
+	"BackupFile":                  Low,
+	"LogFile":                     Low,
+	"KWallet":                     Low,
+	"GNUCash":                     Low,
+	"PasswordPhrasePattern":       Low,
+	"LargeFileSize":               Low,
+}
+
+// Configuration maps the name of each rule to the severity of its findings for a single run
+type Configuration map[string]Severity
+
+// DefaultConfiguration returns a new Configuration holding the built-in severity of each rule
+func DefaultConfiguration() Configuration {
+	configuration := Configuration{}
+	for rule, severity := range defaultSeverities {
+		configuration[rule] = severity
+	}
+	return configuration
+}
+
+// SeverityOf returns the severity configured for a rule, or the zero severity for unknown rules
+func (c Configuration) SeverityOf(rule string) Severity {
+	return c[rule]
+}
+
+// IsKnownRule answers if a rule has a built-in severity
+func IsKnownRule(rule string) bool {
+	_, known := defaultSeverities[rule]
+	return known
 }
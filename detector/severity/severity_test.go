@@ -10,6 +10,8 @@ func TestShouldReturnSeverityStringForDefinedSeverity(t *testing.T) {
 	assert.Equal(t, String(Low), "low")
 	assert.Equal(t, String(Medium), "medium")
 	assert.Equal(t, String(High), "high")
+	assert.Equal(t, String(Info), "info")
+	assert.Equal(t, String(Critical), "critical")
 }
 func TestShouldReturnEmptyForInvalidSeverity(t *testing.T) {
 	assert.Equal(t, String(10), "")
@@ -30,3 +32,35 @@ func TestShouldReturnSeverityZeroWithErrorForUnknownStrings(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "unknown severity FakeSeverity", err.Error())
 }
+
+func TestDefaultConfigurationsAreIndependent(t *testing.T) {
+	configuration := DefaultConfiguration()
+	configuration["Base64Content"] = Low
+
+	assert.Equal(t, Low, configuration.SeverityOf("Base64Content"))
+	assert.Equal(t, High, DefaultConfiguration().SeverityOf("Base64Content"))
+}
+
+func TestPatternSeverityResolvesRulesFromConfiguration(t *testing.T) {
+	configuration := DefaultConfiguration()
+	configuration["PemFile"] = Medium
+
+	assert.Equal(t, Medium, (&PatternSeverity{Rule: "PemFile"}).SeverityIn(configuration))
+	assert.Equal(t, High, (&PatternSeverity{Rule: "PemFile"}).SeverityIn(nil))
+	assert.Equal(t, Low, (&PatternSeverity{Severity: Low}).SeverityIn(configuration))
+}
+
+func TestSeveritiesAreOrdered(t *testing.T) {
+	assert.True(t, Info < Low && Low < Medium && Medium < High && High < Critical)
+	severityValue, _ := FromString("Critical")
+	assert.Equal(t, Critical, severityValue)
+}
+
+func TestExceedingThreshold(t *testing.T) {
+	assert.True(t, Critical.ExceedsThreshold(High))
+	assert.True(t, High.ExceedsThreshold(High))
+	assert.False(t, Medium.ExceedsThreshold(High))
+	assert.True(t, Low.ExceedsThreshold(0), "An unset threshold should default to low")
+	assert.False(t, Info.ExceedsThreshold(0), "Info findings should only warn by default")
+	assert.True(t, Info.ExceedsThreshold(Info))
+}
@@ -56,11 +56,17 @@ func (git *GitTesting) Clean() {
 func (git *GitTesting) SetupBaselineFiles(filenames ...string) {
 	Logger.Debugf("Creating %v in %s\n", filenames, git.root)
 	for _, filename := range filenames {
-		git.CreateFileWithContents(filename, lorem.Sentence(8, 10), lorem.Sentence(8, 10))
+		git.CreateFileWithContents(filename, baselineSentence(), baselineSentence())
 	}
 	git.AddAndcommit("*", "initial commit")
 }
 
+// baselineSentence returns a random sentence without the separators the password phrase patterns look for,
+// as Latin words like "secreta" would otherwise make baseline files fail the detectors at random
+func baselineSentence() string {
+	return strings.NewReplacer(",", "", ":", "", "=", "", ">", "").Replace(lorem.Sentence(8, 10))
+}
+
 func (git *GitTesting) EarliestCommit() string {
 	return git.execCommand("git", "rev-list", "--max-parents=0", "HEAD")
 }
@@ -133,10 +139,24 @@ func (git *GitTesting) Add(fileName string) {
 	git.execCommand("git", "add", fileName)
 }
 
+// Move renames a file and stages the rename
+func (git *GitTesting) Move(from string, to string) {
+	git.execCommand("git", "mv", from, to)
+}
+
 func (git *GitTesting) Commit(fileName string, message string) {
 	git.execCommand("git", "commit", "-m", message)
 }
 
+// Tag creates an annotated tag on HEAD, or a lightweight tag if the message is empty
+func (git *GitTesting) Tag(name string, message string) {
+	if message == "" {
+		git.execCommand("git", "tag", name)
+		return
+	}
+	git.execCommand("git", "tag", "-a", name, "-m", message)
+}
+
 // GetBlobDetails returns git blob details for a path
 func (git *GitTesting) GetBlobDetails(fileName string) string {
 	var output []byte
@@ -0,0 +1,32 @@
+package gitrepo
+
+import (
+	"path"
+	"strings"
+)
+
+// ArchiveEntrySeparator separates the path of an archive from the path of an entry within it, as in lib.jar!/config/app.properties
+const ArchiveEntrySeparator = "!/"
+
+// NewArchiveEntryAddition returns an Addition for an entry of the archive held by container, whose path is nested in the path of the archive
+func NewArchiveEntryAddition(container Addition, entry string, data []byte) Addition {
+	return Addition{
+		Path:    FilePath(string(container.Path) + ArchiveEntrySeparator + entry),
+		Name:    FileName(path.Base(entry)),
+		Commits: container.Commits,
+		Data:    data,
+	}
+}
+
+// IsArchiveEntry answers if an Addition holds an entry of an archive, rather than a file of the repository
+func (a Addition) IsArchiveEntry() bool {
+	return strings.Contains(string(a.Path), ArchiveEntrySeparator)
+}
+
+// ContainerOf returns the path of the file of the repository holding an archive entry, or the path itself for other paths
+func ContainerOf(filePath FilePath) FilePath {
+	if index := strings.Index(string(filePath), ArchiveEntrySeparator); index >= 0 {
+		return filePath[:index]
+	}
+	return filePath
+}
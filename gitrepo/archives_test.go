@@ -0,0 +1,22 @@
+package gitrepo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveEntryAdditionsAreNestedInTheirArchive(t *testing.T) {
+	war := NewAddition("dist/app.war", []byte("PK"))
+	war.Commits = []string{"abc"}
+	jar := NewArchiveEntryAddition(war, "WEB-INF/lib/db.jar", []byte("PK"))
+	entry := NewArchiveEntryAddition(jar, "config/db.properties", []byte("password=hunter2"))
+
+	assert.Equal(t, FilePath("dist/app.war!/WEB-INF/lib/db.jar!/config/db.properties"), entry.Path)
+	assert.Equal(t, FileName("db.properties"), entry.Name)
+	assert.Equal(t, []string{"abc"}, entry.Commits)
+	assert.True(t, entry.IsArchiveEntry())
+	assert.False(t, war.IsArchiveEntry())
+	assert.Equal(t, FilePath("dist/app.war"), ContainerOf(entry.Path))
+	assert.Equal(t, FilePath("dist/app.war"), ContainerOf(war.Path))
+}
@@ -0,0 +1,171 @@
+package gitrepo
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	log "github.com/sirupsen/logrus"
+)
+
+// AttributesFileName is the name of the files holding the git attributes of the files of their directory
+const AttributesFileName = ".gitattributes"
+
+// infoAttributesPath keys the rules of $GIT_DIR/info/attributes, which take precedence over any .gitattributes file
+const infoAttributesPath = FilePath("$GIT_DIR/info/attributes")
+
+// Attribute values that are not strings
+const (
+	AttributeSet   = "set"
+	AttributeUnset = "unset"
+)
+
+// Attributes holds the rules of the .gitattributes files of a repository, keyed by the path of their file
+type Attributes map[FilePath][]attributeRule
+
+type attributeRule struct {
+	pattern    string
+	attributes map[string]string
+}
+
+// ParseAttributes reads the rules of a .gitattributes file. Macro definitions are not supported and are skipped.
+func ParseAttributes(content []byte) []attributeRule {
+	var rules []attributeRule
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+		rule := attributeRule{pattern: fields[0], attributes: map[string]string{}}
+		for _, attribute := range fields[1:] {
+			switch {
+			case strings.HasPrefix(attribute, "-"):
+				rule.attributes[attribute[1:]] = AttributeUnset
+			case strings.HasPrefix(attribute, "!"):
+				rule.attributes[attribute[1:]] = ""
+			case strings.Contains(attribute, "="):
+				nameAndValue := strings.SplitN(attribute, "=", 2)
+				rule.attributes[nameAndValue[0]] = nameAndValue[1]
+			default:
+				rule.attributes[attribute] = AttributeSet
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Of returns the attributes of a file, e.g. {"binary": "set", "diff": "unset", "filter": "lfs"}.
+// As in git, rules of deeper .gitattributes files override those of their parent directories, and later lines override earlier ones.
+func (a Attributes) Of(filePath FilePath) map[string]string {
+	var files []FilePath
+	for file := range a {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i] == infoAttributesPath || files[j] == infoAttributesPath {
+			return files[j] == infoAttributesPath && files[i] != infoAttributesPath
+		}
+		return strings.Count(string(files[i]), "/") < strings.Count(string(files[j]), "/")
+	})
+	attributes := map[string]string{}
+	for _, file := range files {
+		directory := ""
+		if file != infoAttributesPath {
+			directory = path.Dir(string(file))
+		}
+		for _, rule := range a[file] {
+			if !rule.matches(directory, string(filePath)) {
+				continue
+			}
+			for name, value := range rule.attributes {
+				if value == "" {
+					delete(attributes, name)
+				} else {
+					attributes[name] = value
+				}
+			}
+		}
+	}
+	return attributes
+}
+
+// matches answers if the rule of a .gitattributes file in directory applies to a file.
+// Patterns without a slash match the name of files at any depth, others match paths relative to the directory.
+func (rule attributeRule) matches(directory string, filePath string) bool {
+	relativePath := filePath
+	if directory != "" && directory != "." {
+		if !strings.HasPrefix(filePath, directory+"/") {
+			return false
+		}
+		relativePath = strings.TrimPrefix(filePath, directory+"/")
+	}
+	pattern := rule.pattern
+	if strings.HasSuffix(pattern, "/") {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(relativePath))
+		return matched
+	}
+	matched, _ := doublestar.Match(strings.TrimPrefix(pattern, "/"), relativePath)
+	return matched
+}
+
+// Attributes returns the rules of the .gitattributes files of the working tree, along with those of $GIT_DIR/info/attributes.
+// The .gitattributes files of a bare repository are read at HEAD.
+func (repo GitRepo) Attributes() Attributes {
+	if repo.bare {
+		return repo.AttributesAtRef("HEAD")
+	}
+	attributes := Attributes{}
+	output, err := repo.executeRepoCommand("git", "ls-files", "-z")
+	if err != nil {
+		log.Debugf("unable to list the .gitattributes files of the working tree: %v", err)
+		return attributes
+	}
+	for _, file := range strings.Split(string(output), "\x00") {
+		if path.Base(file) != AttributesFileName {
+			continue
+		}
+		if content, err := os.ReadFile(filepath.Join(repo.root, file)); err == nil {
+			attributes[FilePath(file)] = ParseAttributes(content)
+		}
+	}
+	repo.addInfoAttributes(attributes)
+	return attributes
+}
+
+// AttributesAtRef returns the rules of the .gitattributes files as they are in the tree of the given git ref,
+// along with those of $GIT_DIR/info/attributes
+func (repo GitRepo) AttributesAtRef(ref string) Attributes {
+	attributes := Attributes{}
+	output, err := repo.executeRepoCommand("git", "ls-tree", "-r", "-z", "--name-only", ref)
+	if err != nil {
+		log.Debugf("unable to list the .gitattributes files of %s: %v", ref, err)
+		return attributes
+	}
+	for _, file := range strings.Split(string(output), "\x00") {
+		if path.Base(file) != AttributesFileName {
+			continue
+		}
+		if content, err := repo.ReadFileAtRef(ref, file); err == nil {
+			attributes[FilePath(file)] = ParseAttributes(content)
+		}
+	}
+	repo.addInfoAttributes(attributes)
+	return attributes
+}
+
+func (repo GitRepo) addInfoAttributes(attributes Attributes) {
+	gitDir := repo.gitDir
+	if gitDir == "" {
+		gitDir = filepath.Join(repo.root, ".git")
+	}
+	if content, err := os.ReadFile(filepath.Join(gitDir, "info", "attributes")); err == nil {
+		attributes[infoAttributesPath] = ParseAttributes(content)
+	}
+}
@@ -0,0 +1,56 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAttributes(t *testing.T) {
+	rules := ParseAttributes([]byte("# comment\n[attr]blob -diff -merge\n*.png binary\n*.bin -diff !text eol=lf\n\n*.psd filter=lfs diff=lfs merge=lfs -text\n"))
+
+	if assert.Len(t, rules, 3) {
+		assert.Equal(t, attributeRule{pattern: "*.png", attributes: map[string]string{"binary": AttributeSet}}, rules[0])
+		assert.Equal(t, attributeRule{pattern: "*.bin", attributes: map[string]string{"diff": AttributeUnset, "text": "", "eol": "lf"}}, rules[1])
+		assert.Equal(t, "lfs", rules[2].attributes["filter"])
+	}
+}
+
+func TestAttributesOfFilesFollowPrecedenceOfGit(t *testing.T) {
+	attributes := Attributes{
+		".gitattributes":        ParseAttributes([]byte("*.dat binary\n/docs/*.txt -diff\n")),
+		"vendor/.gitattributes": ParseAttributes([]byte("*.dat !binary\n")),
+		infoAttributesPath:      ParseAttributes([]byte("secret.dat filter=lfs\n")),
+	}
+
+	assert.Equal(t, map[string]string{"binary": AttributeSet}, attributes.Of("a/b/c.dat"))
+	assert.Equal(t, map[string]string{}, attributes.Of("vendor/lib/c.dat"), "Expected the deeper .gitattributes to override the root one")
+	assert.Equal(t, map[string]string{"diff": AttributeUnset}, attributes.Of("docs/notes.txt"))
+	assert.Equal(t, map[string]string{}, attributes.Of("src/docs/notes.txt"), "Expected patterns with a slash to be anchored to their directory")
+	assert.Equal(t, map[string]string{"filter": "lfs"}, attributes.Of("vendor/secret.dat"))
+}
+
+func TestAttributesOfRepository(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		git.CreateFileWithContents(".gitattributes", "*.png binary")
+		git.CreateFileWithContents("assets/.gitattributes", "*.svg -diff")
+		git.AddAndcommit("*", "add attributes")
+		assert.NoError(t, os.WriteFile(filepath.Join(git.Root(), ".git", "info", "attributes"), []byte("*.key filter=lfs\n"), 0644))
+		repo := RepoLocatedAt(git.Root())
+
+		attributes := repo.Attributes()
+		assert.Equal(t, map[string]string{"binary": AttributeSet}, attributes.Of("logo.png"))
+		assert.Equal(t, map[string]string{"diff": AttributeUnset}, attributes.Of("assets/logo.svg"))
+		assert.Equal(t, map[string]string{"filter": "lfs"}, attributes.Of("id.key"))
+
+		git.OverwriteFileContent(".gitattributes", "*.png -diff")
+		git.AddAndcommit(".gitattributes", "change attributes")
+		assert.Equal(t, map[string]string{"binary": AttributeSet}, repo.AttributesAtRef("HEAD~1").Of("logo.png"))
+		assert.Equal(t, map[string]string{"diff": AttributeUnset}, repo.AttributesAtRef("HEAD").Of("logo.png"))
+	})
+}
@@ -29,50 +29,66 @@ func (bgor *BatchGitObjectReader) Start() error {
 	return bgor.cmd.Start()
 }
 
+// Shutdown stops the git subprocess, if it was started. It is safe to call more than once.
 func (bgor *BatchGitObjectReader) Shutdown() error {
-	return bgor.cmd.Process.Kill()
+	if bgor.cmd.Process == nil || bgor.cmd.ProcessState != nil {
+		return nil
+	}
+	if err := bgor.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	_ = bgor.cmd.Wait()
+	return nil
 }
 
 func (bgor *BatchGitObjectReader) Read(expr string) ([]byte, error) {
 	return bgor.read(expr)
 }
 
-func newBatchGitObjectReader(root string) *BatchGitObjectReader {
-	repo := &GitRepo{root}
+func newBatchGitObjectReader(repo GitRepo) (*BatchGitObjectReader, error) {
 	cmd := repo.makeRepoCommand("git", "cat-file", "--batch=%(objectsize)")
 	inputPipe, err := cmd.StdinPipe()
 	if err != nil {
-		logrus.Fatalf("error creating stdin pipe for batch git file reader subprocess: %v", err)
+		return nil, fmt.Errorf("error creating stdin pipe for batch git file reader subprocess: %v", err)
 	}
 	outputPipe, err := cmd.StdoutPipe()
 	if err != nil {
-		logrus.Fatalf("error creating stdout pipe for batch git file reader subprocess: %v", err)
+		return nil, fmt.Errorf("error creating stdout pipe for batch git file reader subprocess: %v", err)
 	}
 	batchReader := BatchGitObjectReader{
-		repo:         repo,
+		repo:         &repo,
 		cmd:          cmd,
 		inputWriter:  bufio.NewWriter(inputPipe),
 		outputReader: bufio.NewReader(outputPipe),
 	}
-	return &batchReader
+	return &batchReader, nil
 }
 
-func NewBatchGitHeadPathReader(root string) BatchReader {
-	bgor := newBatchGitObjectReader(root)
+func NewBatchGitHeadPathReader(repo GitRepo) (BatchReader, error) {
+	bgor, err := newBatchGitObjectReader(repo)
+	if err != nil {
+		return nil, err
+	}
 	bgor.read = bgor.makePathReader(GIT_HEAD_PREFIX)
-	return bgor
+	return bgor, nil
 }
 
-func NewBatchGitStagedPathReader(root string) BatchReader {
-	bgor := newBatchGitObjectReader(root)
+func NewBatchGitStagedPathReader(repo GitRepo) (BatchReader, error) {
+	bgor, err := newBatchGitObjectReader(repo)
+	if err != nil {
+		return nil, err
+	}
 	bgor.read = bgor.makePathReader(GIT_STAGED_PREFIX)
-	return bgor
+	return bgor, nil
 }
 
-func NewBatchGitObjectHashReader(root string) BatchReader {
-	bgor := newBatchGitObjectReader(root)
+func NewBatchGitObjectHashReader(repo GitRepo) (BatchReader, error) {
+	bgor, err := newBatchGitObjectReader(repo)
+	if err != nil {
+		return nil, err
+	}
 	bgor.read = bgor.makeObjectHashReader()
-	return bgor
+	return bgor, nil
 }
 
 type gitCatFileReadResult struct {
@@ -6,9 +6,9 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/bmatcuk/doublestar"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -28,102 +28,126 @@ type Addition struct {
 
 // GitRepo represents a Git repository located at the absolute path represented by root
 type GitRepo struct {
-	root string
+	root   string
+	gitDir string
+	bare   bool
 }
 
 // RepoLocatedAt returns a new GitRepo with it's root located at the location specified by the argument.
 // If the argument is not an absolute path, it will be turned into one.
 func RepoLocatedAt(path string) GitRepo {
 	absoluteRoot, _ := filepath.Abs(path)
-	return GitRepo{absoluteRoot}
+	return GitRepo{root: absoluteRoot}
 }
 
+// Discover returns the GitRepo that contains the given path, which may be any directory of a working tree,
+// a linked worktree or a bare repository. Like git itself, it honours the GIT_DIR and GIT_WORK_TREE environment variables.
+// The root of a bare repository is its git dir.
+func Discover(path string) (GitRepo, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return GitRepo{}, err
+	}
+	probe := GitRepo{root: absolutePath}
+	output, err := probe.revParse("--absolute-git-dir", "--is-bare-repository")
+	if err != nil {
+		return GitRepo{}, fmt.Errorf("%s is not inside a git repository: %v", absolutePath, err)
+	}
+	gitDirAndBareness := strings.Split(output, "\n")
+	if len(gitDirAndBareness) != 2 {
+		return GitRepo{}, fmt.Errorf("unexpected output of git rev-parse: %q", output)
+	}
+	gitDir := gitDirAndBareness[0]
+	if gitDirAndBareness[1] == "true" {
+		return GitRepo{root: gitDir, gitDir: gitDir, bare: true}, nil
+	}
+	root, err := probe.revParse("--show-toplevel")
+	if err != nil {
+		return GitRepo{}, fmt.Errorf("unable to determine the working tree of %s: %v", gitDir, err)
+	}
+	return GitRepo{root: root, gitDir: gitDir}, nil
+}
+
+func (repo GitRepo) revParse(args ...string) (string, error) {
+	output, err := repo.makeRepoCommand("git", append([]string{"rev-parse"}, args...)...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return strings.TrimSpace(string(output)), err
+}
+
+// Root returns the absolute path of the working tree of the GitRepo, or of its git dir if it is bare
 func (repo GitRepo) Root() string {
 	return repo.root
 }
 
-// GetDiffForStagedFiles gets all the staged files and collects the diff section in each file
-func (repo GitRepo) GetDiffForStagedFiles() []Addition {
-	stagedContent := repo.executeRepoCommand("git", "diff", "--staged", "--src-prefix=a/", "--dst-prefix=b/")
-	content := strings.TrimSpace(string(stagedContent))
-	lines := strings.Split(content, "\n")
-	result := make([]Addition, 0)
+// GitDir returns the absolute path of the git dir of a discovered GitRepo, or "" if it was not discovered
+func (repo GitRepo) GitDir() string {
+	return repo.gitDir
+}
 
-	if len(lines) < 1 {
-		return result
-	}
+// IsBare answers if the GitRepo has no working tree
+func (repo GitRepo) IsBare() bool {
+	return repo.bare
+}
 
-	// Standard git diff header pattern
-	// ref: https://git-scm.com/docs/diff-format#_generating_patches_with_p
+// GitCommand returns a git command that runs against the GitRepo, wherever the current process is located
+func (repo GitRepo) GitCommand(args ...string) *exec.Cmd {
+	return repo.makeRepoCommand("git", args...)
+}
 
-	lineNumberOfFirstHeader := 0
-	var additionFilename string
-	for ; lineNumberOfFirstHeader < len(lines); lineNumberOfFirstHeader++ {
-		match, stagedFilename := MatchGitDiffLine(lines[lineNumberOfFirstHeader])
-		if match {
-			additionFilename = stagedFilename
-			break
-		}
+// GetDiffForStagedFiles gets all the staged files and collects the lines they add.
+// Renamed and copied files only contribute the lines they add under their new path, files that only change their mode
+// contribute nothing, and binary files contribute their entire staged contents.
+func (repo GitRepo) GetDiffForStagedFiles() ([]Addition, error) {
+	diffOptions := []string{"diff", "--staged", "--find-renames", "--no-color", "--no-ext-diff", "--no-textconv"}
+	rawDiff, err := repo.executeRepoCommand("git", append(diffOptions, "--raw", "-z", "--no-abbrev")...)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseRawDiff(rawDiff)
+	if err != nil {
+		return nil, err
 	}
+	patch, err := repo.executeRepoCommand("git", append(diffOptions, "--patch", "--src-prefix=a/", "--dst-prefix=b/")...)
+	if err != nil {
+		return nil, err
+	}
+	sections := parsePatch(string(patch))
 
-	additionContentBuffer := &strings.Builder{}
-	for i := lineNumberOfFirstHeader + 1; i < len(lines); i++ {
-		match, stagedFilename := MatchGitDiffLine(lines[i])
-		if match {
-			// It is a new diff header
-			// which means we have reached the next file's header
-
-			// capture content written to buffer so far as addition content
-			stagedChanges := repo.extractAdditions(additionContentBuffer.String())
-			if stagedChanges != nil {
-				addition := NewAddition(additionFilename, stagedChanges)
-				result = append(
-					result, addition,
-				)
+	result := make([]Addition, 0)
+	for _, entry := range entries {
+		section := sections[entry.newPath]
+		if entry.isDeletion() || section == nil {
+			continue
+		}
+		if section.binary {
+			contents, err := repo.executeRepoCommand("git", "cat-file", "blob", entry.newObject)
+			if err != nil {
+				return nil, err
 			}
-
-			// get next file name and reset buffer for next iteration
-			additionFilename = stagedFilename
-			additionContentBuffer.Reset()
-		} else {
-			additionContentBuffer.WriteString(lines[i])
-			additionContentBuffer.WriteRune('\n')
+			result = append(result, NewAddition(entry.newPath, contents))
+			continue
+		}
+		// Renamed and copied files are checked under their new name, even if their content did not change
+		if len(section.added) > 0 || entry.isRenameOrCopy() {
+			result = append(result, NewAddition(entry.newPath, section.added))
 		}
-	}
-
-	// Save last file's diff content
-	stagedChanges := repo.extractAdditions(additionContentBuffer.String())
-	if stagedChanges != nil {
-		addition := NewAddition(additionFilename, stagedChanges)
-		result = append(result, addition)
 	}
 
 	log.WithFields(log.Fields{
 		"additions": result,
 	}).Debug("Generating staged additions.")
 
-	return result
-}
-
-func MatchGitDiffLine(gitDiffString string) (bool, string) {
-	if strings.Contains(gitDiffString, "diff --git") {
-		fileNameLength := (len(gitDiffString) - len("diff --git a/ b/")) / 2
-		regexPattern := fmt.Sprintf("^diff --git a/(.{%v}) b/(.{%v})$", fileNameLength, fileNameLength)
-		headerRegex := regexp.MustCompile(regexPattern)
-
-		if headerRegex.MatchString(gitDiffString) {
-			matches := headerRegex.FindStringSubmatch(gitDiffString)
-			if matches[1] == matches[2] {
-				return true, matches[1]
-			}
-		}
-	}
-	return false, ""
+	return result, nil
 }
 
 // StagedAdditions returns the files staged for commit in a GitRepo
-func (repo GitRepo) StagedAdditions() []Addition {
-	files := repo.stagedFiles()
+func (repo GitRepo) StagedAdditions() ([]Addition, error) {
+	files, err := repo.stagedFiles()
+	if err != nil {
+		return nil, err
+	}
 	result := make([]Addition, len(files))
 	for i, file := range files {
 		data, _ := repo.readRepoFile(file, GIT_STAGED_PREFIX)
@@ -133,15 +157,19 @@ func (repo GitRepo) StagedAdditions() []Addition {
 	log.WithFields(log.Fields{
 		"additions": result,
 	}).Info("Generating staged additions.")
-	return result
+	return result, nil
 }
 
-// AdditionsWithinRange returns the outgoing additions and modifications in a GitRepo that are in the given commit range. This does not include files that were deleted.
-func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) []Addition {
-	files := repo.outgoingNonDeletedFiles(oldCommit, newCommit)
+// AdditionsWithinRange returns the outgoing additions and modifications in a GitRepo that are in the given commit range, with their contents as of newCommit.
+// This does not include files that were deleted.
+func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) ([]Addition, error) {
+	files, err := repo.outgoingNonDeletedFiles(oldCommit, newCommit)
+	if err != nil {
+		return nil, err
+	}
 	result := make([]Addition, len(files))
 	for i, file := range files {
-		data, _ := repo.readRepoFile(file, GIT_HEAD_PREFIX)
+		data, _ := repo.readRepoFile(file, newCommit)
 		result[i] = NewAddition(file, data)
 	}
 	log.WithFields(log.Fields{
@@ -149,7 +177,90 @@ func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) []A
 		"newCommit": newCommit,
 		"additions": result,
 	}).Info("Generating all additions in range.")
-	return result
+	return result, nil
+}
+
+// AdditionsNotOnRemotes returns the files added, modified or renamed by the commits reachable from newCommit, but not from any remote-tracking ref,
+// with their contents as of newCommit. The changes of merge commits are those against each of their parents.
+// Files that no longer exist in newCommit are left out.
+func (repo GitRepo) AdditionsNotOnRemotes(newCommit string) ([]Addition, error) {
+	return repo.additionsNotOn(newCommit, "--remotes")
+}
+
+// AdditionsNotOnAnyRef returns the files added, modified or renamed by the commits reachable from newCommit, but not from any ref of the repository,
+// with their contents as of newCommit. On a server, these are the changes of a new ref that the server did not have yet.
+func (repo GitRepo) AdditionsNotOnAnyRef(newCommit string) ([]Addition, error) {
+	return repo.additionsNotOn(newCommit, "--all")
+}
+
+// additionsNotOn returns the files added or modified by the commits reachable from newCommit, but not from the refs selected by refsOption
+func (repo GitRepo) additionsNotOn(newCommit string, refsOption string) ([]Addition, error) {
+	output, err := repo.executeRepoCommand("git", "log", "-z", "-m", "--format=", "--name-only", "--diff-filter=ACMR", newCommit, "--not", refsOption)
+	if err != nil {
+		return nil, err
+	}
+	var result []Addition
+	seen := make(map[string]bool)
+	for _, file := range strings.Split(string(output), "\x00") {
+		file = strings.TrimPrefix(file, "\n")
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		data, err := repo.readRepoFile(file, newCommit)
+		if err != nil {
+			continue
+		}
+		result = append(result, NewAddition(file, data))
+	}
+	log.WithFields(log.Fields{
+		"newCommit": newCommit,
+		"excluded":  refsOption,
+		"additions": result,
+	}).Info("Generating additions not on the excluded refs.")
+	return result, nil
+}
+
+// CommitsNotOnRemotes returns the commits reachable from newCommit, but not from any remote-tracking ref
+func (repo GitRepo) CommitsNotOnRemotes(newCommit string) ([]string, error) {
+	return repo.commitsNotOn(newCommit, "--remotes")
+}
+
+// CommitsNotOnAnyRef returns the commits reachable from newCommit, but not from any ref of the repository
+func (repo GitRepo) CommitsNotOnAnyRef(newCommit string) ([]string, error) {
+	return repo.commitsNotOn(newCommit, "--all")
+}
+
+func (repo GitRepo) commitsNotOn(newCommit string, refsOption string) ([]string, error) {
+	output, err := repo.executeRepoCommand("git", "rev-list", newCommit, "--not", refsOption)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// HasRemoteHistory answers if there is any remote-tracking ref, i.e. if anything is known about the history of the remotes
+func (repo GitRepo) HasRemoteHistory() (bool, error) {
+	output, err := repo.executeRepoCommand("git", "for-each-ref", "--count=1", "--format=%(refname)", "refs/remotes")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// ReadFileAtRef returns the contents of the file at the given path, as it is in the given git ref
+func (repo GitRepo) ReadFileAtRef(ref, fileName string) ([]byte, error) {
+	contents, err := repo.readRepoFile(fileName, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(contents)))
+	}
+	return contents, nil
+}
+
+// FileExistsAtRef answers if a file with the given path is present in the tree of the given git ref
+func (repo GitRepo) FileExistsAtRef(ref, fileName string) bool {
+	_, err := repo.rawExecuteRepoCommand("git", "cat-file", "-e", fmt.Sprintf("%s:%s", ref, fileName))
+	return err == nil
 }
 
 // NewAddition returns a new Addition for a file with supplied name and contents
@@ -183,10 +294,15 @@ func (repo GitRepo) CheckIfFileExists(fileName string) bool {
 }
 
 // Matches reports whether the addition matches the given pattern.
+// Patterns follow the gitignore syntax, except for patterns without any special characters (see below).
 //
 // If the pattern ends in a path separator, then all files inside a directory with that name are matched.
 // However, files with that name itself will not be matched.
 //
+// If the pattern starts with a path separator, it is anchored to the root of the repository.
+//
+// A "**" component matches any number of directories, e.g. "**/*.pem", "test/fixtures/**" or "a/**/b".
+//
 // If a pattern contains the path separator in any other location,
 // the match works according to the pattern logic of the default golang glob mechanism.
 //
@@ -195,17 +311,10 @@ func (repo GitRepo) CheckIfFileExists(fileName string) bool {
 //
 // If there are no special characters in the pattern, then it means exact filename is provided as pattern like file.txt.
 // Thus, the pattern is matched against the file path so that not all files with the same name in the repo are not returned.
+//
+// Patterns starting with "!" are negations. They never match on their own, see MatchesAny.
 func (a Addition) Matches(pattern string) bool {
-	var result bool
-	if pattern[len(pattern)-1] == '/' { // If the pattern ends in a path separator, then all files inside a directory with that name are matched. However, files with that name itself will not be matched.
-		result = strings.HasPrefix(string(a.Path), pattern)
-	} else if strings.ContainsRune(pattern, '/') { // If a pattern contains the path separator in any other location, the match works according to the pattern logic of the default golang glob mechanism
-		result, _ = path.Match(pattern, string(a.Path))
-	} else if strings.ContainsAny(pattern, "*?[]\\") { // If there are other special characters in the pattern, the pattern is matched against the base name of the file. Thus, the pattern will match files with that pattern anywhere in the repository.
-		result = a.NameMatches(pattern)
-	} else { // If there are no special characters in the pattern, then it means exact filename is provided as pattern like file.txt. Thus, the pattern is matched against the file path so that not all files with the same name in the repo are not returned.
-		result = strings.Compare(string(a.Path), pattern) == 0
-	}
+	result := matchesPathPattern(pattern, string(a.Path), string(a.Name))
 	log.WithFields(log.Fields{
 		"pattern":  pattern,
 		"filePath": a.Path,
@@ -214,6 +323,58 @@ func (a Addition) Matches(pattern string) bool {
 	return result
 }
 
+// MatchesAny reports whether the addition matches a list of patterns the way gitignore evaluates them:
+// patterns are checked in order, and a matching negated pattern ("!pattern") excludes the addition again
+// if it was matched by any earlier pattern.
+func (a Addition) MatchesAny(patterns []string) bool {
+	result := false
+	for _, pattern := range patterns {
+		if IsNegatedPattern(pattern) {
+			result = result && !a.Matches(pattern[1:])
+		} else {
+			result = result || a.Matches(pattern)
+		}
+	}
+	return result
+}
+
+// IsNegatedPattern reports whether a gitignore-style pattern excludes the files it matches
+func IsNegatedPattern(pattern string) bool {
+	return strings.HasPrefix(pattern, "!")
+}
+
+func matchesPathPattern(pattern string, filePath string, fileName string) bool {
+	if len(pattern) == 0 || IsNegatedPattern(pattern) {
+		return false
+	}
+	anchored := pattern[0] == '/'
+	if anchored {
+		pattern = pattern[1:]
+	}
+	hasSpecialCharacters := strings.ContainsAny(pattern, "*?[]\\")
+	if strings.HasSuffix(pattern, "/") { // If the pattern ends in a path separator, then all files inside a directory with that name are matched. However, files with that name itself will not be matched.
+		if hasSpecialCharacters {
+			result, _ := doublestar.Match(pattern+"**", filePath)
+			return result
+		}
+		return strings.HasPrefix(filePath, pattern)
+	}
+	if strings.Contains(pattern, "**") { // "**" matches any number of directories
+		result, _ := doublestar.Match(pattern, filePath)
+		return result
+	}
+	if anchored || strings.ContainsRune(pattern, '/') { // If a pattern contains the path separator in any other location, the match works according to the pattern logic of the default golang glob mechanism
+		result, _ := path.Match(pattern, filePath)
+		return result
+	}
+	if hasSpecialCharacters { // If there are other special characters in the pattern, the pattern is matched against the base name of the file. Thus, the pattern will match files with that pattern anywhere in the repository.
+		result, _ := path.Match(pattern, fileName)
+		return result
+	}
+	// If there are no special characters in the pattern, then it means exact filename is provided as pattern like file.txt. Thus, the pattern is matched against the file path so that not all files with the same name in the repo are not returned.
+	return filePath == pattern
+}
+
 // NameMatches reports whether the basename of the Addition matches the given pattern
 func (a Addition) NameMatches(pattern string) bool {
 	result, _ := path.Match(pattern, string(a.Name))
@@ -221,27 +382,37 @@ func (a Addition) NameMatches(pattern string) bool {
 }
 
 // TrackedFilesAsAdditions returns all of the tracked files in a GitRepo as Additions
-func (repo GitRepo) TrackedFilesAsAdditions() []Addition {
-	trackedFilePaths := repo.trackedFilePaths()
+func (repo GitRepo) TrackedFilesAsAdditions() ([]Addition, error) {
+	trackedFilePaths, err := repo.trackedFilePaths()
+	if err != nil {
+		return nil, err
+	}
 	var additions []Addition
 	for _, path := range trackedFilePaths {
 		additions = append(additions, NewAddition(path, make([]byte, 0)))
 	}
-	return additions
+	return additions, nil
 }
 
-func (repo GitRepo) trackedFilePaths() []string {
-	branchName := repo.currentBranch()
-	if len(branchName) == 0 {
-		return make([]string, 0)
+func (repo GitRepo) trackedFilePaths() ([]string, error) {
+	branchName, err := repo.currentBranch()
+	if err != nil || len(branchName) == 0 {
+		return make([]string, 0), err
+	}
+	byteArray, err := repo.executeRepoCommand("git", "ls-tree", branchName, "--name-only", "-r")
+	if err != nil {
+		return nil, err
 	}
-	byteArray := repo.executeRepoCommand("git", "ls-tree", branchName, "--name-only", "-r")
 	trackedFilePaths := strings.Split(string(byteArray), "\n")
-	return trackedFilePaths
+	return trackedFilePaths, nil
 }
 
-func (repo GitRepo) stagedFiles() []string {
-	stagedFiles := strings.Split(repo.fetchStagedChanges(), "\n")
+func (repo GitRepo) stagedFiles() ([]string, error) {
+	stagedChanges, err := repo.fetchStagedChanges()
+	if err != nil {
+		return nil, err
+	}
+	stagedFiles := strings.Split(stagedChanges, "\n")
 	var result []string
 	for _, c := range stagedFiles {
 		if len(c) != 0 {
@@ -252,76 +423,77 @@ func (repo GitRepo) stagedFiles() []string {
 			}
 		}
 	}
-	return result
+	return result, nil
 }
 
-func (repo GitRepo) currentBranch() string {
-	if !repo.hasBranch() {
-		return ""
+func (repo GitRepo) currentBranch() (string, error) {
+	hasBranch, err := repo.hasBranch()
+	if err != nil || !hasBranch {
+		return "", err
+	}
+	byteArray, err := repo.executeRepoCommand("git", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
 	}
-	byteArray := repo.executeRepoCommand("git", "rev-parse", "--abbrev-ref", "HEAD")
 	branchName := strings.TrimSpace(string(byteArray))
-	return branchName
+	return branchName, nil
 }
 
-func (repo GitRepo) hasBranch() bool {
-	byteArray := repo.executeRepoCommand("git", "branch")
-	return len(string(byteArray)) != 0
+func (repo GitRepo) hasBranch() (bool, error) {
+	byteArray, err := repo.executeRepoCommand("git", "branch")
+	return len(string(byteArray)) != 0, err
 }
 
-func (repo GitRepo) outgoingNonDeletedFiles(oldCommit, newCommit string) []string {
-	allChanges := strings.Split(repo.fetchRawOutgoingDiff(oldCommit, newCommit), "\n")
+func (repo GitRepo) outgoingNonDeletedFiles(oldCommit, newCommit string) ([]string, error) {
+	outgoingDiff, err := repo.fetchRawOutgoingDiff(oldCommit, newCommit)
+	if err != nil {
+		return nil, err
+	}
+	allChanges := strings.Split(outgoingDiff, "\n")
 	var result []string
 	for _, c := range allChanges {
 		if len(c) != 0 {
 			result = append(result, c)
 		}
 	}
-	return result
+	return result, nil
 }
 
-func (repo *GitRepo) fetchStagedChanges() string {
-	return string(repo.executeRepoCommand("git", "diff", "--cached", "--name-status", "--diff-filter=ACM"))
+func (repo *GitRepo) fetchStagedChanges() (string, error) {
+	stagedChanges, err := repo.executeRepoCommand("git", "diff", "--cached", "--name-status", "--diff-filter=ACM")
+	return string(stagedChanges), err
 }
 
-// extractAdditions will accept git diff --staged {file} output and filters the command output
-// to get only the modified sections of the file
-func (repo *GitRepo) extractAdditions(diffContent string) []byte {
-	var result []byte
-	changes := strings.Split(diffContent, "\n")
-	for _, c := range changes {
-		if !strings.HasPrefix(c, "+++") && !strings.HasPrefix(c, "---") && strings.HasPrefix(c, "+") {
-
-			result = append(result, strings.TrimPrefix(c, "+")...)
-			result = append(result, "\n"...)
-		}
-	}
-	return result
-}
-
-func (repo GitRepo) fetchRawOutgoingDiff(oldCommit string, newCommit string) string {
+func (repo GitRepo) fetchRawOutgoingDiff(oldCommit string, newCommit string) (string, error) {
 	gitRange := oldCommit + ".." + newCommit
-	return string(repo.executeRepoCommand("git", "diff", gitRange, "--name-only", "--diff-filter=ACM"))
+	outgoingDiff, err := repo.executeRepoCommand("git", "diff", gitRange, "--name-only", "--diff-filter=ACM")
+	return string(outgoingDiff), err
 }
 
-func (repo GitRepo) executeRepoCommand(commandName string, args ...string) []byte {
+// executeRepoCommand runs a command in the GitRepo and returns its output, or an error describing why it failed
+func (repo GitRepo) executeRepoCommand(commandName string, args ...string) ([]byte, error) {
 	log.WithFields(log.Fields{
 		"command": commandName,
 		"args":    args,
 	}).Debug("Building repo command")
-	co, err := repo.rawExecuteRepoCommand(commandName, args...)
+	// Only the standard output is returned, so that warnings printed by git cannot corrupt machine-readable output
+	command := repo.makeRepoCommand(commandName, args...)
+	stderr := &strings.Builder{}
+	command.Stderr = stderr
+	co, err := command.Output()
 	logEntry := log.WithFields(log.Fields{
 		"dir":     repo.root,
 		"command": fmt.Sprintf("%s %s", commandName, strings.Join(args, " ")),
 		"output":  string(co),
+		"stderr":  stderr.String(),
 		"error":   err,
 	})
-	if err == nil {
-		logEntry.Debug("Git command executed successfully")
-	} else {
-		logEntry.Fatal("Git command execution failed")
+	if err != nil {
+		logEntry.Error("Git command execution failed")
+		return co, fmt.Errorf("%s %s failed in %s: %v: %s", commandName, strings.Join(args, " "), repo.root, err, strings.TrimSpace(stderr.String()))
 	}
-	return co
+	logEntry.Debug("Git command executed successfully")
+	return co, nil
 }
 
 func (repo GitRepo) rawExecuteRepoCommand(commandName string, args ...string) ([]byte, error) {
@@ -331,6 +503,13 @@ func (repo GitRepo) rawExecuteRepoCommand(commandName string, args ...string) ([
 func (repo GitRepo) makeRepoCommand(commandName string, args ...string) *exec.Cmd {
 	command := exec.Command(commandName, args...)
 	command.Dir = repo.root
+	if repo.gitDir != "" {
+		// Relative GIT_DIR and GIT_WORK_TREE values inherited from hooks would not resolve from the root
+		command.Env = append(os.Environ(), "GIT_DIR="+repo.gitDir)
+		if !repo.bare {
+			command.Env = append(command.Env, "GIT_WORK_TREE="+repo.root)
+		}
+	}
 	return command
 }
 
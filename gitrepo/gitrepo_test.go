@@ -20,7 +20,12 @@ func init() {
 }
 
 func (repo GitRepo) additionsInLastCommit() []Addition {
-	return repo.AdditionsWithinRange("HEAD~1", "HEAD")
+	return repo.additionsBetween("HEAD~1", "HEAD")
+}
+
+func (repo GitRepo) additionsBetween(oldCommit, newCommit string) []Addition {
+	additions, _ := repo.AdditionsWithinRange(oldCommit, newCommit)
+	return additions
 }
 
 func TestNewRepoGetsCreatedWithAbsolutePath(t *testing.T) {
@@ -31,7 +36,7 @@ func TestNewRepoGetsCreatedWithAbsolutePath(t *testing.T) {
 
 func TestNoAdditionsBetweenSameRef(t *testing.T) {
 	doInRepoWithCommit(func(git *git_testing.GitTesting) {
-		assert.Len(t, RepoLocatedAt(git.Root()).AdditionsWithinRange("HEAD", "HEAD"), 0,
+		assert.Len(t, RepoLocatedAt(git.Root()).additionsBetween("HEAD", "HEAD"), 0,
 			"There should be no additions between a ref and itself.")
 	})
 }
@@ -43,7 +48,8 @@ func TestGetDiffForStagedFiles(t *testing.T) {
 		git.Add("a.txt")
 		git.Add("new.txt")
 		repo := RepoLocatedAt(git.Root())
-		additions := repo.GetDiffForStagedFiles()
+		additions, err := repo.GetDiffForStagedFiles()
+		assert.NoError(t, err)
 
 		if assert.Len(t, additions, 2) {
 			modifiedAddition := additions[0]
@@ -81,7 +87,8 @@ func TestGetDiffForStagedFilesWithSpacesInPath(t *testing.T) {
 		git.AppendFileContent("folder b/c.txt", "New content.\n", "Spanning multiple lines, even.")
 		git.Add("folder b/c.txt")
 		repo := RepoLocatedAt(git.Root())
-		additions := repo.GetDiffForStagedFiles()
+		additions, err := repo.GetDiffForStagedFiles()
+		assert.NoError(t, err)
 
 		if assert.Len(t, additions, 1) {
 			modifiedAddition := additions[0]
@@ -130,7 +137,7 @@ func TestOutgoingContentOfNewlyAddedFilesIsAvailableInChanges(t *testing.T) {
 		git.AddAndcommit("*", "added new files")
 		repo := RepoLocatedAt(git.Root())
 		assert.Len(t, repo.additionsInLastCommit(), 1)
-		assert.True(t, strings.HasSuffix(string(repo.AdditionsWithinRange("HEAD~1", "HEAD")[0].Data), "new contents"))
+		assert.True(t, strings.HasSuffix(string(repo.additionsBetween("HEAD~1", "HEAD")[0].Data), "new contents"))
 	})
 }
 
@@ -154,7 +161,7 @@ func TestMultipleOutgoingChangesToTheSameFileAreAvailableInAdditions(t *testing.
 
 		repo := RepoLocatedAt(git.Root())
 		assert.Len(t, repo.additionsInLastCommit(), 1)
-		assert.True(t, strings.HasSuffix(string(repo.AdditionsWithinRange("HEAD~2", "HEAD")[0].Data), "New content.\nMore new content.\n"))
+		assert.True(t, strings.HasSuffix(string(repo.additionsBetween("HEAD~2", "HEAD")[0].Data), "New content.\nMore new content.\n"))
 	})
 }
 
@@ -185,7 +192,8 @@ func TestStagedAdditionsIncludeStagedFiles(t *testing.T) {
 		git.AppendFileContent("a.txt", "More new content\n")
 		git.AppendFileContent("alice/bob/b.txt", "New content to b\n")
 
-		stagedAdditions := RepoLocatedAt(git.Root()).StagedAdditions()
+		stagedAdditions, err := RepoLocatedAt(git.Root()).StagedAdditions()
+		assert.NoError(t, err)
 		assert.Len(t, stagedAdditions, 1)
 		assert.Equal(t, "a.txt", string(stagedAdditions[0].Name))
 		assert.Equal(t, "New content.\n", string(stagedAdditions[0].Data))
@@ -197,7 +205,8 @@ func TestStagedAdditionsIncludeStagedNewFiles(t *testing.T) {
 		git.CreateFileWithContents("new.txt", "New content.\n")
 		git.Add("new.txt")
 
-		stagedAdditions := RepoLocatedAt(git.Root()).StagedAdditions()
+		stagedAdditions, err := RepoLocatedAt(git.Root()).StagedAdditions()
+		assert.NoError(t, err)
 		assert.Len(t, stagedAdditions, 1)
 		assert.Equal(t, "new.txt", string(stagedAdditions[0].Name))
 		assert.Equal(t, "New content.\n", string(stagedAdditions[0].Data))
@@ -209,7 +218,8 @@ func TestStagedAdditionsShouldNotIncludeDeletedFiles(t *testing.T) {
 		git.RemoveFile("a.txt")
 		git.Add(".")
 
-		stagedAdditions := RepoLocatedAt(git.Root()).StagedAdditions()
+		stagedAdditions, err := RepoLocatedAt(git.Root()).StagedAdditions()
+		assert.NoError(t, err)
 		assert.Len(t, stagedAdditions, 0)
 	})
 }
@@ -254,6 +264,46 @@ func TestMatchingWithPatterns(t *testing.T) {
 	}
 }
 
+func TestMatchingWithGitignorePatterns(t *testing.T) {
+	files := []Addition{
+		NewAddition("test/fixtures/key.pem", nil),
+		NewAddition("test/fixtures/nested/data.json", nil),
+		NewAddition("src/test/fixtures/data.json", nil),
+		NewAddition("key.pem", nil),
+		NewAddition("docs/key.pem", nil),
+	}
+
+	expectedToMatch := map[string][]bool{
+		"**/*.pem":          {true, false, false, true, true},
+		"test/fixtures/**":  {true, true, false, false, false},
+		"**/fixtures/":      {true, true, true, false, false},
+		"test/**/data.json": {false, true, false, false, false},
+		"/key.pem":          {false, false, false, true, false},
+		"/*.pem":            {false, false, false, true, false},
+		"/test/*/key.pem":   {true, false, false, false, false},
+		"!*.pem":            {false, false, false, false, false},
+		"":                  {false, false, false, false, false},
+	}
+
+	for pattern, expectedResults := range expectedToMatch {
+		t.Run(fmt.Sprintf("Testing matches for pattern %s", pattern), func(t *testing.T) {
+			for i := range files {
+				assert.Equal(t, expectedResults[i], files[i].Matches(pattern), "unexpected match result for %s", files[i].Path)
+			}
+		})
+	}
+}
+
+func TestMatchingAnyPatternWithNegations(t *testing.T) {
+	patterns := []string{"test/fixtures/", "!*.pem", "test/fixtures/keep.pem"}
+
+	assert.True(t, NewAddition("test/fixtures/data.json", nil).MatchesAny(patterns))
+	assert.False(t, NewAddition("test/fixtures/key.pem", nil).MatchesAny(patterns), "negated pattern should exclude earlier matches")
+	assert.True(t, NewAddition("test/fixtures/keep.pem", nil).MatchesAny(patterns), "later patterns should include files again")
+	assert.False(t, NewAddition("src/data.json", nil).MatchesAny(patterns))
+	assert.False(t, NewAddition("key.pem", nil).MatchesAny([]string{"!*.pem"}), "negated patterns should not match on their own")
+}
+
 func TestMatchingAdditionBasename(t *testing.T) {
 	addition := NewAddition("subdirectory/nested-file", nil)
 	assert.False(t, addition.Matches("nested-file"))
@@ -267,3 +317,231 @@ func doInRepoWithCommit(gitOperation git_testing.GitOperation) {
 		gitOperation(git)
 	})
 }
+
+func TestDiscoveringRepositoryFromSubdirectory(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo, err := Discover(filepath.Join(git.Root(), "alice", "bob"))
+		assert.NoError(t, err)
+		assert.Equal(t, canonical(git.Root()), canonical(repo.Root()))
+		assert.Equal(t, canonical(filepath.Join(git.Root(), ".git")), canonical(repo.GitDir()))
+		assert.False(t, repo.IsBare())
+		assert.Contains(t, trackedPaths(repo), FilePath("a.txt"))
+	})
+}
+
+func TestDiscoveringLinkedWorktree(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		worktree := filepath.Join(t.TempDir(), "linked")
+		output, err := exec.Command("git", "-C", git.Root(), "worktree", "add", "-q", worktree).CombinedOutput()
+		assert.NoError(t, err, string(output))
+
+		repo, err := Discover(worktree)
+		assert.NoError(t, err)
+		assert.Equal(t, canonical(worktree), canonical(repo.Root()))
+		assert.True(t, strings.HasPrefix(canonical(repo.GitDir()), canonical(filepath.Join(git.Root(), ".git", "worktrees"))),
+			"Expected the git dir of a linked worktree to be located under the main repository")
+		assert.Contains(t, trackedPaths(repo), FilePath("a.txt"))
+	})
+}
+
+func TestDiscoveringRepositoryFromGitDirEnvironment(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		t.Setenv("GIT_DIR", filepath.Join(git.Root(), ".git"))
+		t.Setenv("GIT_WORK_TREE", git.Root())
+
+		repo, err := Discover(t.TempDir())
+		assert.NoError(t, err)
+		assert.Equal(t, canonical(git.Root()), canonical(repo.Root()))
+		assert.Equal(t, canonical(filepath.Join(git.Root(), ".git")), canonical(repo.GitDir()))
+	})
+}
+
+func TestDiscoveringBareRepository(t *testing.T) {
+	bare := t.TempDir()
+	output, err := exec.Command("git", "init", "-q", "--bare", bare).CombinedOutput()
+	assert.NoError(t, err, string(output))
+
+	repo, err := Discover(bare)
+	assert.NoError(t, err)
+	assert.True(t, repo.IsBare())
+	assert.Equal(t, canonical(bare), canonical(repo.Root()))
+	assert.Equal(t, canonical(bare), canonical(repo.GitDir()))
+}
+
+func TestDiscoveringOutsideRepository(t *testing.T) {
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	directory := t.TempDir()
+	_, err := Discover(directory)
+	assert.ErrorContains(t, err, "is not inside a git repository")
+}
+
+func canonical(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
+
+func trackedPaths(repo GitRepo) []FilePath {
+	var paths []FilePath
+	trackedFiles, _ := repo.TrackedFilesAsAdditions()
+	for _, addition := range trackedFiles {
+		paths = append(paths, addition.Path)
+	}
+	return paths
+}
+
+func TestFailingGitCommandsReturnErrors(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		_, err := RepoLocatedAt(git.Root()).AdditionsWithinRange("HEAD", "no-such-ref")
+		assert.ErrorContains(t, err, "git diff HEAD..no-such-ref --name-only --diff-filter=ACM failed")
+	})
+}
+
+func TestShuttingDownBatchReaders(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		reader, err := NewBatchGitHeadPathReader(RepoLocatedAt(git.Root()))
+		assert.NoError(t, err)
+		assert.NoError(t, reader.Shutdown(), "Shutting down a reader that was not started should do nothing")
+
+		reader, err = NewBatchGitHeadPathReader(RepoLocatedAt(git.Root()))
+		assert.NoError(t, err)
+		assert.NoError(t, reader.Start())
+		contents, err := reader.Read("a.txt")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, contents)
+		assert.NoError(t, reader.Shutdown())
+		assert.NoError(t, reader.Shutdown(), "Shutting down a reader twice should do nothing")
+	})
+}
+
+func TestGetDiffForStagedRenamesOnlyIncludesNewContent(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("before.txt", "line 1\n", "line 2\n", "line 3\n", "line 4\n")
+		git.AddAndcommit("before.txt", "Add file to rename")
+		git.Move("before.txt", "after.txt")
+		git.AppendFileContent("after.txt", "line 5\n")
+		git.Add("after.txt")
+		git.Move("a.txt", "renamed a.txt")
+
+		additions, err := RepoLocatedAt(git.Root()).GetDiffForStagedFiles()
+
+		assert.NoError(t, err)
+		if assert.Len(t, additions, 2) {
+			assert.Equal(t, NewAddition("after.txt", []byte("line 5\n")), additions[0])
+			assert.Equal(t, NewAddition("renamed a.txt", nil), additions[1])
+		}
+	})
+}
+
+func TestGetDiffForStagedFilesWithQuotedPaths(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("naïve b/say \"hi\".txt", "secret contents")
+		git.CreateFileWithContents("tab\tseparated.txt", "more contents")
+		git.Add(".")
+
+		additions, err := RepoLocatedAt(git.Root()).GetDiffForStagedFiles()
+
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []Addition{
+			NewAddition("naïve b/say \"hi\".txt", []byte("secret contents\n")),
+			NewAddition("tab\tseparated.txt", []byte("more contents\n")),
+		}, additions)
+	})
+}
+
+func TestGetDiffForStagedModeChangesAndBinaryFiles(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo := RepoLocatedAt(git.Root())
+		assert.NoError(t, os.Chmod(filepath.Join(repo.root, "a.txt"), 0755))
+		git.Add("a.txt")
+		pixel, err := os.ReadFile("pixel.jpg")
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(repo.root, "pixel.jpg"), pixel, 0644))
+		git.Add("pixel.jpg")
+
+		additions, err := repo.GetDiffForStagedFiles()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Addition{NewAddition("pixel.jpg", pixel)}, additions)
+	})
+}
+
+func TestAdditionsNotOnRemotesIncludeRenamedFiles(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("on-remote.txt")
+		git.CreateFileWithContents("config.txt", "user=admin\nhost=localhost\nport=8080\n")
+		git.AddAndcommit("config.txt", "add config")
+		repo := RepoLocatedAt(git.Root())
+		output, err := exec.Command("git", "-C", git.Root(), "update-ref", "refs/remotes/origin/main", "HEAD").CombinedOutput()
+		assert.NoError(t, err, string(output))
+		git.Move("config.txt", "settings.txt")
+		git.AppendFileContent("settings.txt", "password=hunter2\n")
+		git.AddAndcommit("*", "rename and edit config")
+
+		additions, err := repo.AdditionsNotOnRemotes("HEAD")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Addition{NewAddition("settings.txt", []byte("user=admin\nhost=localhost\nport=8080\npassword=hunter2\n"))}, additions)
+	})
+}
+
+func TestAdditionsNotOnRemotesIncludeChangesOfMerges(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("on-remote.txt")
+		repo := RepoLocatedAt(git.Root())
+		gitCommand := func(args ...string) {
+			output, err := exec.Command("git", append([]string{"-C", git.Root()}, args...)...).CombinedOutput()
+			assert.NoError(t, err, string(output))
+		}
+		gitCommand("update-ref", "refs/remotes/origin/main", "HEAD")
+		gitCommand("checkout", "--quiet", "-b", "side")
+		git.CreateFileWithContents("side.txt", "side")
+		git.AddAndcommit("side.txt", "add side file")
+		gitCommand("checkout", "--quiet", "-")
+		git.CreateFileWithContents("main.txt", "main")
+		git.AddAndcommit("main.txt", "add main file")
+		gitCommand("merge", "--quiet", "--no-ff", "--no-commit", "side")
+		git.CreateFileWithContents("merged.txt", "only added by the merge")
+		git.Add("merged.txt")
+		gitCommand("commit", "--quiet", "--no-edit")
+
+		additions, err := repo.AdditionsNotOnRemotes("HEAD")
+
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []Addition{
+			NewAddition("main.txt", []byte("main")),
+			NewAddition("side.txt", []byte("side")),
+			NewAddition("merged.txt", []byte("only added by the merge")),
+		}, additions)
+	})
+}
+
+func TestAdditionsNotOnRemotes(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("on-remote.txt")
+		repo := RepoLocatedAt(git.Root())
+		hasRemoteHistory, err := repo.HasRemoteHistory()
+		assert.NoError(t, err)
+		assert.False(t, hasRemoteHistory)
+
+		output, err := exec.Command("git", "-C", git.Root(), "update-ref", "refs/remotes/origin/main", "HEAD").CombinedOutput()
+		assert.NoError(t, err, string(output))
+		git.CreateFileWithContents("new.txt", "new")
+		git.CreateFileWithContents("removed-again.txt", "removed")
+		git.AddAndcommit("*", "add new files")
+		git.RemoveFile("removed-again.txt")
+		git.AddAndcommit("*", "remove one of them")
+
+		hasRemoteHistory, err = repo.HasRemoteHistory()
+		assert.NoError(t, err)
+		assert.True(t, hasRemoteHistory)
+		commits, err := repo.CommitsNotOnRemotes("HEAD")
+		assert.NoError(t, err)
+		assert.Len(t, commits, 2)
+		additions, err := repo.AdditionsNotOnRemotes("HEAD")
+		assert.NoError(t, err)
+		assert.Equal(t, []Addition{NewAddition("new.txt", []byte("new"))}, additions)
+	})
+}
@@ -0,0 +1,97 @@
+package gitrepo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxLFSPointerSize is the size above which a file is not a Git LFS pointer, as the specification puts it
+const maxLFSPointerSize = 1024
+
+var (
+	lfsVersionPattern = regexp.MustCompile(`^version https://(git-lfs\.github\.com/spec/v1|hawser\.github\.com/spec/v1)$`)
+	lfsOIDPattern     = regexp.MustCompile(`^oid sha256:([0-9a-f]{64})$`)
+	lfsSizePattern    = regexp.MustCompile(`^size ([0-9]+)$`)
+)
+
+// LFSPointer is the text that Git LFS commits in place of the content of a file, which it stores apart
+type LFSPointer struct {
+	OID  string
+	Size int64
+}
+
+// ParseLFSPointer answers if data is a Git LFS pointer, and returns the object it points to.
+// ref: https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+func ParseLFSPointer(data []byte) (LFSPointer, bool) {
+	if len(data) == 0 || len(data) > maxLFSPointerSize {
+		return LFSPointer{}, false
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) < 3 || !lfsVersionPattern.MatchString(lines[0]) {
+		return LFSPointer{}, false
+	}
+	var pointer LFSPointer
+	for _, line := range lines[1:] {
+		if match := lfsOIDPattern.FindStringSubmatch(line); match != nil {
+			pointer.OID = match[1]
+		} else if match := lfsSizePattern.FindStringSubmatch(line); match != nil {
+			pointer.Size, _ = strconv.ParseInt(match[1], 10, 64)
+		}
+	}
+	return pointer, pointer.OID != ""
+}
+
+// LFSObjects is the local store of Git LFS objects of a repository
+type LFSObjects struct {
+	dir string
+}
+
+// LFSObjects returns the local store of Git LFS objects of the GitRepo, in $GIT_DIR/lfs/objects
+func (repo GitRepo) LFSObjects() LFSObjects {
+	gitDir := repo.gitDir
+	if gitDir == "" {
+		gitDir = filepath.Join(repo.root, ".git")
+	}
+	return LFSObjects{dir: filepath.Join(gitDir, "lfs", "objects")}
+}
+
+// Read returns the content of the object a pointer points to, unless it is larger than maxSize bytes,
+// missing from the local store, or does not match the oid of the pointer
+func (objects LFSObjects) Read(pointer LFSPointer, maxSize int64) ([]byte, error) {
+	if objects.dir == "" {
+		return nil, fmt.Errorf("there is no local store of Git LFS objects")
+	}
+	if pointer.Size > maxSize {
+		return nil, fmt.Errorf("its size of %d bytes exceeds the limit of %d bytes", pointer.Size, maxSize)
+	}
+	object, err := os.Open(filepath.Join(objects.dir, pointer.OID[0:2], pointer.OID[2:4], pointer.OID))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("it is not in the local store of Git LFS objects")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	// the size of the pointer may lie, so the object is never read beyond the limit
+	if info, err := object.Stat(); err == nil && info.Size() > maxSize {
+		return nil, fmt.Errorf("its object of %d bytes exceeds the limit of %d bytes", info.Size(), maxSize)
+	}
+	content, err := io.ReadAll(io.LimitReader(object, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("its object holds more than the limit of %d bytes", maxSize)
+	}
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != pointer.OID {
+		return nil, fmt.Errorf("its content does not match its oid")
+	}
+	return content, nil
+}
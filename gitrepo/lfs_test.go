@@ -0,0 +1,60 @@
+package gitrepo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lfsPointerTo(content []byte) (LFSPointer, []byte) {
+	sum := sha256.Sum256(content)
+	pointer := LFSPointer{OID: hex.EncodeToString(sum[:]), Size: int64(len(content))}
+	return pointer, []byte(fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", pointer.OID, pointer.Size))
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	expected, text := lfsPointerTo([]byte("password=hunter2"))
+
+	pointer, isPointer := ParseLFSPointer(text)
+	assert.True(t, isPointer)
+	assert.Equal(t, expected, pointer)
+
+	for _, notAPointer := range []string{"", "oid sha256:abc\nsize 3\n", "version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 3\n"} {
+		_, isPointer := ParseLFSPointer([]byte(notAPointer))
+		assert.False(t, isPointer, notAPointer)
+	}
+}
+
+func TestReadLFSObjects(t *testing.T) {
+	root := t.TempDir()
+	content := []byte("password=hunter2")
+	pointer, _ := lfsPointerTo(content)
+	objectDir := filepath.Join(root, ".git", "lfs", "objects", pointer.OID[0:2], pointer.OID[2:4])
+	assert.NoError(t, os.MkdirAll(objectDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(objectDir, pointer.OID), content, 0644))
+	objects := RepoLocatedAt(root).LFSObjects()
+
+	read, err := objects.Read(pointer, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, content, read)
+
+	_, err = objects.Read(pointer, 8)
+	assert.EqualError(t, err, "its size of 16 bytes exceeds the limit of 8 bytes")
+
+	lying := LFSPointer{OID: pointer.OID, Size: 4}
+	_, err = objects.Read(lying, 8)
+	assert.EqualError(t, err, "its object of 16 bytes exceeds the limit of 8 bytes", "Expected the size of the object rather than that of the pointer to be checked")
+
+	missing, _ := lfsPointerTo([]byte("missing"))
+	_, err = objects.Read(missing, 1024)
+	assert.EqualError(t, err, "it is not in the local store of Git LFS objects")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(objectDir, pointer.OID), []byte("tampered content"), 0644))
+	_, err = objects.Read(pointer, 1024)
+	assert.EqualError(t, err, "its content does not match its oid")
+}
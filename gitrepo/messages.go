@@ -0,0 +1,126 @@
+package gitrepo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// CommitMessagePrefix starts the path of an Addition holding a commit message, followed by the commit it belongs to
+	CommitMessagePrefix = "commit-message:"
+	// TagAnnotationPrefix starts the path of an Addition holding the annotation of a tag, followed by the name of the tag
+	TagAnnotationPrefix = "tag-annotation:"
+	// scissorsLine marks the start of the part of a commit message that git strips, e.g. the diff added by `git commit --verbose`
+	scissorsLine = " ------------------------ >8 ------------------------"
+)
+
+// NewMessageAddition returns an Addition for a commit message or tag annotation, whose path is the given name
+func NewMessageAddition(name string, commits []string, message []byte) Addition {
+	return Addition{
+		Path:    FilePath(name),
+		Name:    FileName(name),
+		Commits: commits,
+		Data:    message,
+	}
+}
+
+// IsMessage answers if an Addition holds a commit message or tag annotation, rather than the contents of a file
+func (a Addition) IsMessage() bool {
+	return IsMessagePath(a.Path)
+}
+
+// IsMessagePath answers if a path is that of a commit message or tag annotation
+func IsMessagePath(path FilePath) bool {
+	return strings.HasPrefix(string(path), CommitMessagePrefix) || strings.HasPrefix(string(path), TagAnnotationPrefix)
+}
+
+// CommitMessagesWithinRange returns the messages of the commits reachable from newCommit, but not from oldCommit.
+// If oldCommit is empty, the messages of all commits reachable from newCommit are returned.
+func (repo GitRepo) CommitMessagesWithinRange(oldCommit, newCommit string) ([]Addition, error) {
+	if oldCommit == "" {
+		return repo.commitMessages(newCommit)
+	}
+	return repo.commitMessages(fmt.Sprintf("%s..%s", oldCommit, newCommit))
+}
+
+// CommitMessagesNotOnRemotes returns the messages of the commits reachable from newCommit, but not from any remote-tracking ref
+func (repo GitRepo) CommitMessagesNotOnRemotes(newCommit string) ([]Addition, error) {
+	return repo.commitMessages(newCommit, "--not", "--remotes")
+}
+
+// AllCommitMessages returns the messages of all commits reachable from any ref of the repository,
+// and optionally from any reflog entry, which includes all stash entries
+func (repo GitRepo) AllCommitMessages(includeReflogs bool) ([]Addition, error) {
+	if includeReflogs {
+		return repo.commitMessages("--all", "--reflog")
+	}
+	return repo.commitMessages("--all")
+}
+
+func (repo GitRepo) commitMessages(revisions ...string) ([]Addition, error) {
+	args := append([]string{"log", "--no-color", "--format=%H%x00%B%x00"}, revisions...)
+	output, err := repo.executeRepoCommand("git", args...)
+	if err != nil {
+		return nil, err
+	}
+	var messages []Addition
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		commit := strings.TrimSpace(fields[i])
+		messages = append(messages, NewMessageAddition(CommitMessagePrefix+commit, []string{commit}, []byte(fields[i+1])))
+	}
+	return messages, nil
+}
+
+// TagAnnotations returns the messages of the annotated tags matching the given ref patterns, or of all annotated tags if none are given.
+// Lightweight tags have no annotation, so they are skipped. Signatures of signed tags are not part of the returned messages.
+func (repo GitRepo) TagAnnotations(refPatterns ...string) ([]Addition, error) {
+	if len(refPatterns) == 0 {
+		refPatterns = []string{"refs/tags"}
+	}
+	args := append([]string{"for-each-ref", "--format=%(objecttype)%00%(refname:strip=2)%00%(*objectname)%00%(contents:subject)%0a%0a%(contents:body)%00"}, refPatterns...)
+	output, err := repo.executeRepoCommand("git", args...)
+	if err != nil {
+		return nil, err
+	}
+	var annotations []Addition
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+3 < len(fields); i += 4 {
+		if strings.TrimSpace(fields[i]) != "tag" {
+			continue
+		}
+		tag, taggedCommit, message := fields[i+1], fields[i+2], fields[i+3]
+		annotations = append(annotations, NewMessageAddition(TagAnnotationPrefix+tag, []string{taggedCommit}, []byte(message)))
+	}
+	return annotations, nil
+}
+
+// CleanupCommitMessage removes what git strips from a commit message being edited before committing it:
+// lines starting with the comment character, and everything from the scissors line on, e.g. the diff shown by `git commit --verbose`.
+func CleanupCommitMessage(message []byte, commentChar string) []byte {
+	if commentChar == "" {
+		commentChar = "#"
+	}
+	var cleaned []byte
+	for _, line := range bytes.SplitAfter(message, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte(commentChar+scissorsLine)) {
+			break
+		}
+		if bytes.HasPrefix(line, []byte(commentChar)) {
+			continue
+		}
+		cleaned = append(cleaned, line...)
+	}
+	return cleaned
+}
+
+// CommentChar returns the character that starts comment lines in commit messages being edited, as configured by core.commentChar
+func (repo GitRepo) CommentChar() string {
+	output, err := repo.rawExecuteRepoCommand("git", "config", "--get", "core.commentChar")
+	commentChar := strings.TrimSpace(string(output))
+	if err != nil || commentChar == "" || commentChar == "auto" {
+		return "#"
+	}
+	return commentChar
+}
@@ -0,0 +1,169 @@
+package gitrepo
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rawDiffEntry represents a record of `git diff --raw -z`, i.e. a single file changed by a diff
+type rawDiffEntry struct {
+	newObject string
+	status    byte
+	oldPath   string
+	newPath   string
+}
+
+func (e rawDiffEntry) isDeletion() bool {
+	return e.status == 'D'
+}
+
+func (e rawDiffEntry) isRenameOrCopy() bool {
+	return e.status == 'R' || e.status == 'C'
+}
+
+// parseRawDiff parses the NUL-separated output of `git diff --raw -z --no-abbrev`.
+// Paths are taken verbatim from that output, so they never need to be unquoted.
+// ref: https://git-scm.com/docs/diff-format#_raw_output_format
+func parseRawDiff(output []byte) ([]rawDiffEntry, error) {
+	fields := bytes.Split(output, []byte{0})
+	var entries []rawDiffEntry
+	for i := 0; i < len(fields); i++ {
+		header := string(fields[i])
+		if header == "" {
+			continue
+		}
+		// :<old mode> <new mode> <old object> <new object> <status>[<score>]
+		headerFields := strings.Fields(strings.TrimPrefix(header, ":"))
+		if !strings.HasPrefix(header, ":") || len(headerFields) != 5 || len(headerFields[4]) == 0 {
+			return nil, fmt.Errorf("unexpected raw diff record %q", header)
+		}
+		entry := rawDiffEntry{newObject: headerFields[3], status: headerFields[4][0]}
+		pathCount := 1
+		if entry.isRenameOrCopy() {
+			pathCount = 2
+		}
+		if i+pathCount >= len(fields) {
+			return nil, fmt.Errorf("missing paths for raw diff record %q", header)
+		}
+		entry.oldPath = string(fields[i+1])
+		entry.newPath = string(fields[i+pathCount])
+		i += pathCount
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// patchSection holds what a single file section of a patch adds
+type patchSection struct {
+	path   string
+	added  []byte
+	binary bool
+}
+
+// parsePatch collects the added lines of each file section of `git diff -p` output, keyed by the path of the new file.
+// Renamed and copied files are keyed by their new path, so that only their new content is attributed to them.
+// Header lines are only interpreted outside of hunks, where every line starts with a prefix (' ', '+', '-' or '\').
+// ref: https://git-scm.com/docs/diff-format#_generating_patches_with_p
+func parsePatch(patch string) map[string]*patchSection {
+	var sections []*patchSection
+	var current *patchSection
+	inHunk := false
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			current = &patchSection{path: pathOfDiffHeader(strings.TrimPrefix(line, "diff --git "))}
+			sections = append(sections, current)
+			inHunk = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if inHunk {
+			if strings.HasPrefix(line, "+") {
+				current.added = append(current.added, line[1:]...)
+				current.added = append(current.added, '\n')
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case strings.HasPrefix(line, "rename to "):
+			current.path = unquotePath(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "copy to "):
+			current.path = unquotePath(strings.TrimPrefix(line, "copy to "))
+		case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+			current.binary = true
+		}
+	}
+
+	// A file changing its type (e.g. into a symlink) is reported as a deletion followed by a creation of the same path
+	sectionsByPath := make(map[string]*patchSection)
+	for _, section := range sections {
+		if existing, ok := sectionsByPath[section.path]; ok {
+			existing.added = append(existing.added, section.added...)
+			existing.binary = existing.binary || section.binary
+			continue
+		}
+		sectionsByPath[section.path] = section
+	}
+	return sectionsByPath
+}
+
+// pathOfDiffHeader returns the path of the new file in a "diff --git a/<path> b/<path>" header.
+// Paths with special characters are quoted by git. Otherwise, both paths are the same unless the file was
+// renamed or copied, in which case the path is replaced by the "rename to" or "copy to" header that follows.
+func pathOfDiffHeader(paths string) string {
+	if strings.HasPrefix(paths, `"`) {
+		oldPath, rest, ok := cutQuotedPath(paths)
+		if !ok {
+			return ""
+		}
+		newPath := unquotePath(strings.TrimPrefix(rest, " "))
+		if newPath == "" {
+			return strings.TrimPrefix(oldPath, "a/")
+		}
+		return strings.TrimPrefix(newPath, "b/")
+	}
+	if strings.HasSuffix(paths, `"`) {
+		if index := strings.Index(paths, ` "b/`); index >= 0 {
+			return strings.TrimPrefix(unquotePath(paths[index+1:]), "b/")
+		}
+	}
+	pathLength := (len(paths) - len("a/ b/")) / 2
+	if pathLength <= 0 {
+		return ""
+	}
+	return paths[len("a/") : len("a/")+pathLength]
+}
+
+// cutQuotedPath splits a C-style quoted path from the beginning of s, returning it unquoted along with the rest of s
+func cutQuotedPath(s string) (string, string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			unquoted, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", false
+			}
+			return unquoted, s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// unquotePath returns a path as printed by git in diff headers, with C-style quotes and escapes removed
+func unquotePath(path string) string {
+	if !strings.HasPrefix(path, `"`) {
+		return path
+	}
+	unquoted, _, ok := cutQuotedPath(path)
+	if !ok {
+		return path
+	}
+	return unquoted
+}
@@ -0,0 +1,66 @@
+package gitrepo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsingRawDiff(t *testing.T) {
+	output := ":100644 100644 1111 2222 M\x00a b.txt\x00" +
+		":100644 100644 3333 4444 R086\x00old.txt\x00new\tname.txt\x00" +
+		":100644 000000 5555 0000 D\x00gone.txt\x00"
+
+	entries, err := parseRawDiff([]byte(output))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []rawDiffEntry{
+		{newObject: "2222", status: 'M', oldPath: "a b.txt", newPath: "a b.txt"},
+		{newObject: "4444", status: 'R', oldPath: "old.txt", newPath: "new\tname.txt"},
+		{newObject: "0000", status: 'D', oldPath: "gone.txt", newPath: "gone.txt"},
+	}, entries)
+}
+
+func TestParsingMalformedRawDiff(t *testing.T) {
+	_, err := parseRawDiff([]byte(":100644 100644 3333 4444 R086\x00old.txt"))
+	assert.EqualError(t, err, `missing paths for raw diff record ":100644 100644 3333 4444 R086"`)
+}
+
+func TestPathOfDiffHeader(t *testing.T) {
+	assert.Equal(t, "a.txt", pathOfDiffHeader("a/a.txt b/a.txt"))
+	assert.Equal(t, "folder b/c.txt", pathOfDiffHeader("a/folder b/c.txt b/folder b/c.txt"))
+	assert.Equal(t, "naïve.txt", pathOfDiffHeader(`"a/na\303\257ve.txt" "b/na\303\257ve.txt"`))
+	assert.Equal(t, `say "hi".txt`, pathOfDiffHeader(`"a/say \"hi\".txt" "b/say \"hi\".txt"`))
+	assert.Equal(t, "tab\there.txt", pathOfDiffHeader(`a/plain.txt "b/tab\there.txt"`))
+}
+
+func TestParsingPatch(t *testing.T) {
+	patch := `diff --git a/a.txt b/a.txt
+index 1111..2222 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1 +1,2 @@
+ unchanged
++++ added line that looks like a header
+diff --git a/old.txt b/renamed.txt
+similarity index 90%
+rename from old.txt
+rename to renamed.txt
+@@ -1 +1 @@
+-old content
++new content
+diff --git "a/sp\303\244ce d.txt" "b/sp\303\244ce d.txt"
+old mode 100644
+new mode 100755
+diff --git a/pixel.jpg b/pixel.jpg
+new file mode 100644
+Binary files /dev/null and b/pixel.jpg differ
+`
+	sections := parsePatch(patch)
+
+	assert.Len(t, sections, 4)
+	assert.Equal(t, "++ added line that looks like a header\n", string(sections["a.txt"].added))
+	assert.Equal(t, "new content\n", string(sections["renamed.txt"].added))
+	assert.Empty(t, sections["späce d.txt"].added)
+	assert.True(t, sections["pixel.jpg"].binary)
+}
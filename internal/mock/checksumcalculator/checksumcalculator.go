@@ -47,6 +47,20 @@ func (mr *MockChecksumCalculatorMockRecorder) CalculateCollectiveChecksumForPatt
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateCollectiveChecksumForPattern", reflect.TypeOf((*MockChecksumCalculator)(nil).CalculateCollectiveChecksumForPattern), fileNamePattern)
 }
 
+// CalculateCollectiveChecksumForPatterns mocks base method.
+func (m *MockChecksumCalculator) CalculateCollectiveChecksumForPatterns(fileNamePatterns []string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CalculateCollectiveChecksumForPatterns", fileNamePatterns)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CalculateCollectiveChecksumForPatterns indicates an expected call of CalculateCollectiveChecksumForPatterns.
+func (mr *MockChecksumCalculatorMockRecorder) CalculateCollectiveChecksumForPatterns(fileNamePatterns interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateCollectiveChecksumForPatterns", reflect.TypeOf((*MockChecksumCalculator)(nil).CalculateCollectiveChecksumForPatterns), fileNamePatterns)
+}
+
 // SuggestTalismanRC mocks base method.
 func (m *MockChecksumCalculator) SuggestTalismanRC(fileNamePatterns []string) string {
 	m.ctrl.T.Helper()
@@ -0,0 +1,73 @@
+package talisman
+
+import (
+	"sort"
+
+	"talisman/detector/helpers"
+)
+
+// Finding is a problem reported by a detector for a file
+type Finding struct {
+	Path     string
+	Detector string
+	Message  string
+	Severity Severity
+	Commits  []string
+}
+
+// Ignore records that a detector skipped a file, or one of its findings, because of a rule
+type Ignore struct {
+	Path     string
+	Detector string
+	Rule     string
+}
+
+// Explanation records a decision taken for a file while scanning
+type Explanation struct {
+	Path     string
+	Detector string
+	Decision string
+}
+
+// Results holds the outcome of a scan.
+// Failures are findings that meet the severity threshold, Warnings are findings below it.
+type Results struct {
+	Failures     []Finding
+	Warnings     []Finding
+	Ignores      []Ignore
+	Explanations []Explanation
+}
+
+// Successful answers if no finding failed the scan
+func (r *Results) Successful() bool {
+	return len(r.Failures) == 0
+}
+
+func newResults(detectionResults *helpers.DetectionResults) *Results {
+	results := &Results{}
+	for _, resultDetails := range detectionResults.Results {
+		path := string(resultDetails.Filename)
+		results.Failures = append(results.Failures, findingsFor(path, resultDetails.FailureList)...)
+		results.Warnings = append(results.Warnings, findingsFor(path, resultDetails.WarningList)...)
+		for _, ignore := range resultDetails.IgnoreList {
+			results.Ignores = append(results.Ignores, Ignore{Path: path, Detector: ignore.Category, Rule: ignore.Message})
+		}
+	}
+	for filePath, explanations := range detectionResults.Explanations {
+		for _, explanation := range explanations {
+			results.Explanations = append(results.Explanations, Explanation{Path: string(filePath), Detector: explanation.Detector, Decision: explanation.Decision})
+		}
+	}
+	sort.SliceStable(results.Explanations, func(i, j int) bool {
+		return results.Explanations[i].Path < results.Explanations[j].Path
+	})
+	return results
+}
+
+func findingsFor(path string, details []helpers.Details) []Finding {
+	var findings []Finding
+	for _, detail := range details {
+		findings = append(findings, Finding{Path: path, Detector: detail.Category, Message: detail.Message, Severity: Severity(detail.Severity.String()), Commits: detail.Commits})
+	}
+	return findings
+}
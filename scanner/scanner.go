@@ -1,9 +1,8 @@
 package scanner
 
 import (
-	"log"
+	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"talisman/gitrepo"
 	"talisman/utility"
@@ -11,6 +10,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// UnreachableBlobPrefix starts the path of an unreachable blob whose path is unknown, followed by its object id
+const UnreachableBlobPrefix = "unreachable-blob:"
+
 type blobDetails struct {
 	hash, filePath string
 }
@@ -20,78 +22,180 @@ type BlobsInCommits struct {
 	commits map[blobDetails][]string
 }
 
-// GetAdditions will get all the additions for entire git history
-func GetAdditions(ignoreHistory bool, br gitrepo.BatchReader) []gitrepo.Addition {
-	blobsInCommits := getBlobsInCommit(ignoreHistory)
-	var additions []gitrepo.Addition
-	err := br.Start()
+// GetAdditions will get all the additions for entire git history of the repo.
+// The batch reader is always shut down before returning, even if reading the history fails.
+func GetAdditions(repo gitrepo.GitRepo, ignoreHistory bool, br gitrepo.BatchReader) ([]gitrepo.Addition, error) {
+	defer shutdown(br)
+	commits, err := getAllCommits(repo, ignoreHistory)
 	if err != nil {
-		logrus.Errorf("error creating file reader %v", err)
+		return nil, err
 	}
-	defer func() {
-		err = br.Shutdown()
-		if err != nil {
-			logrus.Errorf("error creating file reader %v", err)
-		}
-	}()
+	blobsInCommits, err := getBlobsInCommit(repo, commits)
+	if err != nil {
+		return nil, err
+	}
+	return readAdditions(blobsInCommits, nil, br)
+}
 
+// GetAuditAdditions gets the additions of everything git still holds on to, to confirm that a purged secret is really gone:
+// the history of all refs, every reflog entry (which includes all stash entries, not only the top one) and unreachable objects.
+// Unreachable blobs that are not part of any commit, e.g. those only referenced by dangling trees, are named after their object id.
+// The batch reader is always shut down before returning, even if reading the objects fails.
+func GetAuditAdditions(repo gitrepo.GitRepo, br gitrepo.BatchReader) ([]gitrepo.Addition, error) {
+	defer shutdown(br)
+	commits, err := getReflogCommits(repo)
+	if err != nil {
+		return nil, err
+	}
+	unreachable, err := getUnreachableObjects(repo)
+	if err != nil {
+		return nil, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"commits":            len(commits),
+		"unreachableCommits": len(unreachable["commit"]),
+		"unreachableBlobs":   len(unreachable["blob"]),
+	}).Info("Auditing all objects of the repository")
+	blobsInCommits, err := getBlobsInCommit(repo, append(commits, unreachable["commit"]...))
+	if err != nil {
+		return nil, err
+	}
+	return readAdditions(blobsInCommits, unreachable["blob"], br)
+}
+
+// readAdditions reads the contents of the blobs found in commits, along with the given blobs that have no known path.
+// It fails on the first blob that cannot be read, rather than scanning it as if it were empty.
+func readAdditions(blobsInCommits BlobsInCommits, blobsWithoutPath []string, br gitrepo.BatchReader) ([]gitrepo.Addition, error) {
+	var additions []gitrepo.Addition
+	if err := br.Start(); err != nil {
+		return nil, fmt.Errorf("error creating file reader: %v", err)
+	}
+
+	blobsWithPath := make(map[string]bool)
 	for blob := range blobsInCommits.commits {
-		contents, _ := br.Read(blob.hash)
+		contents, err := br.Read(blob.hash)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read blob %s of %s: %v", blob.hash, blob.filePath, err)
+		}
 		newAddition := gitrepo.NewScannerAddition(blob.filePath, blobsInCommits.commits[blob], contents)
 		additions = append(additions, newAddition)
+		blobsWithPath[blob.hash] = true
+	}
+	for _, hash := range blobsWithoutPath {
+		if blobsWithPath[hash] {
+			continue
+		}
+		contents, err := br.Read(hash)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read unreachable blob %s: %v", hash, err)
+		}
+		additions = append(additions, gitrepo.NewScannerAddition(UnreachableBlobPrefix+hash, []string{}, contents))
 	}
 
-	return additions
+	return additions, nil
+}
+
+func shutdown(br gitrepo.BatchReader) {
+	if err := br.Shutdown(); err != nil {
+		logrus.Errorf("error shutting down file reader %v", err)
+	}
 }
 
-func getBlobsInCommit(ignoreHistory bool) BlobsInCommits {
+// blobsOfCommit holds the NUL separated entries listed by git ls-tree for a commit, or the error that prevented listing them
+type blobsOfCommit struct {
+	commit      string
+	blobEntries []string
+	err         error
+}
+
+func getBlobsInCommit(repo gitrepo.GitRepo, commits []string) (BlobsInCommits, error) {
 	progressBar := utility.GetProgressBar(os.Stdout, "Talisman Fetch Blobs")
-	commits := getAllCommits(ignoreHistory)
-	progressBar.Start(len(commits) - 1)
+	progressBar.Start(len(commits))
+	defer progressBar.Finish()
 	blobsInCommits := newBlobsInCommit()
-	result := make(chan []string, len(commits))
+	result := make(chan blobsOfCommit, len(commits))
 	for _, commit := range commits {
-		go putBlobsInChannel(commit, result)
+		go putBlobsInChannel(repo, commit, result)
 	}
-	for i := 1; i < len(commits); i++ {
+	var firstErr error
+	for i := 0; i < len(commits); i++ {
 		progressBar.Increment()
-		getBlobsFromChannel(blobsInCommits, result)
+		if err := getBlobsFromChannel(blobsInCommits, result); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	progressBar.Finish()
-	return blobsInCommits
+	return blobsInCommits, firstErr
 }
 
-func putBlobsInChannel(commit string, result chan []string) {
-	if commit != "" {
-		blobDetailsBytes, _ := exec.Command("git", "ls-tree", "-r", commit).CombinedOutput()
-		blobDetailsList := strings.Split(string(blobDetailsBytes), "\n")
-		blobDetailsList = append(blobDetailsList, commit)
-		result <- blobDetailsList
+func putBlobsInChannel(repo gitrepo.GitRepo, commit string, result chan blobsOfCommit) {
+	blobDetailsBytes, err := repo.GitCommand("ls-tree", "-r", "-z", commit).CombinedOutput()
+	if err != nil {
+		result <- blobsOfCommit{commit: commit, err: fmt.Errorf("unable to list blobs of commit %s: %v: %s", commit, err, strings.TrimSpace(string(blobDetailsBytes)))}
+		return
 	}
+	result <- blobsOfCommit{commit: commit, blobEntries: strings.Split(string(blobDetailsBytes), "\x00")}
 }
 
-func getBlobsFromChannel(blobsInCommits BlobsInCommits, result chan []string) {
-	blobEntries := <-result
-	commit := blobEntries[len(blobEntries)-1]
-	for _, blobEntry := range blobEntries[:len(blobEntries)-1] {
-		if blobEntry != "" {
-			blobHashAndName := strings.Split(strings.Split(blobEntry, " ")[2], "\t")
-			blob := blobDetails{hash: blobHashAndName[0], filePath: blobHashAndName[1]}
-			blobsInCommits.commits[blob] = append(blobsInCommits.commits[blob], commit)
+func getBlobsFromChannel(blobsInCommits BlobsInCommits, result chan blobsOfCommit) error {
+	blobs := <-result
+	if blobs.err != nil {
+		return blobs.err
+	}
+	for _, blobEntry := range blobs.blobEntries {
+		// Each entry is "<mode> <type> <object>\t<path>". Other types than blobs, e.g. the commits of submodules, have no contents to scan.
+		modeTypeAndObject, filePath, found := strings.Cut(blobEntry, "\t")
+		fields := strings.Fields(modeTypeAndObject)
+		if !found || len(fields) != 3 || fields[1] != "blob" {
+			continue
 		}
+		blob := blobDetails{hash: fields[2], filePath: filePath}
+		blobsInCommits.commits[blob] = append(blobsInCommits.commits[blob], blobs.commit)
 	}
+	return nil
 }
 
-func getAllCommits(ignoreHistory bool) []string {
+func getAllCommits(repo gitrepo.GitRepo, ignoreHistory bool) ([]string, error) {
 	commitRange := "--all"
 	if ignoreHistory {
 		commitRange = "--max-count=1"
 	}
-	out, err := exec.Command("git", "log", commitRange, "--pretty=%H").CombinedOutput()
+	return listCommits(repo, commitRange)
+}
+
+// getReflogCommits lists the commits reachable from any ref or any reflog entry.
+// As every stash entry is an entry of the reflog of refs/stash, this includes all stashes.
+func getReflogCommits(repo gitrepo.GitRepo) ([]string, error) {
+	return listCommits(repo, "--all", "--reflog")
+}
+
+func listCommits(repo gitrepo.GitRepo, revisions ...string) ([]string, error) {
+	args := append([]string{"log", "--pretty=%H"}, revisions...)
+	out, err := repo.GitCommand(args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// getUnreachableObjects lists the ids of the objects that are not reachable from any ref or reflog entry, by object type.
+// These are left behind e.g. by dropped stashes or rewritten history, until git prunes them.
+func getUnreachableObjects(repo gitrepo.GitRepo) (map[string][]string, error) {
+	command := repo.GitCommand("fsck", "--unreachable", "--no-progress")
+	stderr := &strings.Builder{}
+	command.Stderr = stderr
+	out, err := command.Output()
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("unable to list unreachable objects: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	objects := make(map[string][]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		// unreachable <type> <object id>
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "unreachable" {
+			objects[fields[1]] = append(objects[fields[1]], fields[2])
+		}
 	}
-	return strings.Split(string(out), "\n")
+	return objects, nil
 }
 
 func newBlobsInCommit() BlobsInCommits {
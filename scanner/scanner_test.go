@@ -1,31 +1,190 @@
 package scanner
 
 import (
+	"errors"
 	"io/ioutil"
+	"os/exec"
+	"strings"
 	"testing"
 
+	"talisman/git_testing"
+	"talisman/gitrepo"
+
 	logr "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
 func init() {
 	logr.SetOutput(ioutil.Discard)
+	git_testing.Logger = logr.WithField("Environment", "Debug")
 }
 
 func Test_getBlobsFromChannel(t *testing.T) {
-	ch := make(chan []string)
+	ch := make(chan blobsOfCommit)
 	go func() {
-		ch <- []string{
-			"100644 blob 351324aa7b3c66043e484c2f2c7b7f1842152f35	.gitignore",
-			"100644 blob 8715df9907604c8ee8fc5e377821817f84f014fa	.pre-commit-hooks.yaml",
-			"commitSha",
+		ch <- blobsOfCommit{
+			commit: "commitSha",
+			blobEntries: []string{
+				"100644 blob 351324aa7b3c66043e484c2f2c7b7f1842152f35	.gitignore",
+				"100644 blob 8715df9907604c8ee8fc5e377821817f84f014fa	.pre-commit-hooks.yaml",
+				"160000 commit 0e1fc1b8bcfe4d8a23e7a1bdba6eb2b26bac38a4	submodule",
+				"100644 blob 5b9b0c6ad1ed5fea4b6faf74e6a3a0eee8e4ec5c	name with\ttab",
+				"",
+			},
 		}
 	}()
 	blobsInCommits := BlobsInCommits{commits: map[blobDetails][]string{}}
-	getBlobsFromChannel(blobsInCommits, ch)
+	err := getBlobsFromChannel(blobsInCommits, ch)
 
+	assert.NoError(t, err)
 	commits := blobsInCommits.commits
-	assert.Len(t, commits, 2)
+	assert.Len(t, commits, 3)
 	assert.Equal(t, []string{"commitSha"}, commits[blobDetails{"351324aa7b3c66043e484c2f2c7b7f1842152f35", ".gitignore"}])
 	assert.Equal(t, []string{"commitSha"}, commits[blobDetails{"8715df9907604c8ee8fc5e377821817f84f014fa", ".pre-commit-hooks.yaml"}])
+	assert.Equal(t, []string{"commitSha"}, commits[blobDetails{"5b9b0c6ad1ed5fea4b6faf74e6a3a0eee8e4ec5c", "name with\ttab"}])
+}
+
+func Test_getBlobsFromChannelReturnsListingErrors(t *testing.T) {
+	ch := make(chan blobsOfCommit, 1)
+	ch <- blobsOfCommit{commit: "commitSha", err: errors.New("unable to list blobs")}
+	blobsInCommits := BlobsInCommits{commits: map[blobDetails][]string{}}
+
+	assert.EqualError(t, getBlobsFromChannel(blobsInCommits, ch), "unable to list blobs")
+	assert.Empty(t, blobsInCommits.commits)
+}
+
+type recordingBatchReader struct {
+	started, shutdown bool
+	readErr           error
+}
+
+func (r *recordingBatchReader) Start() error {
+	r.started = true
+	return nil
+}
+
+func (r *recordingBatchReader) Read(string) ([]byte, error) {
+	if r.readErr != nil {
+		return nil, r.readErr
+	}
+	return []byte("contents"), nil
+}
+
+func (r *recordingBatchReader) Shutdown() error {
+	r.shutdown = true
+	return nil
+}
+
+func TestGetAdditionsReturnsErrorsAndShutsDownReader(t *testing.T) {
+	reader := &recordingBatchReader{}
+	_, err := GetAdditions(gitrepo.RepoLocatedAt(t.TempDir()), false, reader)
+
+	assert.ErrorContains(t, err, "unable to list commits")
+	assert.True(t, reader.shutdown, "Expected the batch reader to be shut down")
+}
+
+func TestGetAdditionsReadsAllBlobs(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		reader := &recordingBatchReader{}
+		additions, err := GetAdditions(gitrepo.RepoLocatedAt(git.Root()), false, reader)
+
+		assert.NoError(t, err)
+		assert.Len(t, additions, 1)
+		assert.True(t, reader.started)
+		assert.True(t, reader.shutdown, "Expected the batch reader to be shut down")
+	})
+}
+
+func TestGetAdditionsSkipsSubmodules(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		runGit(t, git.Root(), "update-index", "--add", "--cacheinfo", "160000,"+git.LatestCommit()+",sub")
+		runGit(t, git.Root(), "commit", "--quiet", "-m", "add submodule")
+
+		repo := gitrepo.RepoLocatedAt(git.Root())
+		reader, err := gitrepo.NewBatchGitObjectHashReader(repo)
+		assert.NoError(t, err)
+
+		additions, err := GetAdditions(repo, false, reader)
+
+		assert.NoError(t, err)
+		assert.Len(t, additions, 1)
+		assert.Equal(t, gitrepo.FilePath("a.txt"), additions[0].Path)
+	})
+}
+
+func TestGetAdditionsReturnsErrorsReadingBlobs(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		reader := &recordingBatchReader{readErr: errors.New("broken pipe")}
+		additions, err := GetAdditions(gitrepo.RepoLocatedAt(git.Root()), false, reader)
+
+		assert.ErrorContains(t, err, "broken pipe")
+		assert.ErrorContains(t, err, "a.txt")
+		assert.Empty(t, additions)
+		assert.True(t, reader.shutdown, "Expected the batch reader to be shut down")
+	})
+}
+
+func TestGetAuditAdditionsFindsReflogStashAndUnreachableBlobs(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		// a commit that is only referenced by the reflog after a history rewrite
+		git.CreateFileWithContents("rewritten.txt", "rewritten")
+		git.AddAndcommit("rewritten.txt", "commit to be rewritten")
+		runGit(t, git.Root(), "reset", "--hard", "HEAD~1")
+		// an older stash entry, below the top of refs/stash
+		git.AppendFileContent("a.txt", "stashed")
+		git.Add("a.txt")
+		runGit(t, git.Root(), "stash", "push", "-m", "older stash")
+		git.CreateFileWithContents("top.txt", "top")
+		git.Add("top.txt")
+		runGit(t, git.Root(), "stash", "push", "-m", "top stash")
+		// a dropped stash, whose commit is unreachable
+		git.CreateFileWithContents("dropped.txt", "dropped")
+		git.Add("dropped.txt")
+		runGit(t, git.Root(), "stash", "push", "-m", "dropped stash")
+		runGit(t, git.Root(), "stash", "drop")
+		// a blob that was never committed
+		danglingBlob := runGit(t, git.Root(), "hash-object", "-w", "--stdin")
+		repo := gitrepo.RepoLocatedAt(git.Root())
+		reader, err := gitrepo.NewBatchGitObjectHashReader(repo)
+		assert.NoError(t, err)
+
+		additions, err := GetAuditAdditions(repo, reader)
+
+		assert.NoError(t, err)
+		contents := contentsByPath(additions)
+		assert.Equal(t, []string{"rewritten"}, contents["rewritten.txt"])
+		assert.Len(t, contents["a.txt"], 2, "Expected the committed and the stashed version")
+		assert.Equal(t, []string{"top"}, contents["top.txt"])
+		assert.Equal(t, []string{"dropped"}, contents["dropped.txt"])
+		assert.Equal(t, []string{"never committed"}, contents[UnreachableBlobPrefix+danglingBlob])
+
+		reader, _ = gitrepo.NewBatchGitObjectHashReader(repo)
+		additions, err = GetAdditions(repo, false, reader)
+		assert.NoError(t, err)
+		contents = contentsByPath(additions)
+		assert.Len(t, contents["a.txt"], 1, "Expected a regular scan to only see the top stash entry")
+		assert.NotContains(t, contents, "rewritten.txt")
+		assert.NotContains(t, contents, "dropped.txt")
+	})
+}
+
+func contentsByPath(additions []gitrepo.Addition) map[string][]string {
+	contents := map[string][]string{}
+	for _, addition := range additions {
+		contents[string(addition.Path)] = append(contents[string(addition.Path)], string(addition.Data))
+	}
+	return contents
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	command.Stdin = strings.NewReader("never committed")
+	output, err := command.Output()
+	assert.NoError(t, err, "git %v failed", args)
+	return strings.TrimSpace(string(output))
 }
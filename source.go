@@ -0,0 +1,56 @@
+package talisman
+
+import (
+	"talisman/gitrepo"
+)
+
+// Addition is a file (or the changed part of a file) to be scanned
+type Addition = gitrepo.Addition
+
+// NewAddition returns an Addition for the file at the given path with the given contents
+func NewAddition(filePath string, content []byte) Addition {
+	return gitrepo.NewAddition(filePath, content)
+}
+
+// Source provides the additions a Scanner checks, from any origin
+type Source interface {
+	Additions() ([]Addition, error)
+}
+
+// SourceFunc adapts a function to a Source
+type SourceFunc func() ([]Addition, error)
+
+// Additions calls f
+func (f SourceFunc) Additions() ([]Addition, error) {
+	return f()
+}
+
+// Additions is a Source of additions that are already in memory
+type Additions []Addition
+
+// Additions returns the additions themselves
+func (a Additions) Additions() ([]Addition, error) {
+	return a, nil
+}
+
+// StagedChanges returns a Source of the changes staged in the git repository containing root, as checked by the pre-commit hook
+func StagedChanges(root string) Source {
+	return SourceFunc(func() ([]Addition, error) {
+		repo, err := gitrepo.Discover(root)
+		if err != nil {
+			return nil, err
+		}
+		return repo.GetDiffForStagedFiles()
+	})
+}
+
+// CommitRange returns a Source of the files changed between two commits of the git repository containing root, as checked by the pre-push hook
+func CommitRange(root, oldCommit, newCommit string) Source {
+	return SourceFunc(func() ([]Addition, error) {
+		repo, err := gitrepo.Discover(root)
+		if err != nil {
+			return nil, err
+		}
+		return repo.AdditionsWithinRange(oldCommit, newCommit)
+	})
+}
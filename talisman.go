@@ -0,0 +1,62 @@
+// Package talisman lets Go programs run Talisman scans without going through the command line.
+//
+// A Scanner checks the additions provided by a Source against the rules of a .talismanrc and returns typed results.
+// Scanners share no state and never write to stdout, so several of them can run concurrently.
+package talisman
+
+import (
+	"fmt"
+
+	"talisman/detector"
+	"talisman/detector/helpers"
+	"talisman/talismanrc"
+)
+
+// Options configure a Scanner
+type Options struct {
+	// Config holds the .talismanrc rules to apply. A nil Config applies no rules.
+	// Checksums of fileignoreconfig entries are compared against the contents of each addition.
+	Config *Config
+	// Explain records why each addition was scanned, ignored or failed in the results
+	Explain bool
+	// RevealSecrets reports secrets in full rather than masked in the messages of the results.
+	// It is only meant for runs whose results stay on the local machine.
+	RevealSecrets bool
+}
+
+// Scanner runs the Talisman detectors against additions
+type Scanner struct {
+	config          *talismanrc.TalismanRC
+	ignoreEvaluator helpers.IgnoreEvaluator
+	explain         bool
+	revealSecrets   bool
+}
+
+// NewScanner returns a Scanner configured with the given options
+func NewScanner(options Options) *Scanner {
+	config := &talismanrc.TalismanRC{}
+	if options.Config != nil {
+		config = options.Config.rc
+	}
+	return &Scanner{config: config, ignoreEvaluator: helpers.HistoricBlobEvaluator(config), explain: options.Explain, revealSecrets: options.RevealSecrets}
+}
+
+// Scan checks all additions provided by the source
+func (s *Scanner) Scan(source Source) (*Results, error) {
+	additions, err := source.Additions()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read additions: %v", err)
+	}
+	detectionResults := helpers.NewDetectionResults()
+	if s.explain {
+		detectionResults.EnableExplain()
+	}
+	if s.revealSecrets {
+		detectionResults.RevealSecrets()
+	}
+	additionsToScan := helpers.RemoveScopedFiles(s.config, additions, detectionResults)
+	detector.DefaultChain(s.config, s.ignoreEvaluator).
+		WithProgressOutput(nil).
+		Test(additionsToScan, s.config, detectionResults)
+	return newResults(detectionResults), nil
+}
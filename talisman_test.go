@@ -0,0 +1,141 @@
+package talisman
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	logr "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logr.SetOutput(io.Discard)
+}
+
+func TestScanningAdditionsFromMemory(t *testing.T) {
+	scanner := NewScanner(Options{})
+
+	results, err := scanner.Scan(Additions{
+		NewAddition("id_rsa", []byte("some key")),
+		NewAddition("notes.txt", []byte("nothing to see here")),
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, results.Successful())
+	assert.Len(t, results.Failures, 1)
+	assert.Equal(t, "id_rsa", results.Failures[0].Path)
+	assert.Equal(t, "filename", results.Failures[0].Detector)
+	assert.Equal(t, SeverityHigh, results.Failures[0].Severity)
+	assert.Empty(t, results.Warnings)
+}
+
+func TestScanningWithConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+fileignoreconfig:
+- filename: id_rsa
+  ignore_detectors: [filename]
+`), ".")
+	assert.NoError(t, err)
+
+	results, err := NewScanner(Options{Config: config}).Scan(Additions{NewAddition("id_rsa", []byte("some key"))})
+
+	assert.NoError(t, err)
+	assert.True(t, results.Successful())
+	assert.Contains(t, results.Ignores, Ignore{
+		Path:     "id_rsa",
+		Detector: "filename",
+		Rule:     `ignored by fileignoreconfig entry "id_rsa", which ignores the filename detector`,
+	})
+}
+
+func TestScanningWithThreshold(t *testing.T) {
+	config, err := ParseConfig([]byte("threshold: high"), ".")
+	assert.NoError(t, err)
+
+	results, err := NewScanner(Options{Config: config}).Scan(Additions{NewAddition("config.txt", []byte("password=not-so-secret"))})
+
+	assert.NoError(t, err)
+	assert.True(t, results.Successful())
+	assert.NotEmpty(t, results.Warnings)
+}
+
+func TestExplainingScans(t *testing.T) {
+	results, err := NewScanner(Options{Explain: true}).Scan(Additions{NewAddition("notes.txt", []byte("nothing to see here"))})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, results.Explanations)
+	for _, explanation := range results.Explanations {
+		assert.Equal(t, "notes.txt", explanation.Path)
+	}
+}
+
+func TestScanningMasksSecretsUnlessRevealed(t *testing.T) {
+	additions := Additions{NewAddition("config.txt", []byte("password=hunter2secret"))}
+
+	masked, err := NewScanner(Options{}).Scan(additions)
+	assert.NoError(t, err)
+	revealed, err := NewScanner(Options{RevealSecrets: true}).Scan(additions)
+	assert.NoError(t, err)
+
+	assert.Len(t, masked.Failures, 1)
+	assert.NotContains(t, masked.Failures[0].Message, "hunter2secret")
+	assert.Len(t, revealed.Failures, 1)
+	assert.Contains(t, revealed.Failures[0].Message, "password=hunter2secret")
+}
+
+func TestScannersDoNotShareResults(t *testing.T) {
+	scanner := NewScanner(Options{})
+
+	first, _ := scanner.Scan(Additions{NewAddition("id_rsa", []byte("some key"))})
+	second, _ := scanner.Scan(Additions{NewAddition("notes.txt", []byte("nothing to see here"))})
+
+	assert.False(t, first.Successful())
+	assert.True(t, second.Successful())
+}
+
+func TestConcurrentScansWithDifferentSeverities(t *testing.T) {
+	lenient, _ := ParseConfig([]byte(`
+threshold: medium
+custom_severities:
+- detector: RSAFile
+  severity: low
+`), ".")
+	strict, _ := ParseConfig([]byte("threshold: medium"), ".")
+	source := Additions{NewAddition("id_rsa", []byte("some key"))}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			results, _ := NewScanner(Options{Config: lenient}).Scan(source)
+			assert.True(t, results.Successful())
+		}()
+		go func() {
+			defer wg.Done()
+			results, _ := NewScanner(Options{Config: strict}).Scan(source)
+			assert.False(t, results.Successful())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParsingInvalidConfig(t *testing.T) {
+	config, err := ParseConfig([]byte("threshold: [high"), ".")
+
+	assert.Nil(t, config)
+	assert.Error(t, err)
+}
+
+func TestScanningFailingSource(t *testing.T) {
+	source := SourceFunc(func() ([]Addition, error) {
+		return nil, errors.New("unreachable")
+	})
+
+	results, err := NewScanner(Options{}).Scan(source)
+
+	assert.Nil(t, results)
+	assert.EqualError(t, err, "unable to read additions: unreachable")
+}
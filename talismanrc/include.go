@@ -0,0 +1,193 @@
+package talismanrc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	logr "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+
+	"talisman/gitrepo"
+)
+
+// maxIncludeDepth guards against include chains that are too deep to be intentional
+const maxIncludeDepth = 10
+
+// IncludeConfig points to another YAML file whose rules should be merged into the current .talismanrc, listed under include or extends.
+// Path is resolved relative to the including file, unless it is absolute.
+// If Ref is set, Path is read from that git ref in the current repository instead of the working tree.
+// The Checksum may only be left out when Ref is a commit id, as the commit id then pins the content. Branches and tags may move.
+type IncludeConfig struct {
+	Path     string `yaml:"path"`
+	Ref      string `yaml:"ref,omitempty"`
+	Checksum string `yaml:"checksum,omitempty"`
+}
+
+// commitIDPattern matches full SHA-1 and SHA-256 commit ids
+var commitIDPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// includeSource locates included files: dir is relative to the repository root, and read from the git ref if there is one
+type includeSource struct {
+	root string
+	dir  string
+	ref  string
+}
+
+// IncludeChecksum returns the checksum expected for an included file with the given contents
+func IncludeChecksum(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveIncludes returns a TalismanRC with the rules of all (transitively) included files merged in.
+// Included rules come first, so that the including file can override scalar values and append to lists.
+// Files listed under extends are included like those listed under include, after them.
+func (tRC *TalismanRC) resolveIncludes(source includeSource, visited map[string]bool, depth int) (*TalismanRC, error) {
+	includes := append(append([]IncludeConfig{}, tRC.Include...), tRC.Extends...)
+	if len(includes) == 0 {
+		return tRC, nil
+	}
+	if depth >= maxIncludeDepth {
+		return nil, fmt.Errorf("includes nested deeper than %d levels", maxIncludeDepth)
+	}
+	merged := &TalismanRC{}
+	for _, include := range includes {
+		included, err := loadInclude(include, source, visited, depth)
+		if err != nil {
+			return nil, err
+		}
+		merged.merge(included)
+	}
+	merged.merge(tRC)
+	merged.Include = nil
+	merged.Extends = nil
+	merged.Version = tRC.Version
+	return merged, nil
+}
+
+func loadInclude(include IncludeConfig, source includeSource, visited map[string]bool, depth int) (*TalismanRC, error) {
+	if isEmptyString(include.Path) {
+		return nil, fmt.Errorf("include entry is missing a path")
+	}
+	ref := source.ref
+	if include.Ref != "" {
+		ref = include.Ref
+	}
+
+	var includePath string
+	var contents []byte
+	var err error
+	if ref != "" {
+		includePath = path.Clean(path.Join(source.dir, filepath.ToSlash(include.Path)))
+		contents, err = readIncludeAtRef(source.root, ref, includePath)
+	} else {
+		includePath = include.Path
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(source.dir, includePath)
+		}
+		contents, err = afero.ReadFile(fs, source.resolve(includePath))
+	}
+	location := describeLocation(ref, includePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read include %s: %v", location, err)
+	}
+	if visited[location] {
+		return nil, fmt.Errorf("include cycle detected at %s", location)
+	}
+
+	actualChecksum := IncludeChecksum(contents)
+	if include.Checksum == "" && commitIDPattern.MatchString(include.Ref) {
+		logr.Debugf("Not checking the checksum of include %s, as the commit id pins its content", location)
+	} else if include.Checksum != actualChecksum {
+		return nil, fmt.Errorf("checksum mismatch for include %s: expected %q, but file has checksum %q", location, include.Checksum, actualChecksum)
+	}
+	logr.Debugf("Including rules from %s", location)
+
+	included := TalismanRC{}
+	if err := yaml.Unmarshal(contents, &included); err != nil {
+		return nil, fmt.Errorf("unable to parse include %s: %v", location, err)
+	}
+
+	nestedVisited := map[string]bool{location: true}
+	for k := range visited {
+		nestedVisited[k] = true
+	}
+	nestedSource := includeSource{root: source.root, dir: filepath.Dir(includePath), ref: ref}
+	if ref != "" {
+		nestedSource.dir = path.Dir(includePath)
+	}
+	return included.resolveIncludes(nestedSource, nestedVisited, depth+1)
+}
+
+// resolve returns the location of a file in the working tree, relative to the root of the repository unless it is absolute
+func (source includeSource) resolve(includePath string) string {
+	if filepath.IsAbs(includePath) {
+		return includePath
+	}
+	return filepath.Join(source.root, includePath)
+}
+
+func readIncludeAtRef(root, ref, includePath string) ([]byte, error) {
+	repo, err := gitrepo.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	return repo.ReadFileAtRef(ref, includePath)
+}
+
+func describeLocation(ref, includePath string) string {
+	if ref != "" {
+		return fmt.Sprintf("%s:%s", ref, includePath)
+	}
+	return includePath
+}
+
+// merge appends the rules from other to tRC. Scalar values set in other take precedence.
+func (tRC *TalismanRC) merge(other *TalismanRC) {
+	tRC.FileIgnoreConfig = append(tRC.FileIgnoreConfig, other.FileIgnoreConfig...)
+	tRC.ScopeConfig = append(tRC.ScopeConfig, other.ScopeConfig...)
+	tRC.CustomPatterns = append(tRC.CustomPatterns, other.CustomPatterns...)
+	tRC.CustomSeverities = append(tRC.CustomSeverities, other.CustomSeverities...)
+	tRC.SeverityEscalations = append(tRC.SeverityEscalations, other.SeverityEscalations...)
+	tRC.PathThresholds = append(tRC.PathThresholds, other.PathThresholds...)
+	tRC.AllowedPatterns = append(tRC.AllowedPatterns, other.AllowedPatterns...)
+	tRC.Placeholders = append(tRC.Placeholders, other.Placeholders...)
+	if other.Experimental.Base64EntropyThreshold > 0.0 {
+		tRC.Experimental.Base64EntropyThreshold = other.Experimental.Base64EntropyThreshold
+	}
+	if other.Decoding.MaxDepth != 0 {
+		tRC.Decoding.MaxDepth = other.Decoding.MaxDepth
+	}
+	if other.Archives.MaxDepth != 0 {
+		tRC.Archives.MaxDepth = other.Archives.MaxDepth
+	}
+	if other.Archives.MaxEntrySize != 0 {
+		tRC.Archives.MaxEntrySize = other.Archives.MaxEntrySize
+	}
+	if other.Archives.MaxTotalSize != 0 {
+		tRC.Archives.MaxTotalSize = other.Archives.MaxTotalSize
+	}
+	if other.BinaryFiles.ScanAsText != nil {
+		tRC.BinaryFiles.ScanAsText = other.BinaryFiles.ScanAsText
+	}
+	if other.LFS.ScanObjects != nil {
+		tRC.LFS.ScanObjects = other.LFS.ScanObjects
+	}
+	if other.LFS.MaxObjectSize != 0 {
+		tRC.LFS.MaxObjectSize = other.LFS.MaxObjectSize
+	}
+	if other.Threshold != 0 {
+		tRC.Threshold = other.Threshold
+	}
+}
+
+// EffectiveConfig returns the YAML representation of the rules that are applied after resolving all includes
+func (tRC *TalismanRC) EffectiveConfig() string {
+	result, _ := yaml.Marshal(tRC)
+	return string(result)
+}
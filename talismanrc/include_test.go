@@ -0,0 +1,237 @@
+package talismanrc
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"talisman/detector/severity"
+	"talisman/git_testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const sharedPolicy = `
+custom_patterns:
+- shared-pattern
+allowed_patterns:
+- shared-allowed
+placeholders:
+- ^EXAMPLE
+threshold: high
+`
+
+func rcIncluding(path, checksum string) string {
+	return fmt.Sprintf(`
+include:
+- path: %s
+  checksum: %s
+custom_patterns:
+- local-pattern
+threshold: medium
+`, path, checksum)
+}
+
+func TestLoadingIncludes(t *testing.T) {
+	t.Run("Merges rules from included files before local rules", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		_ = afero.WriteFile(fs, "policies/shared.yml", []byte(sharedPolicy), 0666)
+		_ = afero.WriteFile(fs, RCFileName, []byte(rcIncluding("policies/shared.yml", IncludeChecksum([]byte(sharedPolicy)))), 0666)
+
+		talismanRC, err := Load()
+
+		assert.NoError(t, err)
+		assert.Nil(t, talismanRC.Include)
+		assert.Equal(t, []PatternString{"shared-pattern", "local-pattern"}, talismanRC.CustomPatterns)
+		assert.Equal(t, []*Pattern{{regexp.MustCompile("shared-allowed")}}, talismanRC.AllowedPatterns)
+		assert.Equal(t, []*Pattern{{regexp.MustCompile("^EXAMPLE")}}, talismanRC.Placeholders)
+		assert.Equal(t, severity.Medium, talismanRC.Threshold, "Local scalar values should override included ones")
+	})
+
+	t.Run("Resolves nested includes relative to the including file", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		nested := "custom_patterns:\n- nested-pattern\n"
+		intermediate := fmt.Sprintf("include:\n- path: nested.yml\n  checksum: %s\n", IncludeChecksum([]byte(nested)))
+		_ = afero.WriteFile(fs, "policies/nested.yml", []byte(nested), 0666)
+		_ = afero.WriteFile(fs, "policies/intermediate.yml", []byte(intermediate), 0666)
+		_ = afero.WriteFile(fs, RCFileName, []byte(rcIncluding("policies/intermediate.yml", IncludeChecksum([]byte(intermediate)))), 0666)
+
+		talismanRC, err := Load()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []PatternString{"nested-pattern", "local-pattern"}, talismanRC.CustomPatterns)
+	})
+
+	t.Run("Includes the files listed under extends like those listed under include", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		_ = afero.WriteFile(fs, "shared.yml", []byte(sharedPolicy), 0666)
+		_ = afero.WriteFile(fs, RCFileName, []byte(fmt.Sprintf("extends:\n- path: shared.yml\n  checksum: %s\n", IncludeChecksum([]byte(sharedPolicy)))), 0666)
+
+		talismanRC, err := Load()
+
+		assert.NoError(t, err)
+		assert.Nil(t, talismanRC.Extends)
+		assert.Equal(t, []PatternString{"shared-pattern"}, talismanRC.CustomPatterns)
+	})
+
+	t.Run("Lets the including file turn off options that included files turn on", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		shared := "binary_files:\n  scan_as_text: true\nlfs:\n  scan_objects: true\n"
+		_ = afero.WriteFile(fs, "shared.yml", []byte(shared), 0666)
+		_ = afero.WriteFile(fs, RCFileName, []byte(fmt.Sprintf("include:\n- path: shared.yml\n  checksum: %s\nbinary_files:\n  scan_as_text: false\n", IncludeChecksum([]byte(shared)))), 0666)
+
+		talismanRC, err := Load()
+
+		assert.NoError(t, err)
+		assert.False(t, talismanRC.ScansBinaryFilesAsText(), "Expected the including file to turn scanning binary files as text off")
+		assert.True(t, talismanRC.ScansLFSObjects(), "Expected options the including file does not set to be kept")
+	})
+
+	t.Run("Fails if the checksum of an included file does not match", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		_ = afero.WriteFile(fs, "shared.yml", []byte(sharedPolicy), 0666)
+		_ = afero.WriteFile(fs, RCFileName, []byte(rcIncluding("shared.yml", "not-the-checksum")), 0666)
+
+		_, err := Load()
+
+		assert.ErrorContains(t, err, "checksum mismatch for include shared.yml")
+		assert.ErrorContains(t, err, IncludeChecksum([]byte(sharedPolicy)))
+	})
+
+	t.Run("Fails if the included file does not exist", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		_ = afero.WriteFile(fs, RCFileName, []byte(rcIncluding("missing.yml", "checksum")), 0666)
+
+		_, err := Load()
+
+		assert.ErrorContains(t, err, "unable to read include missing.yml")
+	})
+
+	t.Run("Fails on include cycles", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		second := "include:\n- path: first.yml\n  checksum: irrelevant\n"
+		first := fmt.Sprintf("include:\n- path: second.yml\n  checksum: %s\n", IncludeChecksum([]byte(second)))
+		_ = afero.WriteFile(fs, "first.yml", []byte(first), 0666)
+		_ = afero.WriteFile(fs, "second.yml", []byte(second), 0666)
+		_ = afero.WriteFile(fs, RCFileName, []byte(rcIncluding("first.yml", IncludeChecksum([]byte(first)))), 0666)
+
+		_, err := Load()
+
+		assert.ErrorContains(t, err, "include cycle detected at first.yml")
+	})
+
+	t.Run("Only persists local rules when adding ignores", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		SetFs__(fs)
+		localRC := rcIncluding("shared.yml", IncludeChecksum([]byte(sharedPolicy)))
+		_ = afero.WriteFile(fs, "shared.yml", []byte(sharedPolicy), 0666)
+		_ = afero.WriteFile(fs, RCFileName, []byte(localRC), 0666)
+
+		talismanRC, _ := Load()
+		talismanRC.AddIgnores([]FileIgnoreConfig{{FileName: "Foo", Checksum: "SomeCheckSum"}})
+
+		persisted, _ := afero.ReadFile(fs, RCFileName)
+		persistedRC, _ := talismanRCFromYaml(persisted)
+		assert.Len(t, persistedRC.Include, 1)
+		assert.Equal(t, []PatternString{"local-pattern"}, persistedRC.CustomPatterns)
+		assert.Equal(t, []FileIgnoreConfig{{FileName: "Foo", Checksum: "SomeCheckSum"}}, persistedRC.FileIgnoreConfig)
+	})
+}
+
+func TestLoadingIncludesFromGitRef(t *testing.T) {
+	git_testing.Logger = logrus.WithField("Environment", "Debug")
+	SetFs__(afero.NewOsFs())
+	defer SetFs__(afero.NewMemMapFs())
+
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("policies/shared.yml", sharedPolicy)
+		git.AddAndcommit("*", "add shared policy")
+		git.RemoveFile("policies/shared.yml")
+		git.CreateFileWithContents(RCFileName, fmt.Sprintf(`
+include:
+- path: policies/shared.yml
+  ref: HEAD
+  checksum: %s
+`, IncludeChecksum([]byte(sharedPolicy))))
+
+		wd, _ := os.Getwd()
+		_ = os.Chdir(git.Root())
+		defer func() { _ = os.Chdir(wd) }()
+		talismanRC, err := Load()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []PatternString{"shared-pattern"}, talismanRC.CustomPatterns)
+		assert.Equal(t, severity.High, talismanRC.Threshold)
+	})
+}
+
+func TestLoadingIncludesPinnedToACommitWithoutChecksum(t *testing.T) {
+	git_testing.Logger = logrus.WithField("Environment", "Debug")
+	SetFs__(afero.NewOsFs())
+	defer SetFs__(afero.NewMemMapFs())
+
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("policies/shared.yml", sharedPolicy)
+		git.AddAndcommit("*", "add shared policy")
+		commit := git.LatestCommit()
+		git.CreateFileWithContents(RCFileName, fmt.Sprintf("include:\n- path: policies/shared.yml\n  ref: %s\n", commit))
+
+		wd, _ := os.Getwd()
+		_ = os.Chdir(git.Root())
+		defer func() { _ = os.Chdir(wd) }()
+		talismanRC, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, []PatternString{"shared-pattern"}, talismanRC.CustomPatterns)
+
+		git.CreateFileWithContents(RCFileName, "include:\n- path: policies/shared.yml\n  ref: HEAD\n")
+		_, err = Load()
+		assert.ErrorContains(t, err, "checksum mismatch", "Expected includes from refs that may move to need a checksum")
+	})
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	talismanRC := &TalismanRC{CustomPatterns: []PatternString{"pattern"}, Version: DefaultRCVersion}
+	assert.Equal(t, "custom_patterns:\n- pattern\nversion: \"1.0\"\n", talismanRC.EffectiveConfig())
+}
+
+func TestLoadingAtGitRef(t *testing.T) {
+	git_testing.Logger = logrus.WithField("Environment", "Debug")
+
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		withoutRC := git.LatestCommit()
+		git.CreateFileWithContents("policies/shared.yml", sharedPolicy)
+		git.CreateFileWithContents(RCFileName, fmt.Sprintf(`
+include:
+- path: policies/shared.yml
+  checksum: %s
+`, IncludeChecksum([]byte(sharedPolicy))))
+		git.AddAndcommit("*", "add talismanrc")
+		withRC := git.LatestCommit()
+		git.RemoveFile(RCFileName)
+		git.RemoveFile("policies/shared.yml")
+
+		t.Run("Loads the .talismanrc and includes committed at the ref", func(t *testing.T) {
+			talismanRC, err := LoadAtRef(git.Root(), withRC)
+			assert.NoError(t, err)
+			assert.Equal(t, []PatternString{"shared-pattern"}, talismanRC.CustomPatterns)
+			assert.Equal(t, severity.High, talismanRC.Threshold)
+		})
+
+		t.Run("Creates an empty TalismanRC if the ref has no .talismanrc", func(t *testing.T) {
+			talismanRC, err := LoadAtRef(git.Root(), withoutRC)
+			assert.NoError(t, err)
+			assert.Equal(t, &TalismanRC{Version: DefaultRCVersion}, talismanRC)
+		})
+	})
+}
@@ -2,6 +2,8 @@ package talismanrc
 
 import (
 	"fmt"
+	"path/filepath"
+	"talisman/gitrepo"
 
 	logr "github.com/sirupsen/logrus"
 
@@ -13,28 +15,116 @@ const (
 	// RCFileName represents the name of default file in which all the ignore patterns are configured in new version
 	RCFileName       = ".talismanrc"
 	DefaultRCVersion = "1.0"
+	// DefaultDecodingDepth is enough for e.g. base64 encoded .dockerconfigjson files, whose credentials are base64 encoded again
+	DefaultDecodingDepth = 2
+	// MaxDecodingDepth bounds the decoding depth, as every layer decodes and checks again the texts found in the layer above it
+	MaxDecodingDepth = 5
+	// DefaultArchiveDepth unpacks archives within archives, e.g. the jars in the lib directory of a war
+	DefaultArchiveDepth     = 2
+	DefaultArchiveEntrySize = 10 << 20
+	DefaultArchiveTotalSize = 100 << 20
+	// DefaultLFSObjectSize allows Git LFS objects as large as the entries of archives that are scanned
+	DefaultLFSObjectSize = DefaultArchiveEntrySize
 )
 
 var (
 	fs = afero.NewOsFs()
 )
 
-// Load creates a TalismanRC struct based on a .talismanrc file, if present
+// Load creates a TalismanRC struct based on a .talismanrc file in the working directory, if present.
+// Rules from files listed under `include` are merged into the result.
 func Load() (*TalismanRC, error) {
-	fileContents, err := afero.ReadFile(fs, RCFileName)
+	return LoadFrom("")
+}
+
+// LoadFrom creates a TalismanRC struct based on the .talismanrc file in the root of a repository, if present.
+// Rules from files listed under `include` are merged into the result, and new ignores are saved to the same file.
+func LoadFrom(root string) (*TalismanRC, error) {
+	fileContents, err := afero.ReadFile(fs, filepath.Join(root, RCFileName))
 	if err != nil {
 		// File does not exist or is not readable, proceed as if there is no .talismanrc
 		fileContents = []byte{}
 	}
-	return talismanRCFromYaml(fileContents)
+	talismanRC, err := talismanRCFromYaml(fileContents)
+	if err != nil {
+		logr.Errorf("Unable to parse .talismanrc : %v", err)
+		fmt.Println(fmt.Errorf("\n\x1b[1m\x1b[31mUnable to parse .talismanrc %s. Please ensure it is following the right YAML structure\x1b[0m\x1b[0m", err))
+		return talismanRC, err
+	}
+	talismanRC, err = withIncludesResolved(talismanRC, includeSource{root: root, dir: "."})
+	if err != nil {
+		logr.Errorf("Unable to resolve includes in .talismanrc : %v", err)
+		fmt.Println(fmt.Errorf("\n\x1b[1m\x1b[31mUnable to resolve includes in .talismanrc: %s\x1b[0m\x1b[0m", err))
+		return talismanRC, err
+	}
+	talismanRC.root = root
+	if talismanRC.local != nil {
+		talismanRC.local.root = root
+	}
+	return talismanRC, nil
+}
+
+// Parse creates a TalismanRC struct from the contents of a .talismanrc file located in dir, without reporting errors to the console.
+// Rules from files listed under `include` are resolved relative to dir.
+func Parse(fileContents []byte, dir string) (*TalismanRC, error) {
+	talismanRC, err := talismanRCFromYaml(fileContents)
+	if err != nil {
+		return talismanRC, fmt.Errorf("unable to parse %s: %v", RCFileName, err)
+	}
+	talismanRC, err = withIncludesResolved(talismanRC, includeSource{root: dir, dir: "."})
+	if err != nil {
+		return talismanRC, fmt.Errorf("unable to resolve includes in %s: %v", RCFileName, err)
+	}
+	return talismanRC, nil
+}
+
+// LoadAtRef creates a TalismanRC struct from the .talismanrc file committed at a git ref of the repository located at root,
+// e.g. to apply the configuration of pushed commits in a bare repository. Files listed under `include` are read from the same ref.
+// If the ref has no .talismanrc file, an empty TalismanRC is returned.
+func LoadAtRef(root, ref string) (*TalismanRC, error) {
+	repo, err := gitrepo.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	if !repo.FileExistsAtRef(ref, RCFileName) {
+		return &TalismanRC{Version: DefaultRCVersion}, nil
+	}
+	fileContents, err := repo.ReadFileAtRef(ref, RCFileName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s:%s: %v", ref, RCFileName, err)
+	}
+	talismanRC, err := talismanRCFromYaml(fileContents)
+	if err != nil {
+		return talismanRC, fmt.Errorf("unable to parse %s:%s: %v", ref, RCFileName, err)
+	}
+	talismanRC, err = withIncludesResolved(talismanRC, includeSource{root: root, dir: ".", ref: ref})
+	if err != nil {
+		return talismanRC, fmt.Errorf("unable to resolve includes in %s:%s: %v", ref, RCFileName, err)
+	}
+	return talismanRC, nil
+}
+
+func withIncludesResolved(talismanRC *TalismanRC, source includeSource) (*TalismanRC, error) {
+	merged, err := talismanRC.resolveIncludes(source, map[string]bool{}, 0)
+	if err != nil {
+		return &TalismanRC{}, err
+	}
+	if merged != talismanRC {
+		merged.local = talismanRC
+	}
+	for _, pattern := range merged.MultilineAllowedPatterns() {
+		logr.Warnf("allowed pattern %q matches across lines, but allowed patterns are matched against single lines, so it never allows anything", pattern)
+	}
+	if merged.Decoding.MaxDepth > MaxDecodingDepth {
+		logr.Warnf("decoding max_depth %d is above the maximum of %d, so only %d layers of encoding are decoded", merged.Decoding.MaxDepth, MaxDecodingDepth, MaxDecodingDepth)
+	}
+	return merged, nil
 }
 
 func talismanRCFromYaml(fileContents []byte) (*TalismanRC, error) {
 	talismanRCFromFile := TalismanRC{}
 	err := yaml.Unmarshal(fileContents, &talismanRCFromFile)
 	if err != nil {
-		logr.Errorf("Unable to parse .talismanrc : %v", err)
-		fmt.Println(fmt.Errorf("\n\x1b[1m\x1b[31mUnable to parse .talismanrc %s. Please ensure it is following the right YAML structure\x1b[0m\x1b[0m", err))
 		return &TalismanRC{}, err
 	}
 	if talismanRCFromFile.Version == "" {
@@ -44,8 +134,12 @@ func talismanRCFromYaml(fileContents []byte) (*TalismanRC, error) {
 }
 
 func (tRC *TalismanRC) saveToFile() {
+	if tRC.local != nil {
+		// Only the rules of the .talismanrc itself are persisted, never the included ones
+		tRC = tRC.local
+	}
 	ignoreEntries, _ := yaml.Marshal(&tRC)
-	err := afero.WriteFile(fs, RCFileName, ignoreEntries, 0644)
+	err := afero.WriteFile(fs, filepath.Join(tRC.root, RCFileName), ignoreEntries, 0644)
 	if err != nil {
 		logr.Errorf("error writing to %s: %s", RCFileName, err)
 	}
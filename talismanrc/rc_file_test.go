@@ -1,6 +1,7 @@
 package talismanrc
 
 import (
+	"path/filepath"
 	"regexp"
 	"talisman/detector/severity"
 	"testing"
@@ -29,7 +30,7 @@ func TestLoadingFromFile(t *testing.T) {
 		expectedTalismanRC := &TalismanRC{
 			FileIgnoreConfig: []FileIgnoreConfig{
 				{FileName: "existing.pem", Checksum: "123444ddssa75333b25b6275f97680604add51b84eb8f4a3b9dcbbc652e6f27ac"}},
-			ScopeConfig: []ScopeConfig{{"go"}},
+			ScopeConfig: []ScopeConfig{{ScopeName: "go"}},
 			AllowedPatterns: []*Pattern{
 				{regexp.MustCompile("this-is-okay")},
 				{regexp.MustCompile("key={listOfThings.id}")}},
@@ -132,3 +133,21 @@ func TestShouldIgnoreUnformattedFiles(t *testing.T) {
 		assert.Equal(t, &TalismanRC{Version: "1.0"}, talismanRC, "Expected commented line '%s' to result in an empty TalismanRC")
 	}
 }
+
+func TestLoadingFromRepositoryRoot(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	SetFs__(fs)
+	root := filepath.Join("some", "repo")
+	err := afero.WriteFile(fs, filepath.Join(root, RCFileName), []byte("threshold: high\n"), 0666)
+	assert.NoError(t, err, "Problem setting up test .talismanrc?")
+
+	tRC, err := LoadFrom(root)
+	assert.NoError(t, err)
+	assert.Equal(t, severity.High, tRC.Threshold)
+
+	tRC.saveToFile()
+	inCurrentDirectory, _ := afero.Exists(fs, RCFileName)
+	assert.False(t, inCurrentDirectory, "Should not save to the current directory")
+	fileContents, _ := afero.ReadFile(fs, filepath.Join(root, RCFileName))
+	assert.Contains(t, string(fileContents), "threshold: high")
+}
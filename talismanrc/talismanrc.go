@@ -1,7 +1,9 @@
 package talismanrc
 
 import (
+	"regexp"
 	"sort"
+	"strings"
 
 	logr "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -12,14 +14,28 @@ import (
 )
 
 type TalismanRC struct {
-	FileIgnoreConfig []FileIgnoreConfig     `yaml:"fileignoreconfig,omitempty"`
-	ScopeConfig      []ScopeConfig          `yaml:"scopeconfig,omitempty"`
-	CustomPatterns   []PatternString        `yaml:"custom_patterns,omitempty"`
-	CustomSeverities []CustomSeverityConfig `yaml:"custom_severities,omitempty"`
-	AllowedPatterns  []*Pattern             `yaml:"allowed_patterns,omitempty"`
-	Experimental     ExperimentalConfig     `yaml:"experimental,omitempty"`
-	Threshold        severity.Severity      `yaml:"threshold,omitempty"`
-	Version          string                 `yaml:"version"`
+	Include             []IncludeConfig            `yaml:"include,omitempty"`
+	Extends             []IncludeConfig            `yaml:"extends,omitempty"`
+	FileIgnoreConfig    []FileIgnoreConfig         `yaml:"fileignoreconfig,omitempty"`
+	ScopeConfig         []ScopeConfig              `yaml:"scopeconfig,omitempty"`
+	CustomPatterns      []PatternString            `yaml:"custom_patterns,omitempty"`
+	CustomSeverities    []CustomSeverityConfig     `yaml:"custom_severities,omitempty"`
+	SeverityEscalations []SeverityEscalationConfig `yaml:"severity_escalations,omitempty"`
+	AllowedPatterns     []*Pattern                 `yaml:"allowed_patterns,omitempty"`
+	Placeholders        []*Pattern                 `yaml:"placeholders,omitempty"`
+	Experimental        ExperimentalConfig         `yaml:"experimental,omitempty"`
+	Decoding            DecodingConfig             `yaml:"decoding,omitempty"`
+	Archives            ArchiveConfig              `yaml:"archives,omitempty"`
+	BinaryFiles         BinaryFilesConfig          `yaml:"binary_files,omitempty"`
+	LFS                 LFSConfig                  `yaml:"lfs,omitempty"`
+	Threshold           severity.Severity          `yaml:"threshold,omitempty"`
+	PathThresholds      []PathThresholdConfig      `yaml:"path_thresholds,omitempty"`
+	Version             string                     `yaml:"version"`
+
+	// local holds the rules of the .talismanrc file itself when they were merged with included files
+	local *TalismanRC
+	// root is the directory of the .talismanrc file, or "" for the working directory
+	root string
 }
 
 // SuggestRCFor returns a string representation of a .talismanrc for the specified FileIgnoreConfigs
@@ -32,40 +48,42 @@ func SuggestRCFor(configs []FileIgnoreConfig) string {
 
 // RemoveScopedFiles removes scope files from additions
 func (tRC *TalismanRC) RemoveScopedFiles(additions []gitrepo.Addition) []gitrepo.Addition {
-	var applicableScopeFileNames []string
-	if tRC.ScopeConfig != nil {
-		for _, scope := range tRC.ScopeConfig {
-			if len(knownScopes[scope.ScopeName]) > 0 {
-				applicableScopeFileNames = append(applicableScopeFileNames, knownScopes[scope.ScopeName]...)
-			}
-		}
-	}
 	var result []gitrepo.Addition
 	for _, addition := range additions {
-		isFilePresentInScope := false
-		for _, fileName := range applicableScopeFileNames {
-			if addition.NameMatches(fileName) {
-				isFilePresentInScope = true
-				break
-			}
-		}
-		if !isFilePresentInScope {
+		if tRC.ScopeOf(addition) == nil {
 			result = append(result, addition)
 		}
 	}
 	return result
 }
 
+// ScopeOf returns the first scope that covers the addition, or nil if no scope does
+func (tRC *TalismanRC) ScopeOf(addition gitrepo.Addition) *ScopeConfig {
+	for i := range tRC.ScopeConfig {
+		if addition.MatchesAny(tRC.ScopeConfig[i].patterns()) {
+			return &tRC.ScopeConfig[i]
+		}
+	}
+	return nil
+}
+
 // AddIgnores inserts the specified FileIgnoreConfigs to an existing .talismanrc file, or creates one if it doesn't exist.
 func (tRC *TalismanRC) AddIgnores(entriesToAdd []FileIgnoreConfig) {
 	if len(entriesToAdd) > 0 {
 		logr.Debugf("Adding entries: %v", entriesToAdd)
 		tRC.FileIgnoreConfig = combineFileIgnores(tRC.FileIgnoreConfig, entriesToAdd)
+		if tRC.local != nil {
+			tRC.local.FileIgnoreConfig = combineFileIgnores(tRC.local.FileIgnoreConfig, entriesToAdd)
+		}
 		tRC.saveToFile()
 	}
 }
 
 func combineFileIgnores(exsiting, incoming []FileIgnoreConfig) []FileIgnoreConfig {
+	if hasNegatedEntries(exsiting) || hasNegatedEntries(incoming) {
+		// Negated entries only apply to the entries declared before them, so the order has to be preserved
+		return combineFileIgnoresInOrder(exsiting, incoming)
+	}
 	existingMap := make(map[string]FileIgnoreConfig)
 	for _, fIC := range exsiting {
 		existingMap[fIC.FileName] = fIC
@@ -91,45 +109,219 @@ func combineFileIgnores(exsiting, incoming []FileIgnoreConfig) []FileIgnoreConfi
 	return result
 }
 
-// RemoveAllowedPatterns removes globally- and per-file allowed patterns from an Addition
-func (tRC *TalismanRC) RemoveAllowedPatterns(addition gitrepo.Addition) string {
-	// Processing global allowed patterns
+func combineFileIgnoresInOrder(exsiting, incoming []FileIgnoreConfig) []FileIgnoreConfig {
+	incomingIndex := make(map[string]int)
+	for i, fIC := range incoming {
+		incomingIndex[fIC.FileName] = i
+	}
+	var result []FileIgnoreConfig
+	for _, fIC := range exsiting {
+		if i, replaced := incomingIndex[fIC.FileName]; replaced {
+			fIC = incoming[i]
+			delete(incomingIndex, fIC.FileName)
+		}
+		result = append(result, fIC)
+	}
+	for i, fIC := range incoming {
+		if index, pending := incomingIndex[fIC.FileName]; pending && index == i {
+			result = append(result, fIC)
+		}
+	}
+	return result
+}
+
+func hasNegatedEntries(fileIgnores []FileIgnoreConfig) bool {
+	for _, fIC := range fileIgnores {
+		if fIC.IsNegated() {
+			return true
+		}
+	}
+	return false
+}
+
+// FilePatternsFor returns the gitignore-style patterns that select the files covered by the FileIgnoreConfig at the given index:
+// its own filename, followed by the negated filenames of all entries declared after it.
+func (tRC *TalismanRC) FilePatternsFor(index int) []string {
+	patterns := []string{tRC.FileIgnoreConfig[index].FileName}
+	for _, later := range tRC.FileIgnoreConfig[index+1:] {
+		if later.IsNegated() {
+			patterns = append(patterns, later.FileName)
+		}
+	}
+	return patterns
+}
+
+func (tRC *TalismanRC) fileIgnoreAppliesTo(index int, addition gitrepo.Addition) bool {
+	return !tRC.FileIgnoreConfig[index].IsNegated() && addition.MatchesAny(tRC.FilePatternsFor(index))
+}
+
+// FileIgnoreConfigFor returns the first FileIgnoreConfig covering the addition, along with the patterns that select its files.
+// It returns nil if no entry covers the addition.
+func (tRC *TalismanRC) FileIgnoreConfigFor(addition gitrepo.Addition) (*FileIgnoreConfig, []string) {
+	for index := range tRC.FileIgnoreConfig {
+		if tRC.fileIgnoreAppliesTo(index, addition) {
+			return &tRC.FileIgnoreConfig[index], tRC.FilePatternsFor(index)
+		}
+	}
+	return nil, nil
+}
+
+// AllowedPatternFor returns the first globally- or per-file allowed pattern that covers the secret at line[start:end] in an Addition.
+// A pattern only covers the secret if one of its matches within the line overlaps the secret.
+func (tRC *TalismanRC) AllowedPatternFor(addition gitrepo.Addition, line string, start, end int) (string, bool) {
 	for _, pattern := range tRC.AllowedPatterns {
-		addition.Data = pattern.ReplaceAll(addition.Data, []byte(""))
+		if overlaps(pattern.Regexp, line, start, end) {
+			return pattern.String(), true
+		}
+	}
+	for index := range tRC.FileIgnoreConfig {
+		if tRC.fileIgnoreAppliesTo(index, addition) {
+			for _, pattern := range tRC.FileIgnoreConfig[index].GetAllowedPatterns() {
+				if overlaps(pattern, line, start, end) {
+					return pattern.String(), true
+				}
+			}
+		}
 	}
+	return "", false
+}
 
-	// Processing allowed patterns based on file path
+// MultilineAllowedPatterns returns the globally- and per-file allowed patterns that only match across several lines.
+// As allowed patterns are matched against the line of each finding, such patterns never allow anything.
+func (tRC *TalismanRC) MultilineAllowedPatterns() []string {
+	var multiline []string
+	for _, pattern := range tRC.AllowedPatterns {
+		if spansLines(pattern.String()) {
+			multiline = append(multiline, pattern.String())
+		}
+	}
 	for _, ignoreConfig := range tRC.FileIgnoreConfig {
-		if addition.Matches(ignoreConfig.GetFileName()) {
-			for _, pattern := range ignoreConfig.GetAllowedPatterns() {
-				addition.Data = pattern.ReplaceAll(addition.Data, []byte(""))
+		for _, pattern := range ignoreConfig.AllowedPatterns {
+			if spansLines(pattern) {
+				multiline = append(multiline, pattern)
 			}
 		}
 	}
-	return string(addition.Data)
+	return multiline
 }
 
-// Deny answers true if the Addition should NOT be checked by the specified detector
-func (tRC *TalismanRC) Deny(addition gitrepo.Addition, detectorName string) bool {
-	for _, pattern := range tRC.effectiveRules(detectorName) {
-		if addition.Matches(pattern) {
+func spansLines(pattern string) bool {
+	return strings.Contains(pattern, "\n") || strings.Contains(pattern, `\n`)
+}
+
+func overlaps(pattern *regexp.Regexp, line string, start, end int) bool {
+	for _, match := range pattern.FindAllStringIndex(line, -1) {
+		if match[0] < end && match[1] > start {
 			return true
 		}
 	}
 	return false
 }
 
+// Deny answers true if the Addition should NOT be checked by the specified detector
+func (tRC *TalismanRC) Deny(addition gitrepo.Addition, detectorName string) bool {
+	return tRC.DeniedBy(addition, detectorName) != nil
+}
+
+// DeniedBy returns the FileIgnoreConfig that prevents the specified detector from checking the Addition, or nil if there is none
+func (tRC *TalismanRC) DeniedBy(addition gitrepo.Addition, detectorName string) *FileIgnoreConfig {
+	for index, ignore := range tRC.FileIgnoreConfig {
+		if ignore.isEffective(detectorName) && tRC.fileIgnoreAppliesTo(index, addition) {
+			return &tRC.FileIgnoreConfig[index]
+		}
+	}
+	return nil
+}
+
 // Accept answers true if the Addition should be checked by the specified detector
 func (tRC *TalismanRC) Accept(addition gitrepo.Addition, detectorName string) bool {
 	return !tRC.Deny(addition, detectorName)
 }
 
-func (tRC *TalismanRC) effectiveRules(detectorName string) []string {
-	var result []string
-	for _, ignore := range tRC.FileIgnoreConfig {
-		if ignore.isEffective(detectorName) {
-			result = append(result, ignore.GetFileName())
+// SeverityConfiguration returns the severity of each rule for a run, with the custom_severities of the .talismanrc applied.
+// Each call returns a new Configuration, so that runs with different .talismanrc files never affect each other.
+func (tRC *TalismanRC) SeverityConfiguration() severity.Configuration {
+	configuration := severity.DefaultConfiguration()
+	for _, cs := range tRC.CustomSeverities {
+		if !severity.IsKnownRule(cs.Detector) {
+			logr.Warnf("ignoring custom severity for unknown detector '%s'", cs.Detector)
+			continue
 		}
+		configuration[cs.Detector] = cs.Severity
 	}
-	return result
+	return configuration
+}
+
+// PathThresholdFor returns the last path_thresholds entry matching the Addition, or nil if the global threshold applies to it
+func (tRC *TalismanRC) PathThresholdFor(addition gitrepo.Addition) *PathThresholdConfig {
+	for index := len(tRC.PathThresholds) - 1; index >= 0; index-- {
+		if addition.Matches(tRC.PathThresholds[index].Path) {
+			return &tRC.PathThresholds[index]
+		}
+	}
+	return nil
+}
+
+// EscalationFor returns the severity_escalations entry that raises a finding of the given severity in the Addition the most,
+// or nil if no entry raises it
+func (tRC *TalismanRC) EscalationFor(addition gitrepo.Addition, findingSeverity severity.Severity) *SeverityEscalationConfig {
+	var escalation *SeverityEscalationConfig
+	for index, candidate := range tRC.SeverityEscalations {
+		if candidate.Severity > findingSeverity && addition.Matches(candidate.Path) {
+			if escalation == nil || candidate.Severity > escalation.Severity {
+				escalation = &tRC.SeverityEscalations[index]
+			}
+		}
+	}
+	return escalation
+}
+
+// DecodingDepth returns how many layers of encoding are decoded to look for secrets inside encoded texts, 0 meaning none.
+// Depths above MaxDecodingDepth are capped to it.
+func (tRC *TalismanRC) DecodingDepth() int {
+	switch {
+	case tRC.Decoding.MaxDepth < 0:
+		return 0
+	case tRC.Decoding.MaxDepth == 0:
+		return DefaultDecodingDepth
+	case tRC.Decoding.MaxDepth > MaxDecodingDepth:
+		return MaxDecodingDepth
+	}
+	return tRC.Decoding.MaxDepth
+}
+
+// ArchiveLimits returns the limits for unpacking archives, with the defaults applied to the limits the .talismanrc does not set.
+// A MaxDepth of 0 in the result means that archives are not unpacked.
+func (tRC *TalismanRC) ArchiveLimits() ArchiveConfig {
+	limits := ArchiveConfig{MaxDepth: DefaultArchiveDepth, MaxEntrySize: DefaultArchiveEntrySize, MaxTotalSize: DefaultArchiveTotalSize}
+	if tRC.Archives.MaxDepth < 0 {
+		limits.MaxDepth = 0
+	} else if tRC.Archives.MaxDepth > 0 {
+		limits.MaxDepth = tRC.Archives.MaxDepth
+	}
+	if tRC.Archives.MaxEntrySize > 0 {
+		limits.MaxEntrySize = tRC.Archives.MaxEntrySize
+	}
+	if tRC.Archives.MaxTotalSize > 0 {
+		limits.MaxTotalSize = tRC.Archives.MaxTotalSize
+	}
+	return limits
+}
+
+// ScansBinaryFilesAsText answers if binary files are scanned like text files, as the .talismanrc may ask
+func (tRC *TalismanRC) ScansBinaryFilesAsText() bool {
+	return tRC.BinaryFiles.ScanAsText != nil && *tRC.BinaryFiles.ScanAsText
+}
+
+// ScansLFSObjects answers if the Git LFS objects found in the local store are scanned in place of their pointers
+func (tRC *TalismanRC) ScansLFSObjects() bool {
+	return tRC.LFS.ScanObjects != nil && *tRC.LFS.ScanObjects
+}
+
+// LFSObjectSizeLimit returns the size in bytes above which Git LFS objects are not scanned
+func (tRC *TalismanRC) LFSObjectSizeLimit() int64 {
+	if tRC.LFS.MaxObjectSize > 0 {
+		return tRC.LFS.MaxObjectSize
+	}
+	return DefaultLFSObjectSize
 }
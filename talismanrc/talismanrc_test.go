@@ -38,43 +38,67 @@ func init() {
 	logr.SetOutput(io.Discard)
 }
 
-func TestShouldFilterAllowedPatternsFromAddition(t *testing.T) {
+func TestShouldAllowFindingsMatchedByAllowedPatterns(t *testing.T) {
 	const hex string = "68656C6C6F20776F726C6421"
-	const fileContent string = "Prefix content" + hex
-	gitRepoAddition1 := testAdditionWithData("file1", []byte(fileContent))
+	const line string = "Prefix content" + hex
+	gitRepoAddition1 := testAdditionWithData("file1", []byte(line))
 	talismanrc := &TalismanRC{AllowedPatterns: []*Pattern{{regexp.MustCompile(hex)}}}
 
-	fileContentFiltered := talismanrc.RemoveAllowedPatterns(gitRepoAddition1)
+	pattern, allowed := talismanrc.AllowedPatternFor(gitRepoAddition1, line, 14, len(line))
 
-	assert.Equal(t, fileContentFiltered, "Prefix content")
+	assert.True(t, allowed)
+	assert.Equal(t, hex, pattern)
 }
 
-func TestShouldFilterAllowedPatternsFromAdditionBasedOnFileConfig(t *testing.T) {
+func TestShouldOnlyAllowFindingsOverlappingTheAllowedPattern(t *testing.T) {
+	const line string = "user=admin password=hunter2"
+	talismanrc := &TalismanRC{AllowedPatterns: []*Pattern{{regexp.MustCompile("admin")}}}
+
+	_, allowed := talismanrc.AllowedPatternFor(testAdditionWithData("file1", []byte(line)), line, 11, len(line))
+
+	assert.False(t, allowed, "Allowed patterns elsewhere on the line should not allow the finding")
+}
+
+func TestShouldTellAllowedPatternsMatchingAcrossLines(t *testing.T) {
+	tRC, err := Parse([]byte(`
+allowed_patterns:
+- this-is-okay
+- 'BEGIN CERTIFICATE-----\n.*'
+fileignoreconfig:
+- filename: certs/ca.pem
+  allowed_patterns: ["issuer:\nAcme"]
+`), ".")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`BEGIN CERTIFICATE-----\n.*`, "issuer:\nAcme"}, tRC.MultilineAllowedPatterns())
+}
+
+func TestShouldAllowFindingsBasedOnFileConfig(t *testing.T) {
 	const hexContent string = "68656C6C6F20776F726C6421"
-	const fileContent string = "Prefix content" + hexContent
-	gitRepoAddition1 := testAdditionWithData("file1", []byte(fileContent))
-	gitRepoAddition2 := testAdditionWithData("file2", []byte(fileContent))
+	const line string = "Prefix content" + hexContent
+	gitRepoAddition1 := testAdditionWithData("file1", []byte(line))
+	gitRepoAddition2 := testAdditionWithData("file2", []byte(line))
 	talismanrc := createTalismanRCWithFileIgnores("file1", "somedetector", []string{hexContent})
 
-	fileContentFiltered1 := talismanrc.RemoveAllowedPatterns(gitRepoAddition1)
-	fileContentFiltered2 := talismanrc.RemoveAllowedPatterns(gitRepoAddition2)
+	_, allowed1 := talismanrc.AllowedPatternFor(gitRepoAddition1, line, 14, len(line))
+	_, allowed2 := talismanrc.AllowedPatternFor(gitRepoAddition2, line, 14, len(line))
 
-	assert.Equal(t, fileContentFiltered1, "Prefix content")
-	assert.Equal(t, fileContentFiltered2, fileContent)
+	assert.True(t, allowed1)
+	assert.False(t, allowed2)
 }
 
-func TestShouldFilterAllowedPatternsFromAdditionBasedOnFileConfigWithWildcards(t *testing.T) {
+func TestShouldAllowFindingsBasedOnFileConfigWithWildcards(t *testing.T) {
 	const hexContent string = "68656C6C6F20776F726C6421"
-	const fileContent string = "Prefix content" + hexContent
-	gitRepoAddition1 := testAdditionWithData("foo/file1.yml", []byte(fileContent))
-	gitRepoAddition2 := testAdditionWithData("foo/file2.yml", []byte(fileContent))
+	const line string = "Prefix content" + hexContent
+	gitRepoAddition1 := testAdditionWithData("foo/file1.yml", []byte(line))
+	gitRepoAddition2 := testAdditionWithData("foo/file2.yml", []byte(line))
 	talismanrc := createTalismanRCWithFileIgnores("foo/*.yml", "somedetector", []string{hexContent})
 
-	fileContentFiltered1 := talismanrc.RemoveAllowedPatterns(gitRepoAddition1)
-	fileContentFiltered2 := talismanrc.RemoveAllowedPatterns(gitRepoAddition2)
+	_, allowed1 := talismanrc.AllowedPatternFor(gitRepoAddition1, line, 14, len(line))
+	_, allowed2 := talismanrc.AllowedPatternFor(gitRepoAddition2, line, 14, len(line))
 
-	assert.Equal(t, fileContentFiltered1, "Prefix content")
-	assert.Equal(t, fileContentFiltered2, "Prefix content")
+	assert.True(t, allowed1)
+	assert.True(t, allowed2)
 }
 
 func TestDirectoryPatterns(t *testing.T) {
@@ -85,6 +109,63 @@ func TestDirectoryPatterns(t *testing.T) {
 	assertDenies("foo/", "filename", "foo/bar/baz.txt", t)
 }
 
+func TestGitignoreStylePatterns(t *testing.T) {
+	assertDenies("**/fixtures/**", "filename", "src/test/fixtures/data.json", t)
+	assertDenies("/config.yml", "filename", "config.yml", t)
+	assertAccepts("/config.yml", "filename", "sub/config.yml", t)
+}
+
+func TestNegatedFileIgnores(t *testing.T) {
+	talismanRC := &TalismanRC{FileIgnoreConfig: []FileIgnoreConfig{
+		{FileName: "test/fixtures/", IgnoreDetectors: []string{"filecontent"}, AllowedPatterns: []string{"secret"}},
+		{FileName: "!**/*.pem"},
+	}}
+
+	t.Run("should exclude files matched by a later negated entry", func(t *testing.T) {
+		assert.True(t, talismanRC.Deny(testAddition("test/fixtures/data.json"), "filecontent"))
+		assert.False(t, talismanRC.Deny(testAddition("test/fixtures/key.pem"), "filecontent"))
+	})
+
+	t.Run("should not apply allowed patterns to excluded files", func(t *testing.T) {
+		_, allowed := talismanRC.AllowedPatternFor(testAddition("test/fixtures/data.json"), "secret data", 0, 6)
+		assert.True(t, allowed)
+		_, allowed = talismanRC.AllowedPatternFor(testAddition("test/fixtures/key.pem"), "secret key", 0, 6)
+		assert.False(t, allowed)
+	})
+
+	t.Run("should return the entry covering an addition with its patterns", func(t *testing.T) {
+		ignore, patterns := talismanRC.FileIgnoreConfigFor(testAddition("test/fixtures/data.json"))
+		assert.Equal(t, &talismanRC.FileIgnoreConfig[0], ignore)
+		assert.Equal(t, []string{"test/fixtures/", "!**/*.pem"}, patterns)
+
+		ignore, _ = talismanRC.FileIgnoreConfigFor(testAddition("test/fixtures/key.pem"))
+		assert.Nil(t, ignore)
+	})
+
+	t.Run("should preserve the order of entries when adding ignores", func(t *testing.T) {
+		combined := combineFileIgnores(talismanRC.FileIgnoreConfig, []FileIgnoreConfig{{FileName: "a.txt", Checksum: "sha"}})
+		assert.Equal(t, []string{"test/fixtures/", "!**/*.pem", "a.txt"},
+			[]string{combined[0].FileName, combined[1].FileName, combined[2].FileName})
+	})
+}
+
+func TestIgnoreAdditionsByScopePaths(t *testing.T) {
+	talismanRC := &TalismanRC{ScopeConfig: []ScopeConfig{
+		{ScopeName: "fixtures", Paths: []string{"**/fixtures/", "!**/*.pem"}},
+		{ScopeName: "go", Paths: []string{"!vendor/go.sum"}},
+	}}
+	additions := []gitrepo.Addition{
+		testAddition("test/fixtures/data.json"),
+		testAddition("test/fixtures/key.pem"),
+		testAddition("go.sum"),
+		testAddition("vendor/go.sum"),
+	}
+
+	filteredAdditions := talismanRC.RemoveScopedFiles(additions)
+
+	assert.Equal(t, []gitrepo.Addition{testAddition("test/fixtures/key.pem"), testAddition("vendor/go.sum")}, filteredAdditions)
+}
+
 func TestIgnoreAdditionsByScope(t *testing.T) {
 	testTable := map[string][]gitrepo.Addition{
 		"node": {
@@ -171,7 +252,7 @@ func TestAddingFileIgnores(t *testing.T) {
 			FileIgnoreConfig: []FileIgnoreConfig{
 				ignoreConfig,
 				{FileName: "existing.pem", Checksum: "123444ddssa75333b25b6275f97680604add51b84eb8f4a3b9dcbbc652e6f27ac"}},
-			ScopeConfig: []ScopeConfig{{"go"}},
+			ScopeConfig: []ScopeConfig{{ScopeName: "go"}},
 			AllowedPatterns: []*Pattern{
 				{regexp.MustCompile("this-is-okay")},
 				{regexp.MustCompile("key={listOfThings.id}")}},
@@ -267,3 +348,57 @@ version: "1.0"
 		assert.Equal(t, expectedRC, str)
 	})
 }
+
+func TestSeverityConfiguration(t *testing.T) {
+	tRC := &TalismanRC{CustomSeverities: []CustomSeverityConfig{
+		{Detector: "Base64Content", Severity: severity.Low},
+		{Detector: "NoSuchDetector", Severity: severity.Low},
+	}}
+
+	configuration := tRC.SeverityConfiguration()
+
+	assert.Equal(t, severity.Low, configuration.SeverityOf("Base64Content"))
+	assert.Equal(t, severity.High, configuration.SeverityOf("HexContent"))
+	assert.NotContains(t, configuration, "NoSuchDetector")
+	assert.Equal(t, severity.High, (&TalismanRC{}).SeverityConfiguration().SeverityOf("Base64Content"), "Custom severities should not leak into other runs")
+}
+
+func TestPathThresholdsAndEscalations(t *testing.T) {
+	tRC, err := talismanRCFromYaml([]byte(`
+threshold: medium
+path_thresholds:
+- path: deploy/
+  threshold: low
+- path: deploy/sandbox/
+  threshold: high
+severity_escalations:
+- path: "*prod*"
+  severity: high
+- path: "config/prod/"
+  severity: critical
+`))
+	assert.NoError(t, err)
+
+	t.Run("should use the last matching path threshold", func(t *testing.T) {
+		assert.Nil(t, tRC.PathThresholdFor(testAddition("src/app.yml")))
+		assert.Equal(t, severity.Low, tRC.PathThresholdFor(testAddition("deploy/app.yml")).Threshold)
+		assert.Equal(t, severity.High, tRC.PathThresholdFor(testAddition("deploy/sandbox/app.yml")).Threshold)
+	})
+
+	t.Run("should use the highest matching escalation", func(t *testing.T) {
+		assert.Nil(t, tRC.EscalationFor(testAddition("config/app.yml"), severity.Low))
+		assert.Equal(t, severity.High, tRC.EscalationFor(testAddition("config/prod.yml"), severity.Low).Severity)
+		assert.Equal(t, severity.Critical, tRC.EscalationFor(testAddition("config/prod/app.yml"), severity.Low).Severity)
+	})
+
+	t.Run("should never lower the severity of a finding", func(t *testing.T) {
+		assert.Nil(t, tRC.EscalationFor(testAddition("prod.yml"), severity.Critical))
+	})
+}
+
+func TestDecodingDepth(t *testing.T) {
+	assert.Equal(t, DefaultDecodingDepth, (&TalismanRC{}).DecodingDepth())
+	assert.Equal(t, 4, (&TalismanRC{Decoding: DecodingConfig{MaxDepth: 4}}).DecodingDepth())
+	assert.Equal(t, 0, (&TalismanRC{Decoding: DecodingConfig{MaxDepth: -1}}).DecodingDepth(), "A negative depth should turn decoding off")
+	assert.Equal(t, MaxDecodingDepth, (&TalismanRC{Decoding: DecodingConfig{MaxDepth: 1000}}).DecodingDepth(), "Expected the depth to be capped")
+}
@@ -3,6 +3,7 @@ package talismanrc
 import (
 	"regexp"
 	"talisman/detector/severity"
+	"talisman/gitrepo"
 
 	logr "github.com/sirupsen/logrus"
 )
@@ -33,6 +34,18 @@ type CustomSeverityConfig struct {
 	Severity severity.Severity `yaml:"severity"`
 }
 
+// PathThresholdConfig overrides the severity threshold for the files matching Path
+type PathThresholdConfig struct {
+	Path      string            `yaml:"path"`
+	Threshold severity.Severity `yaml:"threshold"`
+}
+
+// SeverityEscalationConfig raises all findings in the files matching Path to at least Severity
+type SeverityEscalationConfig struct {
+	Path     string            `yaml:"path"`
+	Severity severity.Severity `yaml:"severity"`
+}
+
 type FileIgnoreConfig struct {
 	FileName        string   `yaml:"filename"`
 	Checksum        string   `yaml:"checksum,omitempty"`
@@ -44,9 +57,15 @@ type FileIgnoreConfig struct {
 
 func (i *FileIgnoreConfig) isEffective(detectorName string) bool {
 	return !isEmptyString(i.FileName) &&
+		!i.IsNegated() &&
 		contains(i.IgnoreDetectors, detectorName)
 }
 
+// IsNegated reports whether the entry excludes files from the entries declared before it, like "!pattern" in a .gitignore
+func (i *FileIgnoreConfig) IsNegated() bool {
+	return gitrepo.IsNegatedPattern(i.FileName)
+}
+
 func (i *FileIgnoreConfig) GetFileName() string {
 	return i.FileName
 }
@@ -69,8 +88,43 @@ func IgnoreFileWithChecksum(filename, checksum string) FileIgnoreConfig {
 	return FileIgnoreConfig{FileName: filename, Checksum: checksum}
 }
 
+// ScopeConfig ignores a well-known group of files (see knownScopes), and/or the files matched by Paths.
+// Paths are gitignore-style patterns, evaluated after the patterns of the known scope.
 type ScopeConfig struct {
-	ScopeName string `yaml:"scope"`
+	ScopeName string   `yaml:"scope"`
+	Paths     []string `yaml:"paths,omitempty"`
+}
+
+func (s ScopeConfig) patterns() []string {
+	return append(append([]string{}, knownScopes[s.ScopeName]...), s.Paths...)
+}
+
+// DecodingConfig sets how many layers of base64, hex and URL encoding are decoded before looking for secrets inside encoded texts.
+// A MaxDepth of 0 stands for DefaultDecodingDepth, while a negative one turns decoding off.
+type DecodingConfig struct {
+	MaxDepth int `yaml:"max_depth,omitempty"`
+}
+
+// ArchiveConfig limits the unpacking of archives: how deeply archives within archives are unpacked,
+// and how many bytes a single entry and all the entries of the archives of a scan may hold. Sizes are in bytes.
+type ArchiveConfig struct {
+	MaxDepth     int   `yaml:"max_depth,omitempty"`
+	MaxEntrySize int64 `yaml:"max_entry_size,omitempty"`
+	MaxTotalSize int64 `yaml:"max_total_size,omitempty"`
+}
+
+// BinaryFilesConfig sets how binary files are handled. By default, only the checks of names, sizes and formats look at them.
+// ScanAsText is nil unless set, so that a .talismanrc can turn off what a file it includes turns on.
+type BinaryFilesConfig struct {
+	ScanAsText *bool `yaml:"scan_as_text,omitempty"`
+}
+
+// LFSConfig sets whether the content of Git LFS objects found in the local store is scanned in place of their pointers,
+// and the size in bytes above which objects are not scanned. A MaxObjectSize of 0 stands for DefaultLFSObjectSize.
+// ScanObjects is nil unless set, like BinaryFilesConfig.ScanAsText.
+type LFSConfig struct {
+	ScanObjects   *bool `yaml:"scan_objects,omitempty"`
+	MaxObjectSize int64 `yaml:"max_object_size,omitempty"`
 }
 
 type ExperimentalConfig struct {
@@ -7,8 +7,9 @@ import (
 	"github.com/cheggaaa/pb/v3"
 )
 
+// GetProgressBar returns a progress bar that renders to out if it is a terminal, or a no-op progress bar otherwise (including for a nil out)
 func GetProgressBar(out *os.File, title string) progressBar {
-	if isTerminal(out) {
+	if out != nil && isTerminal(out) {
 		return &defaultProgressBar{title: title}
 	} else {
 		return &noOpProgressBar{}
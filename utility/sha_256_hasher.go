@@ -3,6 +3,9 @@ package utility
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"talisman/gitrepo"
 
 	"github.com/sirupsen/logrus"
@@ -14,11 +17,19 @@ type SHA256Hasher interface {
 	Shutdown() error
 }
 
-type DefaultSHA256Hasher struct{}
+// DefaultSHA256Hasher hashes files from the file system, resolving relative paths from root (or the working directory if root is empty)
+type DefaultSHA256Hasher struct {
+	root string
+}
 
 // CollectiveSHA256Hash return collective sha256 hash of the passed paths
-func (*DefaultSHA256Hasher) CollectiveSHA256Hash(paths []string) string {
-	return collectiveSHA256Hash(paths, SafeReadFile)
+func (h *DefaultSHA256Hasher) CollectiveSHA256Hash(paths []string) string {
+	return collectiveSHA256Hash(paths, func(path string) ([]byte, error) {
+		if h.root != "" && !filepath.IsAbs(path) {
+			return SafeReadFile(filepath.Join(h.root, path))
+		}
+		return SafeReadFile(path)
+	})
 }
 
 func (*DefaultSHA256Hasher) Start() error    { return nil }
@@ -63,38 +74,74 @@ func collectiveSHA256Hash(paths []string, FileReader func(string) ([]byte, error
 	return m
 }
 
-var hashers = make(map[string]SHA256Hasher)
+// SHA256HashOfContents returns the checksum the hashers calculate for a single file with the given path and contents,
+// e.g. for a blob from the git history that is not present in the working tree
+func SHA256HashOfContents(path string, contents []byte) string {
+	return collectiveSHA256Hash([]string{path}, func(string) ([]byte, error) { return contents, nil })
+}
+
+// Hashers makes SHA256 file/object hashers, keeping a single started hasher per mode and repo for its owner to share.
+// Each run owns its Hashers and shuts them down with Shutdown once done, without affecting the hashers of other runs.
+// Hashers is safe for concurrent use.
+type Hashers struct {
+	lock    sync.Mutex
+	hashers map[string]SHA256Hasher
+}
+
+// NewHashers returns an empty set of hashers
+func NewHashers() *Hashers {
+	return &Hashers{hashers: make(map[string]SHA256Hasher)}
+}
 
-// MakeHasher returns a SHA256 file/object hasher based on mode and a repo root
-func MakeHasher(mode string, root string) SHA256Hasher {
-	if hashers[mode] != nil {
-		return hashers[mode]
+// Make returns a started SHA256 file/object hasher based on mode and a repo, reusing the hasher made earlier for them if any
+func (h *Hashers) Make(mode string, repo gitrepo.GitRepo) (SHA256Hasher, error) {
+	key := mode + ":" + repo.Root()
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.hashers[key] != nil {
+		return h.hashers[key], nil
 	}
+	hasher, err := newHasher(mode, repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s hasher: %v", mode, err)
+	}
+	if err := hasher.Start(); err != nil {
+		_ = hasher.Shutdown()
+		return nil, fmt.Errorf("unable to start %s hasher: %v", mode, err)
+	}
+	h.hashers[key] = hasher
+	return hasher, nil
+}
+
+func newHasher(mode string, repo gitrepo.GitRepo) (SHA256Hasher, error) {
+	var reader gitrepo.BatchReader
+	var err error
 	switch mode {
 	case "pre-push":
-		hashers[mode] = &gitBatchSHA256Hasher{gitrepo.NewBatchGitHeadPathReader(root)}
-	case "pre-commit":
-		hashers[mode] = &gitBatchSHA256Hasher{gitrepo.NewBatchGitStagedPathReader(root)}
+		reader, err = gitrepo.NewBatchGitHeadPathReader(repo)
+	case "pre-commit", "checksum":
+		reader, err = gitrepo.NewBatchGitStagedPathReader(repo)
 	case "scan":
-		hashers[mode] = &gitBatchSHA256Hasher{gitrepo.NewBatchGitObjectHashReader(root)}
-	case "pattern":
-		hashers[mode] = &DefaultSHA256Hasher{}
-	case "checksum":
-		hashers[mode] = &gitBatchSHA256Hasher{gitrepo.NewBatchGitStagedPathReader(root)}
-	case "default":
-		hashers[mode] = &DefaultSHA256Hasher{}
+		reader, err = gitrepo.NewBatchGitObjectHashReader(repo)
+	case "pattern", "default":
+		return &DefaultSHA256Hasher{repo.Root()}, nil
+	default:
+		return nil, fmt.Errorf("unknown mode %q", mode)
 	}
-	err := hashers[mode].Start()
 	if err != nil {
-		logrus.Errorf("unable to start hasher: %v", err)
-		return nil
+		return nil, err
 	}
-	return hashers[mode]
+	return &gitBatchSHA256Hasher{reader}, nil
 }
 
-func DestroyHashers() {
-	for _, hasher := range hashers {
-		hasher.Shutdown()
+// Shutdown shuts down all hashers made so far
+func (h *Hashers) Shutdown() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, hasher := range h.hashers {
+		if err := hasher.Shutdown(); err != nil {
+			logrus.Errorf("unable to shut down hasher: %v", err)
+		}
 	}
-	hashers = make(map[string]SHA256Hasher)
+	h.hashers = make(map[string]SHA256Hasher)
 }
@@ -1,8 +1,10 @@
 package utility
 
 import (
-	"github.com/stretchr/testify/assert"
+	"talisman/gitrepo"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestShouldReturnCorrectFileHash(t *testing.T) {
@@ -18,3 +20,34 @@ func TestShouldReturnEmptyFileHashWhenNoPathsPassed(t *testing.T) {
 	checksum := hasher.CollectiveSHA256Hash([]string{})
 	assert.Equal(t, checksum, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "Should be equal to empty hash value when no paths passed")
 }
+
+func TestHashersReturnErrorForUnknownMode(t *testing.T) {
+	_, err := NewHashers().Make("unknown", gitrepo.RepoLocatedAt("."))
+	assert.EqualError(t, err, `unable to create unknown hasher: unknown mode "unknown"`)
+}
+
+func TestHashersShareHashersSafelyAcrossGoroutines(t *testing.T) {
+	hashers := NewHashers()
+	defer hashers.Shutdown()
+	repo := gitrepo.RepoLocatedAt(".")
+	made := make(chan SHA256Hasher, 8)
+	for i := 0; i < cap(made); i++ {
+		go func() {
+			hasher, _ := hashers.Make("default", repo)
+			made <- hasher
+		}()
+	}
+
+	first := <-made
+	for i := 1; i < cap(made); i++ {
+		assert.Same(t, first, <-made)
+	}
+}
+
+func TestHashersAreNotSharedBetweenOwners(t *testing.T) {
+	repo := gitrepo.RepoLocatedAt(".")
+	first, _ := NewHashers().Make("default", repo)
+	second, _ := NewHashers().Make("default", repo)
+
+	assert.NotSame(t, first, second)
+}